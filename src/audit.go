@@ -0,0 +1,211 @@
+package main
+
+/* Tamper evident audit log
+Every entry is a single JSON line, chained with the previous entry sha256 hash,
+so removing or modifying any line breaks the chain and can be detected by `conan audit verify`.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// audit event names
+const (
+	AuditSecretDecrypt  = "secret.decrypt"
+	AuditConnect        = "connect"
+	AuditExportSettings = "settings.export"
+	AuditImportSettings = "settings.import"
+	AuditGistPush       = "gist.push"
+	AuditGistPull       = "gist.pull"
+	AuditNotesPush      = "notes.push"
+	AuditNotesPull      = "notes.pull"
+	AuditKeyChange      = "key.change"
+	AuditLock           = "app.lock"
+	AuditUnlock         = "app.unlock"
+	AuditNoteRestore    = "note.restore"
+	AuditImportServers  = "servers.import"
+	AuditExportServers  = "servers.export"
+)
+
+// AuditEntry is a single line of the audit log
+type AuditEntry struct {
+	Seq    int64     `json:"seq"`
+	Time   time.Time `json:"time"`
+	Event  string    `json:"event"`
+	User   string    `json:"user,omitempty"`
+	Host   string    `json:"host,omitempty"`
+	Source string    `json:"source,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+	Prev   string    `json:"prev"`
+	Hash   string    `json:"hash"`
+}
+
+// auditMu serializes the writers of the process, other processes are serialized by the file lock
+var auditMu sync.Mutex
+
+func auditLogPath() string {
+	return filepath.Join(env.configDir, "audit.log")
+}
+
+// computeHash returns the hash of the entry (with empty Hash field) chained with Prev
+func (e AuditEntry) computeHash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(append([]byte(e.Prev), data...))
+	return hex.EncodeToString(sum[:])
+}
+
+// auditEvent appends a new entry to the audit log, errors are only logged
+// because auditing should never break the main functionality, nothing is appended
+// when the last entry can't be read.
+// The tray, the command line and the askpass helper write the same log, so the last
+// entry is read again under the file lock before every append.
+func auditEvent(event, host, source, detail string) {
+	if env.configDir == "" {
+		return
+	}
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	f, err := os.OpenFile(auditLogPath(), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		log.Printf("Unable to open audit log: %s\n", err)
+		return
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		log.Printf("Unable to lock audit log: %s\n", err)
+		return
+	}
+	defer unlockFile(f)
+
+	// appending after the damaged entry would start a new chain that can't be told apart from tampering
+	last, err := lastAuditEntry(f)
+	if err != nil {
+		auditLog.Errorf("Audit log is damaged, %s entry is not written, check it with conan audit verify: %s", event, err)
+		return
+	}
+	entry := AuditEntry{
+		Seq:    last.Seq + 1,
+		Time:   time.Now().UTC(),
+		Event:  event,
+		User:   currentUsername(),
+		Host:   host,
+		Source: source,
+		Detail: detail,
+		Prev:   last.Hash,
+	}
+	entry.Hash = entry.computeHash()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Unable to marshal audit entry: %s\n", err)
+		return
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		log.Printf("Unable to write audit log: %s\n", err)
+	}
+}
+
+// lastAuditEntry returns the last entry of the open audit log, it reads only the tail of the file
+func lastAuditEntry(f *os.File) (AuditEntry, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return AuditEntry{}, err
+	}
+	const chunk = 4096
+	end := info.Size()
+	var tail []byte
+	for pos := end; pos > 0; {
+		n := int64(chunk)
+		if pos < n {
+			n = pos
+		}
+		pos -= n
+		buf := make([]byte, n)
+		if _, err := f.ReadAt(buf, pos); err != nil {
+			return AuditEntry{}, err
+		}
+		tail = append(buf, tail...)
+		trimmed := bytes.TrimRight(tail, "\r\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 || pos == 0 {
+			line := trimmed[i+1:]
+			if len(line) == 0 {
+				return AuditEntry{}, nil
+			}
+			var e AuditEntry
+			if err := json.Unmarshal(line, &e); err != nil {
+				return AuditEntry{}, fmt.Errorf("last entry: %w", err)
+			}
+			return e, nil
+		}
+	}
+	return AuditEntry{}, nil
+}
+
+// readAuditLog returns all entries of the audit log
+func readAuditLog() ([]AuditEntry, error) {
+	f, err := os.Open(auditLogPath())
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// verifyAuditLog checks the hash chain, returns the number of verified entries
+// or error describing the first broken entry
+func verifyAuditLog() (int, error) {
+	entries, err := readAuditLog()
+	if err != nil {
+		return 0, err
+	}
+	prev := ""
+	for i, e := range entries {
+		if e.Prev != prev {
+			return i, fmt.Errorf("entry #%d (seq %d): chain broken, previous hash mismatch (entry removed or reordered?)", i+1, e.Seq)
+		}
+		if e.computeHash() != e.Hash {
+			return i, fmt.Errorf("entry #%d (seq %d): hash mismatch (entry modified?)", i+1, e.Seq)
+		}
+		if i > 0 && e.Seq != entries[i-1].Seq+1 {
+			return i, fmt.Errorf("entry #%d (seq %d): sequence gap after %d", i+1, e.Seq, entries[i-1].Seq)
+		}
+		prev = e.Hash
+	}
+	return len(entries), nil
+}
+
+func currentUsername() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mappu/miqt/qt"
+)
+
+var (
+	clipboardMu     sync.Mutex
+	clipboardTimer  *time.Timer
+	clipboardSecret string // secret waiting to be wiped
+)
+
+// copyToClipboard puts text into the system clipboard, in GUI mode it uses Qt clipboard
+// and in terminal mode it uses OSC52 escape sequence (works over ssh too)
+func copyToClipboard(text string) {
+	if GUIMODE {
+		qtCopyToClipboard(text)
+		return
+	}
+	if err := osc52Copy(text); err != nil {
+		guiLog.Errorf("Unable to copy to clipboard: %s", err)
+	}
+}
+
+// copySecretToClipboard copies a password (or other secret) and wipes it from the
+// clipboard after settings.ClipboardClear seconds, if it was not replaced meanwhile
+func copySecretToClipboard(text string) {
+	copyToClipboard(text)
+
+	clipboardMu.Lock()
+	defer clipboardMu.Unlock()
+	if clipboardTimer != nil {
+		clipboardTimer.Stop()
+		clipboardTimer = nil
+	}
+	if settings.ClipboardClear <= 0 {
+		return
+	}
+	clipboardSecret = text
+	clipboardTimer = time.AfterFunc(time.Duration(settings.ClipboardClear)*time.Second, func() {
+		clipboardMu.Lock()
+		clipboardSecret = ""
+		clipboardMu.Unlock()
+		clearClipboard(text)
+	})
+}
+
+// flushClipboardSecret wipes pending secret from the clipboard right now
+func flushClipboardSecret() {
+	clipboardMu.Lock()
+	secret := clipboardSecret
+	clipboardSecret = ""
+	if clipboardTimer != nil {
+		clipboardTimer.Stop()
+		clipboardTimer = nil
+	}
+	clipboardMu.Unlock()
+	if secret != "" {
+		clearClipboard(secret)
+	}
+}
+
+// clearClipboard clears the clipboard if it still holds the given secret
+func clearClipboard(secret string) {
+	if GUIMODE {
+		CallOnQtMain(func() {
+			clipboard := qt.QGuiApplication_Clipboard()
+			if clipboard != nil && clipboard.Text() == secret {
+				clipboard.Clear()
+				guiLog.Infof("Clipboard has been cleared")
+			}
+		})
+		return
+	}
+	// terminal clipboard can't be read back, just overwrite it
+	wipe := func() {
+		if err := osc52Copy(""); err != nil {
+			guiLog.Errorf("Unable to clear clipboard: %s", err)
+		}
+	}
+	// the timer must not write to the terminal while tview draws on it
+	if tuiRunning.Load() {
+		appbase.QueueUpdate(wipe)
+		return
+	}
+	wipe()
+}
+
+// qtCopyToClipboard must be called from Qt main thread
+func qtCopyToClipboard(text string) {
+	clipboard := qt.QGuiApplication_Clipboard()
+	if clipboard == nil {
+		guiLog.Warnf("Clipboard is not available")
+		return
+	}
+	clipboard.SetText(text)
+}
+
+// qtCopyServerField copies the selected server field and notifies via tray message
+func qtCopyServerField(srv Server, field string) {
+	var text string
+	secret := false
+	switch field {
+	case "password":
+		text = srv.DecryptPassword()
+		secret = true
+	case "user":
+		text = srv.User
+	case "ip":
+		text = srv.Address()
+	case "uri":
+		text = srv.ConnectionString()
+	}
+	if text == "" {
+		QTshowWarn(nil, "Info", "Server "+srv.Host+" has no "+field+" defined")
+		return
+	}
+	if secret {
+		copySecretToClipboard(text)
+	} else {
+		qtCopyToClipboard(text)
+	}
+	if tray != nil {
+		msg := "Copied " + field + " of " + srv.Host + " to clipboard"
+		if secret && settings.ClipboardClear > 0 {
+			msg += fmt.Sprintf(" (clears in %ds)", settings.ClipboardClear)
+		}
+		tray.ShowMessage2("Conan", msg)
+	}
+}
+
+// qtAddCopyMenu adds "Copy" submenu with server fields to the given context menu
+func qtAddCopyMenu(menu *qt.QMenu, srv Server) {
+	copyMenu := menu.AddMenuWithTitle("Copy")
+	copyMenu.AddAction("Password").OnTriggered(func() { qtCopyServerField(srv, "password") })
+	copyMenu.AddAction("User").OnTriggered(func() { qtCopyServerField(srv, "user") })
+	copyMenu.AddAction("IP").OnTriggered(func() { qtCopyServerField(srv, "ip") })
+	copyMenu.AddAction("Connection string").OnTriggered(func() { qtCopyServerField(srv, "uri") })
+	otpAction := copyMenu.AddAction("OTP")
+	otpAction.SetEnabled(srv.TOTP != "")
+	otpAction.OnTriggered(func() { qtCopyOTP(srv) })
+}
+
+// osc52Copy writes the OSC52 clipboard sequence directly to the terminal
+func osc52Copy(text string) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		// windows terminal or no controlling terminal, fallback to stdout
+		tty = os.Stdout
+	} else {
+		defer tty.Close()
+	}
+	seq := fmt.Sprintf("\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString([]byte(text)))
+	// tmux requires passthrough wrapping
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;\x1b" + seq + "\x1b\\"
+	}
+	_, err = tty.WriteString(seq)
+	return err
+}
@@ -127,7 +127,7 @@ func (s *configStore) save() error {
 func (s *configStore) Set(section, key string, value interface{}) error {
 	err := s.Reload()
 	if err != nil {
-		log.Printf("failed to reload settings file: %w", err)
+		log.Printf("failed to reload settings file: %s", err)
 	}
 	sec := s.cfg.Section(section)
 	var val string
@@ -162,7 +162,7 @@ Store.SetMany("Window-Notes", map[string]interface{}{
 func (s *configStore) SetMany(section string, values map[string]interface{}) error {
 	err := s.Reload()
 	if err != nil {
-		log.Printf("failed to reload settings file: %w", err)
+		log.Printf("failed to reload settings file: %s", err)
 	}
 	sec := s.cfg.Section(section)
 	for key, val := range values {
@@ -190,6 +190,44 @@ func (s *configStore) HasSection(section string) bool {
 	return s.cfg.HasSection(section)
 }
 
+// names of all sections
+func (s *configStore) Sections() []string {
+	_ = s.Reload()
+	return s.cfg.SectionStrings()
+}
+
+// delete section with all keys
+func (s *configStore) DeleteSection(section string) error {
+	err := s.Reload()
+	if err != nil {
+		log.Printf("failed to reload settings file: %s", err)
+	}
+	if !s.cfg.HasSection(section) {
+		return nil
+	}
+	s.cfg.DeleteSection(section)
+	return s.save()
+}
+
+// rename section, keys of the existing target section are replaced
+func (s *configStore) RenameSection(oldName, newName string) error {
+	err := s.Reload()
+	if err != nil {
+		log.Printf("failed to reload settings file: %s", err)
+	}
+	if !s.cfg.HasSection(oldName) {
+		return nil
+	}
+	keys := s.cfg.Section(oldName).KeysHash()
+	s.cfg.DeleteSection(newName)
+	sec := s.cfg.Section(newName)
+	for k, v := range keys {
+		sec.Key(k).SetValue(v)
+	}
+	s.cfg.DeleteSection(oldName)
+	return s.save()
+}
+
 // check if section and key exists
 func (s *configStore) HasKey(section, key string) bool {
 	_ = s.Reload()
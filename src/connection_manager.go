@@ -1,29 +1,172 @@
 package main
 
 import (
-	// "fmt"
 	"bufio"
 	"bytes"
-	"html/template"
-	"log"
+	"fmt"
+	"os"
 	"os/exec"
 	"runtime"
 	"strings"
+	"text/template"
 )
 
 func GetOS() string {
 	return runtime.GOOS
 }
 
+// connectAttached runs the client in the terminal of conan, set by conan connect
+var connectAttached bool
+
+// connectCommandKeys are the settings of the command line templates of the server types
+var connectCommandKeys = map[string]string{"SSH": "SSHCommand", "RDP": "RDPCommand", "WINBOX": "WINBOXCommand"}
+
+// ConnectCommandLine returns the command ClientConnect runs for the server, password and OTP are redacted
+func ConnectCommandLine(srv Server) (string, error) {
+	tp, ok := connectCommandKeys[srv.Type]
+	if !ok {
+		return "", fmt.Errorf("%s servers are not supported yet", srv.Type)
+	}
+	if srv.Type == "SSH" && (settings.SSHClient == "putty" || settings.SSHClient == "iTerm") {
+		return "", fmt.Errorf("command of the %s ssh client can't be printed", settings.SSHClient)
+	}
+	// secrets are not decrypted, placeholders are printed instead
+	cmdline, _, _, ok := connectCommandLine(srv, tp, true)
+	if !ok {
+		return "", fmt.Errorf("%s is not set in the settings", tp)
+	}
+	return cmdline, nil
+}
+
+// redactCommand hides password and OTP in the command line
+func redactCommand(cmdline, password, otp string) string {
+	if password != "" {
+		cmdline = strings.Replace(cmdline, password, "<redacted>", 1)
+	}
+	if otp != "" {
+		cmdline = strings.Replace(cmdline, otp, "<otp>", 1)
+	}
+	return cmdline
+}
+
 func ConnectCommand(srv Server, tp string) {
-	raw, ok := getStructField(settings, tp)
+	cmdline, Password, OTP, ok := connectCommandLine(srv, tp, false)
 	if !ok {
-		log.Printf("You have not declared key: %s in settings for running %s command", tp)
 		return
 	}
+	auditEvent(AuditConnect, srv.Host, srv.SourceName, tp)
+
+	if Password != "" || OTP != "" {
+		connectLog.Infof("Executing command: %s", redactCommand(cmdline, Password, OTP))
+	}
+	cmdarr := strings.Split(cmdline, " ")
+
+	cmd := exec.Command(cmdarr[0], cmdarr[1:]...)
+
+	if connectAttached {
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			connectLog.Errorf("Command finished with error: %v", err)
+		}
+		return
+	}
+
+	// Create pipes for stdout and stderr
+	stdout, _ := cmd.StdoutPipe()
+	stderr, _ := cmd.StderrPipe()
+
+	// Start the command
+	if err := cmd.Start(); err != nil {
+		connectLog.Errorf("Failed to start: %v", err)
+		return
+	}
+
+	// Log stdout
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			connectLog.Debugf("[stdout] %s", scanner.Text())
+		}
+	}()
+
+	// Log stderr
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			connectLog.Debugf("[stderr] %s", scanner.Text())
+		}
+	}()
+
+	// Wait for the command to complete
+	if err := cmd.Wait(); err != nil {
+		connectLog.Errorf("Command finished with error: %v", err)
+	} else {
+		connectLog.Infof("Command finished successfully")
+	}
+
+}
+
+func ClientConnect(srv Server) {
+	// enumarate between types, ssh, telnet etc...
+	connectLog.Infof("Connecting to server %s", srv.Host)
+	if GUIMODE && settings.NotesSettings.PopupOnConnect {
+		CallOnQtMain(func() {
+			popupLinkedNotes(srv)
+		})
+	}
+	switch srv.Type {
+	case "SSH":
+		if settings.SSHClient == "putty" {
+			sshConnectPutty(srv)
+		} else if settings.SSHClient == "iTerm" {
+			sshConnectIterm(srv)
+		} else {
+			ConnectCommand(srv, "SSHCommand")
+		}
+	case "RDP":
+		ConnectCommand(srv, "RDPCommand")
+	case "WINBOX":
+		ConnectCommand(srv, "WINBOXCommand")
+	default:
+		connectLog.Warnf("This type of server is not supported yet!")
+		if GUIMODE {
+			CallOnQtMain(func() {
+				QTshowError(nil, "Error", "This type of server is not supported yet!")
+			})
+		}
+		return
+	}
+}
+
+// connectCommandLine renders the command line template tp of the settings for the server,
+// returns also the password and OTP used in it, redacted uses placeholders instead of the secrets
+func connectCommandLine(srv Server, tp string, redacted bool) (string, string, string, bool) {
+	raw, ok := getStructField(settings, tp)
+	if !ok {
+		connectLog.Warnf("You have not declared key: %s in settings for running %s command", tp, srv.Type)
+		return "", "", "", false
+	}
 
 	server := srv
-	Password := srv.DecryptPassword()
+	Password := ""
+	OTP := ""
+	if redacted {
+		if srv.Password != "" {
+			Password = "<redacted>"
+		}
+		if srv.TOTP != "" {
+			OTP = "<otp>"
+		}
+	} else {
+		Password = srv.DecryptPassword()
+		if srv.TOTP != "" {
+			code, err := srv.OTP()
+			if err != nil {
+				connectLog.Errorf("Unable to generate OTP for %s: %s", srv.Host, err)
+			}
+			OTP = code
+		}
+	}
 	if server.User == "" {
 		switch GetOS() {
 		case "windows":
@@ -49,13 +192,13 @@ func ConnectCommand(srv Server, tp string) {
 			Option("missingkey=default").
 			Parse(tpl)
 		if err != nil {
-			log.Printf("Invalid defaultsshkey template in settings.ini (parse): %v — using literal", err)
+			connectLog.Warnf("Invalid defaultsshkey template in settings.ini (parse): %v — using literal", err)
 			return tpl
 		}
 
 		var buf bytes.Buffer
 		if err := tmpl.Execute(&buf, sshkeytpl); err != nil {
-			log.Printf("Invalid defaultsshkey template in settings.ini (exec): %v — using literal", err)
+			connectLog.Warnf("Invalid defaultsshkey template in settings.ini (exec): %v — using literal", err)
 			return tpl
 		}
 
@@ -66,6 +209,7 @@ func ConnectCommand(srv Server, tp string) {
 	data := struct {
 		Server
 		Password   string
+		OTP        string
 		Home       string
 		AppDir     string
 		ConfigDir  string
@@ -73,6 +217,7 @@ func ConnectCommand(srv Server, tp string) {
 	}{
 		Server:     server,
 		Password:   Password,
+		OTP:        OTP,
 		Home:       env.homeDir,
 		AppDir:     env.appPath,
 		ConfigDir:  env.configDir,
@@ -84,86 +229,17 @@ func ConnectCommand(srv Server, tp string) {
 			Option("missingkey=default").
 			Parse(tpl)
 		if err != nil {
-			log.Printf("Invalid Command line parameters template in settings.ini (parse): %v — using literal", err)
+			connectLog.Warnf("Invalid Command line parameters template in settings.ini (parse): %v — using literal", err)
 			return tpl
 		}
 
 		var buf bytes.Buffer
 		if err := tmpl.Execute(&buf, data); err != nil {
-			log.Printf("Invalid Command line parameters template in settings.ini (exec): %v — using literal", err)
+			connectLog.Warnf("Invalid Command line parameters template in settings.ini (exec): %v — using literal", err)
 			return tpl
 		}
 		return buf.String()
 	}
 
-	cmdline := cmdparams(raw)
-
-	if Password != "" {
-		redacted := strings.Replace(cmdline, Password, "<redacted>", 1)
-		log.Printf("Executing command: %s\n", redacted)
-	}
-	cmdarr := strings.Split(cmdline, " ")
-
-	cmd := exec.Command(cmdarr[0], cmdarr[1:]...)
-
-	// Create pipes for stdout and stderr
-	stdout, _ := cmd.StdoutPipe()
-	stderr, _ := cmd.StderrPipe()
-
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		log.Printf("Failed to start: %v\n", err)
-		return
-	}
-
-	// Log stdout
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			log.Printf("[stdout] %s\n", scanner.Text())
-		}
-	}()
-
-	// Log stderr
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			log.Printf("[stderr] %s\n", scanner.Text())
-		}
-	}()
-
-	// Wait for the command to complete
-	if err := cmd.Wait(); err != nil {
-		log.Printf("Command finished with error: %v\n", err)
-	} else {
-		log.Printf("Command finished successfully")
-	}
-
-}
-
-func ClientConnect(srv Server) {
-	// enumarate between types, ssh, telnet etc...
-	log.Printf("Connecting to server %s\n", srv.Host)
-	switch srv.Type {
-	case "SSH":
-		if settings.SSHClient == "putty" {
-			sshConnectPutty(srv)
-		} else if settings.SSHClient == "iTerm" {
-			sshConnectIterm(srv)
-		} else {
-			ConnectCommand(srv, "SSHCommand")
-		}
-	case "RDP":
-		ConnectCommand(srv, "RDPCommand")
-	case "WINBOX":
-		ConnectCommand(srv, "WINBOXCommand")
-	default:
-		log.Printf("This type of server is not supported yet!\n")
-		if GUIMODE {
-			CallOnQtMain(func() {
-				QTshowError(nil, "Error", "This type of server is not supported yet!")
-			})
-		}
-		return
-	}
+	return cmdparams(raw), Password, OTP, true
 }
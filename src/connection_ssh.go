@@ -3,7 +3,6 @@ package main
 import (
 	"bytes"
 	"html/template"
-	"log"
 )
 
 func CmdParseTemplate(input string) string {
@@ -22,13 +21,13 @@ func CmdParseTemplate(input string) string {
 			Option("missingkey=default").
 			Parse(tpl)
 		if err != nil {
-			log.Printf("Invalid cmdline template (parse): %v — using literal", err)
+			connectLog.Warnf("Invalid cmdline template (parse): %v — using literal", err)
 			return tpl
 		}
 
 		var buf bytes.Buffer
 		if err := tmpl.Execute(&buf, cmdtpl); err != nil {
-			log.Printf("Invalid cmdline template (exec): %v — using literal", err)
+			connectLog.Warnf("Invalid cmdline template (exec): %v — using literal", err)
 			return tpl
 		}
 
@@ -13,7 +13,7 @@ import (
 // connectionSSHITerm is a placeholder for iTerm2 specific SSH connection handling.
 func sshConnectIterm(server Server) error {
 	// iTerm2 specific SSH connection logic goes here.
-	log.Printf("Connecting to server %s using iTerm2 specific logic", server.Host)
+	connectLog.Infof("Connecting to server %s using iTerm2 specific logic", server.Host)
 
 	// Build user@host
 	target := server.IP
@@ -47,6 +47,9 @@ func sshConnectIterm(server Server) error {
 	if server.Port != "" {
 		args = append(args, "-p", server.Port)
 	}
+	if server.Jump != "" {
+		args = append(args, "-J", server.Jump)
+	}
 
 	// no strict host key checking
 	// This is not recommended for production use, but useful for testing.
@@ -65,14 +68,14 @@ func sshConnectIterm(server Server) error {
 	if key != "" {
 		key = CmdParseTemplate(key)
 		if _, err := os.Stat(key); err == nil {
-			log.Printf("Found private key at: %s\n", key)
+			connectLog.Infof("Found private key at: %s", key)
 		} else {
 			searchPaths := []string{env.appPath, filepath.Join(env.homeDir, ".ssh"), env.configDir}
 			privatekey, err := FindFileInPaths(key, searchPaths)
 			if err == nil {
 				key = privatekey
 			} else {
-				log.Printf("Error finding private key: %s: %s\n", key, err)
+				connectLog.Errorf("Error finding private key: %s: %s", key, err)
 			}
 		}
 		args = append(args, "-i", key)
@@ -103,8 +106,10 @@ func sshConnectIterm(server Server) error {
 		return fmt.Errorf("failed to start osascript: %w", err)
 	}
 
+	auditEvent(AuditConnect, server.Host, server.SourceName, "iTerm")
+
 	// Log and return without waiting
-	log.Printf("SSH command dispatched to iTerm: %s", fullCommand)
+	connectLog.Infof("SSH command dispatched to iTerm: %s", fullCommand)
 
 	return nil
 }
@@ -3,7 +3,6 @@ package main
 import (
 	"bufio"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -14,7 +13,7 @@ import (
 )
 
 func sshConnectPutty(srv Server) {
-	log.Printf("Putty handling function reached\n")
+	connectLog.Infof("Putty handling function reached")
 	args := []string{}
 	var privkey string
 	var user string
@@ -36,14 +35,14 @@ func sshConnectPutty(srv Server) {
 	if privkey != "" {
 		privkey = CmdParseTemplate(privkey)
 		if _, err := os.Stat(privkey); err == nil {
-			log.Printf("Found private key at: %s\n", privkey)
+			connectLog.Infof("Found private key at: %s", privkey)
 		} else {
 			searchPaths := []string{env.appPath, filepath.Join(env.homeDir, ".ssh"), env.configDir}
 			privatekey, err := FindFileInPaths(privkey, searchPaths)
 			if err == nil {
 				privkey = privatekey
 			} else {
-				log.Printf("Error finding private key: %s: %s\n", privkey, err)
+				connectLog.Errorf("Error finding private key: %s: %s", privkey, err)
 			}
 		}
 	}
@@ -78,7 +77,7 @@ func sshConnectPutty(srv Server) {
 			"windows")}
 	putty, err := FindFileInPaths("putty.exe", puttyPaths)
 	if err != nil {
-		log.Printf("Unable to determine where the putty.exe exists... %s\n", err)
+		connectLog.Errorf("Unable to determine where the putty.exe exists... %s", err)
 	}
 
 	args = append(args, "-ssh")
@@ -104,7 +103,8 @@ func sshConnectPutty(srv Server) {
 		cmdline = strings.Replace(cmdline, password, "<redacted>", 1)
 	}
 
-	log.Printf("Executing command: %s %s\n", putty, cmdline)
+	connectLog.Infof("Executing command: %s %s", putty, cmdline)
+	auditEvent(AuditConnect, srv.Host, srv.SourceName, "putty")
 
 	cmd := exec.Command(putty, args...)
 	// Create pipes for stdout and stderr
@@ -113,7 +113,7 @@ func sshConnectPutty(srv Server) {
 
 	// Start the command
 	if err := cmd.Start(); err != nil {
-		log.Printf("Failed to start: %v\n", err)
+		connectLog.Errorf("Failed to start: %v", err)
 		return
 	}
 
@@ -121,7 +121,7 @@ func sshConnectPutty(srv Server) {
 	go func() {
 		scanner := bufio.NewScanner(stdout)
 		for scanner.Scan() {
-			log.Printf("[stdout] %s\n", scanner.Text())
+			connectLog.Debugf("[stdout] %s", scanner.Text())
 		}
 	}()
 
@@ -129,7 +129,7 @@ func sshConnectPutty(srv Server) {
 	go func() {
 		scanner := bufio.NewScanner(stderr)
 		for scanner.Scan() {
-			log.Printf("[stderr] %s\n", scanner.Text())
+			connectLog.Debugf("[stderr] %s", scanner.Text())
 		}
 	}()
 
@@ -138,17 +138,17 @@ func sshConnectPutty(srv Server) {
 			time.Sleep(2 * time.Second)
 			err = os.Remove(passFullPath)
 			if err != nil {
-				log.Printf("Error removing file: %s\n", err)
+				connectLog.Errorf("Error removing file: %s", err)
 				return
 			}
-			log.Printf("Temp file deleted")
+			connectLog.Infof("Temp file deleted")
 		}()
 	}
 
 	// Wait for the command to complete
 	if err := cmd.Wait(); err != nil {
-		log.Printf("Command finished with error: %v\n", err)
+		connectLog.Errorf("Command finished with error: %v", err)
 	} else {
-		log.Printf("Command finished successfully")
+		connectLog.Infof("Command finished successfully")
 	}
 }
@@ -42,6 +42,7 @@ func IgnoreSignum() {
 }
 
 func darwin_bindkey() {
+	go darwin_bindCaptureKey()
 	hk := hotkey.New([]hotkey.Modifier{hotkey.ModCtrl}, hotkey.KeySpace)
 
 	err := hk.Register()
@@ -56,3 +57,15 @@ func darwin_bindkey() {
 		uiCmdChan <- "show"
 	}
 }
+
+// darwin_bindCaptureKey opens quick capture on ctrl+shift+space
+func darwin_bindCaptureKey() {
+	hk := hotkey.New([]hotkey.Modifier{hotkey.ModCtrl, hotkey.ModShift}, hotkey.KeySpace)
+	if err := hk.Register(); err != nil {
+		notesLog.Warnf("hotkey: failed to register quick capture hotkey: %v", err)
+		return
+	}
+	for range hk.Keydown() {
+		uiCmdChan <- "capture"
+	}
+}
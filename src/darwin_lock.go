@@ -0,0 +1,40 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+/*
+#cgo LDFLAGS: -framework CoreGraphics -framework CoreFoundation
+#include <CoreGraphics/CoreGraphics.h>
+#include <CoreFoundation/CoreFoundation.h>
+
+int screenIsLocked() {
+    CFDictionaryRef dict = CGSessionCopyCurrentDictionary();
+    if (dict == NULL) {
+        return 0;
+    }
+    int locked = 0;
+    CFBooleanRef value = (CFBooleanRef)CFDictionaryGetValue(dict, CFSTR("CGSSessionScreenIsLocked"));
+    if (value != NULL && CFBooleanGetValue(value)) {
+        locked = 1;
+    }
+    CFRelease(dict);
+    return locked;
+}
+*/
+import "C"
+
+import "time"
+
+// darwin_watchScreenLock polls the session dictionary for the screen lock flag
+func darwin_watchScreenLock(onLock func()) {
+	locked := false
+	for {
+		now := C.screenIsLocked() == 1
+		if now && !locked {
+			onLock()
+		}
+		locked = now
+		time.Sleep(2 * time.Second)
+	}
+}
@@ -18,3 +18,7 @@ func IgnoreSignum() {
 func darwin_bindkey() {
 	// dummy version
 }
+
+func darwin_watchScreenLock(onLock func()) {
+	// dummy version
+}
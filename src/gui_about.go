@@ -1,8 +1,6 @@
 package main
 
 import (
-	"log"
-
 	"github.com/mappu/miqt/qt"
 )
 
@@ -19,7 +17,7 @@ func showAboutQt(parent *qt.QWidget) {
 		return
 	}
 
-	log.Printf("Loading about screen...\n")
+	guiLog.Debugf("Loading about screen...")
 	aboutWin = qt.NewQDialog(parent)
 	aboutWin.SetWindowTitle("About " + appName)
 	aboutWin.Resize(350, 400)
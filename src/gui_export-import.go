@@ -80,6 +80,7 @@ func exportConfig(parent *qt.QWidget) {
 				QTshowWarn(parent, "Error", "Could not write file: "+err.Error())
 				return
 			}
+			auditEvent(AuditExportSettings, "", filename, "gui")
 			QTshowInfo(parent, "Export Complete", "Settings exported successfully.")
 		}
 	})
@@ -238,6 +239,7 @@ func importConfig(parent *qt.QWidget) {
 		QTshowWarn(parent, "Error", fmt.Sprintf("Failed to decompress settings: %v", err))
 		return
 	}
+	auditEvent(AuditImportSettings, "", filePath, "gui")
 
 	// 6. Confirm dialog for restart
 	confirmDlg := qt.NewQMessageBox6(qt.QMessageBox__Information, "Imported", "Settings imported. Restart now?", qt.QMessageBox__Yes|qt.QMessageBox__No, parent)
@@ -1,15 +1,18 @@
 package main
 
 import (
-	"log"
 	"strings"
 
 	"github.com/lithammer/fuzzysearch/fuzzy"
 	"github.com/mappu/miqt/qt"
 )
 
+// spotlight query prefix to search notes instead of servers
+const notesSearchPrefix = "?"
+
 var (
 	filteredItems []Server
+	filteredNotes []NoteSearchResult // results when query starts with notesSearchPrefix
 	listWidget    *qt.QListWidget
 	entry         *qt.QLineEdit
 	searchWindow  *qt.QWidget
@@ -31,7 +34,7 @@ func showFuzzySearchWindow() {
 		entry.SetFocus()
 		return
 	}
-	searchWindow := qt.NewQWidget(nil)
+	searchWindow = qt.NewQWidget(nil)
 	searchWindow.SetWindowTitle("")
 	searchWindow.SetWindowIcon(globalIcon)
 	searchWindow.Resize(520, 340)
@@ -49,7 +52,7 @@ func showFuzzySearchWindow() {
 	searchWindow.Move(x, y)
 
 	entry = qt.NewQLineEdit(nil)
-	entry.SetPlaceholderText("Type to search...")
+	entry.SetPlaceholderText("Type to search... (" + notesSearchPrefix + " for notes)")
 	font := qt.NewQFont6("Helvetica Neue", 21)
 	font.SetBold(true)
 	//, 21, int(qt.QFont__Normal), false)
@@ -110,8 +113,11 @@ func showFuzzySearchWindow() {
 	// Handle return key in entry
 	entry.OnReturnPressed(func() {
 		row := listWidget.CurrentRow()
+		if openSpotlightNote(row) {
+			return
+		}
 		if row >= 0 && row < len(filteredItems) {
-			log.Printf("Connecting to %s\n", filteredItems[row].Host)
+			guiLog.Debugf("Connecting to %s", filteredItems[row].Host)
 			ConnectCommand(filteredItems[row])
 		}
 	})
@@ -119,8 +125,11 @@ func showFuzzySearchWindow() {
 	// Handle list activation (double-click or Enter)
 	listWidget.OnItemActivated(func(item *qt.QListWidgetItem) {
 		row := listWidget.CurrentRow()
+		if openSpotlightNote(row) {
+			return
+		}
 		if row >= 0 && row < len(filteredItems) {
-			log.Printf("Connecting to %s\n", filteredItems[row].Host)
+			guiLog.Debugf("Connecting to %s", filteredItems[row].Host)
 			ConnectCommand(filteredItems[row])
 		}
 		entry.SetFocus() // return focus to entry
@@ -146,6 +155,40 @@ func showFuzzySearchWindow() {
 	// Optional: ESC closes window (capture keypresses on entry)
 
 	entry.OnKeyPressEvent(func(super func(param1 *qt.QKeyEvent), param1 *qt.QKeyEvent) {
+		// Ctrl+O copies the one time password of the selected server
+		if param1.Key() == int(qt.Key_O) && param1.Modifiers()&qt.ControlModifier != 0 {
+			row := listWidget.CurrentRow()
+			if row >= 0 && row < len(filteredItems) {
+				qtCopyOTP(filteredItems[row])
+				searchWindow.Hide()
+			}
+			return
+		}
+		// Enter with modifiers copies server details instead of connecting:
+		// Shift+Enter password, Ctrl+Enter connection string, Alt+Enter IP, Ctrl+Shift+Enter user
+		if (param1.Key() == int(qt.Key_Return) || param1.Key() == int(qt.Key_Enter)) && param1.Modifiers() != qt.NoModifier {
+			row := listWidget.CurrentRow()
+			if row < 0 || row >= len(filteredItems) {
+				return
+			}
+			mods := param1.Modifiers() &^ qt.KeypadModifier
+			field := ""
+			switch {
+			case mods == qt.ShiftModifier:
+				field = "password"
+			case mods == qt.ControlModifier:
+				field = "uri"
+			case mods == qt.AltModifier:
+				field = "ip"
+			case mods == qt.ControlModifier|qt.ShiftModifier:
+				field = "user"
+			}
+			if field != "" {
+				qtCopyServerField(filteredItems[row], field)
+				searchWindow.Hide()
+				return
+			}
+		}
 		switch param1.Key() {
 		case int(qt.Key_Escape):
 			searchWindow.Hide()
@@ -176,6 +219,20 @@ func showFuzzySearchWindow() {
 func updateFuzzyList(query string) {
 	query = strings.ToLower(query)
 	listWidget.Clear()
+	filteredNotes = nil
+	if strings.HasPrefix(query, notesSearchPrefix) {
+		filteredItems = nil
+		filteredNotes = searchAllNotes(strings.TrimPrefix(query, notesSearchPrefix))
+		labelRefs = nil
+		for _, n := range filteredNotes {
+			addFuzzyListItem("📝 "+n.Title, n.Snippet)
+		}
+		if len(filteredNotes) > 0 {
+			listWidget.SetCurrentRow(0)
+		}
+		updateSelectionColors()
+		return
+	}
 	if query == "" {
 		filteredItems = servers
 	} else {
@@ -192,45 +249,11 @@ func updateFuzzyList(query string) {
 	labelRefs = nil // reset before refilling
 
 	for _, s := range filteredItems {
-		// Create a new empty QListWidgetItem
-		item := qt.NewQListWidgetItem()
-		item.SetSizeHint(qt.NewQSize2(500, 56)) // Adjust height as needed
-
-		// Must use AddItemWithItem() in miqt instead of AddItem()
-		listWidget.AddItemWithItem(item)
-
-		// Create QWidget to embed
-		widget := qt.NewQWidget(nil)
-		layout := qt.NewQVBoxLayout(nil)
-		layout.SetContentsMargins(10, 6, 10, 6)
-		layout.SetSpacing(2) // spacing between host and description
-
-		// Host label (bold, larger font)
-		hostLabel := qt.NewQLabel3(s.Host)
-		hostFont := qt.NewQFont()
-		hostFont.SetPointSize(16)
-		hostFont.SetBold(true)
-		hostLabel.SetFont(hostFont)
-		hostLabel.SetStyleSheet("color: black")
-
-		// Description label (smaller, gray)
-		descLabel := qt.NewQLabel3(s.Description)
-		descFont := qt.NewQFont()
-		descFont.SetPointSize(12)
-		descLabel.SetFont(descFont)
-		descLabel.SetStyleSheet("color: #555555") // darker gray
-
-		layout.AddWidget(hostLabel.QWidget)
-		layout.AddWidget(descLabel.QWidget)
-		widget.SetLayout(layout.QLayout)
-
-		// Associate the custom widget with the item
-		listWidget.SetItemWidget(item, widget)
-		// Save label pointers for later selection handling
-		labelRefs = append(labelRefs, &itemLabels{
-			host: hostLabel,
-			desc: descLabel,
-		})
+		desc := s.Description
+		if links := linkedNotes(s); len(links) > 0 {
+			desc += "  📓 " + linkedNoteTitles(links)
+		}
+		addFuzzyListItem(s.Host, desc)
 	}
 	if len(filteredItems) > 0 {
 		listWidget.SetCurrentRow(0)
@@ -238,6 +261,62 @@ func updateFuzzyList(query string) {
 	updateSelectionColors() // <<<< manually apply correct styles
 }
 
+// addFuzzyListItem appends a two line (title and description) item to the list
+func addFuzzyListItem(title, description string) {
+	// Create a new empty QListWidgetItem
+	item := qt.NewQListWidgetItem()
+	item.SetSizeHint(qt.NewQSize2(500, 56)) // Adjust height as needed
+
+	// Must use AddItemWithItem() in miqt instead of AddItem()
+	listWidget.AddItemWithItem(item)
+
+	// Create QWidget to embed
+	widget := qt.NewQWidget(nil)
+	layout := qt.NewQVBoxLayout(nil)
+	layout.SetContentsMargins(10, 6, 10, 6)
+	layout.SetSpacing(2) // spacing between host and description
+
+	// Host label (bold, larger font)
+	hostLabel := qt.NewQLabel3(title)
+	hostFont := qt.NewQFont()
+	hostFont.SetPointSize(16)
+	hostFont.SetBold(true)
+	hostLabel.SetFont(hostFont)
+	hostLabel.SetStyleSheet("color: black")
+
+	// Description label (smaller, gray)
+	descLabel := qt.NewQLabel3(description)
+	descFont := qt.NewQFont()
+	descFont.SetPointSize(12)
+	descLabel.SetFont(descFont)
+	descLabel.SetStyleSheet("color: #555555") // darker gray
+
+	layout.AddWidget(hostLabel.QWidget)
+	layout.AddWidget(descLabel.QWidget)
+	widget.SetLayout(layout.QLayout)
+
+	// Associate the custom widget with the item
+	listWidget.SetItemWidget(item, widget)
+	// Save label pointers for later selection handling
+	labelRefs = append(labelRefs, &itemLabels{
+		host: hostLabel,
+		desc: descLabel,
+	})
+}
+
+// openSpotlightNote opens the selected note search result, returns false when not in notes mode
+func openSpotlightNote(row int) bool {
+	if filteredNotes == nil {
+		return false
+	}
+	if row >= 0 && row < len(filteredNotes) {
+		n := filteredNotes[row]
+		searchWindow.Hide()
+		OpenNoteQt(n.NotesDir, n.service.Gist, n.ID)
+	}
+	return true
+}
+
 func updateSelectionColors() {
 	for i := 0; i < listWidget.Count(); i++ {
 		if i >= len(labelRefs) {
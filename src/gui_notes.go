@@ -5,7 +5,6 @@ package main
 */
 
 import (
-	"log"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -33,8 +32,10 @@ type NoteWindowQt struct {
 	modifiedLbl   *qt.QLabel
 	revisionsLbl  *qt.QLabel
 	stickyCheck   *qt.QCheckBox
+	serversEdit   *qt.QLineEdit
 	viewContainer *qt.QWidget
 	treeWidget    *qt.QTreeWidget
+	searchEdit    *qt.QLineEdit
 	gist          GistConfig
 	service       *NoteService
 	current       *Note
@@ -58,6 +59,29 @@ func ShowNotesWindowQt(app *qt.QApplication, notesDir string, gist GistConfig) {
 	notesWindowsQt[key] = nw
 }
 
+// notesGistFor returns the gist settings of the servers file the notes folder belongs to
+func notesGistFor(notesDir string) GistConfig {
+	for _, item := range ymlfiles {
+		if trimYML(filepath.Base(item))+"-notes" == filepath.Base(notesDir) {
+			return findGist(filepath.Base(item))
+		}
+	}
+	return GistConfig{}
+}
+
+// OpenNoteQt shows the notes window and selects the given note
+func OpenNoteQt(notesDir string, gist GistConfig, relID string) {
+	ShowNotesWindowQt(nil, notesDir, gist)
+	if nw, ok := notesWindowsQt[filepath.Base(notesDir)]; ok {
+		if nw.searchEdit.Text() != "" {
+			nw.searchEdit.SetText("")
+		}
+		if relID != "" {
+			nw.selectNoteQt(relID)
+		}
+	}
+}
+
 func (nw *NoteWindowQt) initUI() {
 	nw.win = qt.NewQDialog(nil)
 	nw.win.SetWindowTitle("Notes: " + filepath.Base(nw.service.NotesDir))
@@ -99,9 +123,57 @@ func (nw *NoteWindowQt) initUI() {
 		nw.onSelectQt()
 	})
 
+	tree.SetContextMenuPolicy(qt.CustomContextMenu)
+	tree.OnCustomContextMenuRequested(func(pos *qt.QPoint) {
+		// outside of the items the whole notes folder is used
+		rel := ""
+		if item := tree.ItemAt(pos); item != nil {
+			// select the item, so the actions work on it
+			tree.SetCurrentItem(item)
+			rel = getItemRelPath(item)
+		}
+		menu := qt.NewQMenu(tree.QWidget)
+		menu.AddAction("New note").OnTriggered(nw.doNewNoteQt)
+		menu.AddAction("New folder").OnTriggered(nw.doNewFolderQt)
+		if rel != "" {
+			menu.AddAction("Rename / Move...").OnTriggered(func() { nw.doMoveQt(rel) })
+			menu.AddAction("Delete").OnTriggered(nw.doDeleteQt)
+		}
+		if rel != "" && !nw.isBranch[rel] {
+			menu.AddAction("History").OnTriggered(nw.showHistoryQt)
+		}
+		menu.AddSeparator()
+		export := menu.AddMenuWithTitle("Export")
+		export.AddAction("HTML...").OnTriggered(func() { nw.exportQt(rel, ExportHTML) })
+		export.AddAction("PDF...").OnTriggered(func() { nw.exportQt(rel, ExportPDF) })
+		export.AddAction("Markdown zip...").OnTriggered(func() { nw.exportQt(rel, ExportZip) })
+		menu.ExecWithPos(tree.Viewport().MapToGlobal(pos))
+	})
+
+	// --- Search box, filters the tree to the matching notes
+	nw.searchEdit = qt.NewQLineEdit(nil)
+	nw.searchEdit.SetPlaceholderText("Search notes...")
+	nw.searchEdit.SetClearButtonEnabled(true)
+	nw.searchEdit.OnTextChanged(func(text string) {
+		nw.searchQt(text)
+	})
+	left := qt.NewQWidget(nil)
+	leftLayout := qt.NewQVBoxLayout2()
+	leftLayout.SetContentsMargins(0, 0, 0, 0)
+	leftLayout.AddWidget(nw.searchEdit.QWidget)
+	leftLayout.AddWidget(tree.QWidget)
+	left.SetLayout(leftLayout.QLayout)
+
 	// --- Editor & Viewer
 	nw.editor = qt.NewQTextEdit(nil)
+	nw.editor.OnCanInsertFromMimeData(func(super func(source *qt.QMimeData) bool, source *qt.QMimeData) bool {
+		return source.HasImage() || source.HasUrls() || super(source)
+	})
 	nw.editor.OnInsertFromMimeData(func(super func(source *qt.QMimeData), source *qt.QMimeData) {
+		// pasted images and dropped files become attachments of the note
+		if nw.insertAttachmentsQt(source) {
+			return
+		}
 		if source.HasText() {
 			nw.editor.InsertPlainText(source.Text())
 			return
@@ -116,8 +188,11 @@ func (nw *NoteWindowQt) initUI() {
 	nw.viewer.SetOpenLinks(false)
 	nw.viewer.SetOpenExternalLinks(false)
 
-	nw.viewer.OnAnchorClicked(func(url *qt.QUrl) {
-		qt.QDesktopServices_OpenUrl(url)
+	setupNoteBrowserQt(nw.viewer, nw.service, func() string {
+		if nw.current == nil {
+			return ""
+		}
+		return nw.current.ID
 	})
 
 	// default is viewmode
@@ -140,6 +215,30 @@ func (nw *NoteWindowQt) initUI() {
 		}
 	})
 
+	// servers the note is linked to (runbook)
+	nw.serversEdit = qt.NewQLineEdit(nil)
+	nw.serversEdit.SetPlaceholderText("host, IP or link id of the servers, comma separated")
+	nw.serversEdit.OnEditingFinished(func() {
+		if nw.current == nil {
+			return
+		}
+		list := splitList(nw.serversEdit.Text())
+		if strings.Join(list, ",") == strings.Join(nw.current.Meta.Servers, ",") {
+			return
+		}
+		nw.current.Meta.Servers = list
+		if err := nw.service.Save(nw.current); err != nil {
+			qt.QMessageBox_Critical(nw.win.QWidget, "Error", err.Error())
+			return
+		}
+		if serverTableWindow != nil {
+			updateServerTable()
+		}
+	})
+	linkRow := qt.NewQHBoxLayout2()
+	linkRow.AddWidget(qt.NewQLabel5("Servers:", nil).QWidget)
+	linkRow.AddWidget(nw.serversEdit.QWidget)
+
 	footer := qt.NewQHBoxLayout2()
 	footer.AddWidget(qt.NewQLabel5("Created:", nil).QWidget)
 	footer.AddWidget(nw.createdLbl.QWidget)
@@ -155,6 +254,7 @@ func (nw *NoteWindowQt) initUI() {
 	nw.viewContainer = qt.NewQWidget(nil)
 	vl := qt.NewQVBoxLayout2()
 	vl.AddWidget(nw.viewer.QWidget)
+	vl.AddLayout(linkRow.QLayout)
 	vl.AddLayout(footer.QLayout)
 	nw.viewContainer.SetLayout(vl.QLayout)
 
@@ -166,6 +266,8 @@ func (nw *NoteWindowQt) initUI() {
 	viewModeIcon := qt.NewQIcon4(":/icons/show-hide.png")
 	pushIcon := qt.NewQIcon4(":/icons/syncpush.png")
 	pullIcon := qt.NewQIcon4(":/icons/syncpull.png")
+	historyIcon := qt.QApplication_Style().StandardIcon(qt.QStyle__SP_FileDialogDetailedView, nil, nil)
+	attachIcon := qt.QApplication_Style().StandardIcon(qt.QStyle__SP_FileLinkIcon, nil, nil)
 
 	toolbar := qt.NewQHBoxLayout2()
 
@@ -196,6 +298,8 @@ func (nw *NoteWindowQt) initUI() {
 	addToolBtn(deleteIcon, "Delete folder or note", func() { nw.doDeleteQt() })
 	addToolBtn(saveIcon, "Save current note", func() { nw.saveNoteQt() })
 	addToolBtn(viewModeIcon, "View mode editor or viewer", func() { nw.toggleViewQt() })
+	addToolBtn(attachIcon, "Attach files to the note (or paste an image, drop files into the editor)", func() { nw.doAttachQt() })
+	addToolBtn(historyIcon, "History of the note (diff and restore)", func() { nw.showHistoryQt() })
 	addToolBtn(pushIcon, "Upload notes to the remote server (sync push)", func() { nw.pushSyncQt() })
 	addToolBtn(pullIcon, "Download notes from the remote server (sync pull)", func() { nw.pullSyncQt() })
 
@@ -205,7 +309,7 @@ func (nw *NoteWindowQt) initUI() {
 	splitterSaveTimer := qt.NewQTimer()
 	splitterSaveTimer.SetSingleShot(true)
 	splitterSaveTimer.OnTimeout(func() {
-		log.Printf("Saving splitter state")
+		notesLog.Debugf("Saving splitter state")
 		// save splitter state to settings
 		Store.Set("Window-"+filepath.Base(nw.service.NotesDir), "splitter", splitter.SaveState())
 	})
@@ -219,7 +323,7 @@ func (nw *NoteWindowQt) initUI() {
 	//	SetMinimumWidth(200) // Try 350, or whatever you like
 	nw.treeWidget.QWidget.SetSizePolicy2(qt.QSizePolicy__Expanding, qt.QSizePolicy__Expanding)
 
-	splitter.AddWidget(nw.treeWidget.QWidget)
+	splitter.AddWidget(left)
 
 	// 2. Right side: QWidget with its own QVBoxLayout
 	right := qt.NewQWidget(nil)
@@ -238,11 +342,11 @@ func (nw *NoteWindowQt) initUI() {
 	splitter.SetStretchFactor(0, 1) // Left pane gets 2x the "stretch weight"
 	splitter.SetStretchFactor(1, 2)
 
-	nw.treeWidget.QWidget.SetMinimumWidth(200)
-	nw.treeWidget.QWidget.SetSizePolicy2(qt.QSizePolicy__Expanding, qt.QSizePolicy__Expanding)
+	left.SetMinimumWidth(200)
+	left.SetSizePolicy2(qt.QSizePolicy__Expanding, qt.QSizePolicy__Expanding)
 	right.SetMinimumWidth(400)
 	right.SetSizePolicy2(qt.QSizePolicy__Expanding, qt.QSizePolicy__Expanding)
-	splitter.AddWidget(nw.treeWidget.QWidget)
+	splitter.AddWidget(left)
 	splitter.AddWidget(right)
 	splitter.SetSizes([]int{350, 650})
 
@@ -257,7 +361,7 @@ func (nw *NoteWindowQt) initUI() {
 	data, err := Store.GetBytes("Window-"+filepath.Base(nw.service.NotesDir), "splitter")
 	if err == nil {
 		ok := splitter.RestoreState(data)
-		log.Printf("Restoring splitter state: success=%v", ok)
+		notesLog.Debugf("Restoring splitter state: success=%v", ok)
 	}
 
 	nw.win.Show()
@@ -307,7 +411,7 @@ func populateTreeNode(tree *qt.QTreeWidget, parent *qt.QTreeWidgetItem, path str
 func (nw *NoteWindowQt) onSelectQt() {
 	item := nw.treeWidget.CurrentItem()
 	if item == nil {
-		log.Printf("note item is nil\n")
+		notesLog.Debugf("note item is nil")
 		return
 	}
 	// Reconstruct relative path from tree (or store mapping)
@@ -317,7 +421,7 @@ func (nw *NoteWindowQt) onSelectQt() {
 
 	// Folder (branch): clear view/editor
 	if nw.isBranch[relPath] {
-		log.Printf("note is directory\n")
+		notesLog.Debugf("note is directory")
 		nw.current = nil
 		nw.editor.SetPlainText("")
 		nw.viewer.SetMarkdown("")
@@ -350,14 +454,18 @@ func getItemRelPath(item *qt.QTreeWidgetItem) string {
 }
 
 func (nw *NoteWindowQt) doNewNoteQt() {
-	inputDlg := qt.NewQInputDialog(nw.win.QWidget)
-	inputDlg.SetLabelText("Note name:")
-	if inputDlg.Exec() == int(qt.QDialog__Accepted) && inputDlg.TextValue() != "" {
+	if name, tmpl, ok := newNoteDialogQt(nw.win.QWidget, nw.service, "", ""); ok {
 		parentRel := ""
 		if nw.selectedUID != "" && nw.isBranch[nw.selectedUID] {
 			parentRel = nw.selectedUID
 		}
-		if err := nw.service.NewNote(parentRel, inputDlg.TextValue()); err != nil {
+		var err error
+		if tmpl != "" {
+			err = nw.service.NewNoteFromTemplate(parentRel, name, tmpl, nil)
+		} else {
+			err = nw.service.NewNote(parentRel, name)
+		}
+		if err != nil {
 			qt.QMessageBox_Critical(nw.win.QWidget, "Error", err.Error())
 			return
 		}
@@ -386,21 +494,35 @@ func (nw *NoteWindowQt) doDeleteQt() {
 		return
 	}
 	rel := nw.selectedUID
-	oldid := rel
-	fname := strings.TrimSuffix(oldid, ".md")
-	reply := qt.QMessageBox_Question4(nw.win.QWidget, "Delete", "Are you sure, you want to delete "+fname+" note ?", qt.QMessageBox__Yes, qt.QMessageBox__No)
+	fname := strings.TrimSuffix(rel, ".md")
+	question := "Are you sure, you want to delete " + fname + " note ?"
+	if nw.isBranch[rel] {
+		question = "Are you sure, you want to delete " + fname + " folder with all its notes ?"
+	}
+	reply := qt.QMessageBox_Question4(nw.win.QWidget, "Delete", question, qt.QMessageBox__Yes, qt.QMessageBox__No)
 	if reply == int(qt.QMessageBox__Yes) {
-		if err := nw.service.DeleteNote(rel); err != nil {
+		deleted := []string{rel}
+		var err error
+		if nw.isBranch[rel] {
+			deleted, err = nw.service.DeleteFolder(rel)
+		} else {
+			err = nw.service.DeleteNote(rel)
+		}
+		if err != nil {
 			qt.QMessageBox_Critical(nw.win.QWidget, "Error", err.Error())
 			return
 		}
 		nw.selectedUID = ""
+		nw.current = nil
 		nw.treeWidget.ClearSelection()
 		nw.refreshTreeQt()
-		if nw.service.Gist.GistID != "" {
+		if sm, ok := Stickies[filepath.Base(nw.service.NotesDir)]; ok {
+			sm.Refresh()
+		}
+		if nw.service.Gist.GistID != "" && len(deleted) > 0 {
 			reply2 := qt.QMessageBox_Question4(nw.win.QWidget, "Delete", "Do you want to delete from gist also?", qt.QMessageBox__Yes, qt.QMessageBox__No)
 			if reply2 == int(qt.QMessageBox__Yes) {
-				err := nw.service.DeleteFromGist(oldid)
+				err := nw.service.DeleteFromGist(deleted...)
 				if err != nil {
 					qt.QMessageBox_Critical(nw.win.QWidget, "Error", err.Error())
 					return
@@ -411,6 +533,63 @@ func (nw *NoteWindowQt) doDeleteQt() {
 	}
 }
 
+// exportQt asks for the output file and exports note or folder (empty rel for all notes)
+func (nw *NoteWindowQt) exportQt(rel, format string) {
+	name := strings.TrimSuffix(filepath.Base(rel), ".md")
+	if rel == "" {
+		name = filepath.Base(nw.service.NotesDir)
+	}
+	filters := map[string]string{
+		ExportHTML: "HTML files (*.html)",
+		ExportPDF:  "PDF files (*.pdf)",
+		ExportZip:  "Zip archives (*.zip)",
+	}
+	home, _ := os.UserHomeDir()
+	out := qt.QFileDialog_GetSaveFileName4(nw.win.QWidget, "Export "+name, filepath.Join(home, name+"."+format), filters[format])
+	if out == "" {
+		return
+	}
+	if err := nw.service.Export(rel, format, out); err != nil {
+		qt.QMessageBox_Critical(nw.win.QWidget, "Error", err.Error())
+		return
+	}
+	qt.QMessageBox_Information(nw.win.QWidget, "Export", "Exported to "+out)
+}
+
+// doMoveQt renames or moves note or folder, the path can contain folders
+func (nw *NoteWindowQt) doMoveQt(rel string) {
+	if nw.current != nil && string(nw.current.Body) != nw.editor.ToPlainText() {
+		nw.saveNoteQt()
+	}
+	inputDlg := qt.NewQInputDialog(nw.win.QWidget)
+	inputDlg.SetWindowTitle("Rename / Move")
+	inputDlg.SetLabelText("New path (folders separated by /):")
+	inputDlg.SetTextValue(filepath.ToSlash(strings.TrimSuffix(rel, ".md")))
+	if inputDlg.Exec() != int(qt.QDialog__Accepted) {
+		return
+	}
+	newRel := filepath.Clean(filepath.FromSlash(strings.Trim(strings.TrimSpace(inputDlg.TextValue()), "/")))
+	if newRel == "." || newRel == strings.TrimSuffix(rel, ".md") {
+		return
+	}
+	folder := nw.isBranch[rel]
+	if err := nw.service.Move(rel, newRel); err != nil {
+		qt.QMessageBox_Critical(nw.win.QWidget, "Error", err.Error())
+		return
+	}
+	nw.current = nil
+	nw.selectedUID = ""
+	nw.refreshTreeQt()
+	if !folder {
+		if id, err := normalizeNoteID(newRel); err == nil {
+			nw.selectNoteQt(id)
+		}
+	}
+	if sm, ok := Stickies[filepath.Base(nw.service.NotesDir)]; ok {
+		sm.Refresh()
+	}
+}
+
 func (nw *NoteWindowQt) saveNoteQt() {
 	if nw.current == nil {
 		return
@@ -448,9 +627,66 @@ func (nw *NoteWindowQt) toggleViewQt() {
 func (nw *NoteWindowQt) refreshTreeQt() {
 	td, ib, _ := nw.service.ListTree()
 	nw.treeData, nw.isBranch = td, ib
+	if nw.searchEdit != nil && strings.TrimSpace(nw.searchEdit.Text()) != "" {
+		nw.searchQt(nw.searchEdit.Text())
+		return
+	}
 	populateNotesTreeQt(nw.treeWidget, td, ib)
 }
 
+// searchQt shows only the notes matching the query (and their folders) in the tree
+func (nw *NoteWindowQt) searchQt(query string) {
+	if strings.TrimSpace(query) == "" {
+		populateNotesTreeQt(nw.treeWidget, nw.treeData, nw.isBranch)
+		return
+	}
+	results := nw.service.Search(query)
+	snippets := make(map[string]string)
+	filtered := make(map[string][]string)
+	added := make(map[string]bool)
+	for _, r := range results {
+		snippets[r.ID] = r.Snippet
+		// add the note and all its parent folders
+		child := r.ID
+		for !added[child] {
+			added[child] = true
+			parent := filepath.Dir(child)
+			if parent == "." {
+				parent = ""
+			}
+			filtered[parent] = append(filtered[parent], child)
+			if parent == "" {
+				break
+			}
+			child = parent
+		}
+	}
+	populateNotesTreeQt(nw.treeWidget, filtered, nw.isBranch)
+	// show matched text as tooltip
+	it := qt.NewQTreeWidgetItemIterator2(nw.treeWidget)
+	for it.OperatorMultiply() != nil {
+		item := it.OperatorMultiply()
+		if snip, ok := snippets[getItemRelPath(item)]; ok {
+			item.SetToolTip(0, snip)
+		}
+		it.OperatorPlusPlus()
+	}
+}
+
+// selectNoteQt selects the note in the tree, used when note is opened from search
+func (nw *NoteWindowQt) selectNoteQt(relID string) {
+	it := qt.NewQTreeWidgetItemIterator2(nw.treeWidget)
+	for it.OperatorMultiply() != nil {
+		item := it.OperatorMultiply()
+		if getItemRelPath(item) == relID {
+			nw.treeWidget.SetCurrentItem(item)
+			nw.treeWidget.ScrollToItem(item)
+			return
+		}
+		it.OperatorPlusPlus()
+	}
+}
+
 func (nw *NoteWindowQt) updateHeaderQt() {
 	if nw.current == nil {
 		return
@@ -461,6 +697,7 @@ func (nw *NoteWindowQt) updateHeaderQt() {
 	nw.modifiedLbl.SetText(updatedTime.Format("2006-01-02 15:04:05"))
 	nw.revisionsLbl.SetText(strconv.Itoa(len(nw.current.History)))
 	nw.stickyCheck.SetChecked(nw.current.Meta.Sticky)
+	nw.serversEdit.SetText(strings.Join(nw.current.Meta.Servers, ", "))
 }
 func (nw *NoteWindowQt) pushSyncQt() {
 	if err := nw.service.PushSync(); err != nil {
@@ -0,0 +1,171 @@
+package main
+
+/* Notes attachments in the Qt notes window and stickies
+Images pasted or files dropped into the editor are stored as attachments of the current note
+and a link is inserted at the cursor, the viewers load them through the attachment: scheme.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mappu/miqt/qt"
+)
+
+// setupNoteBrowserQt makes the browser render attachments of the note and handle clicked links,
+// images are shown inline, other attachments are saved where the user chooses
+func setupNoteBrowserQt(b *qt.QTextBrowser, s *NoteService, noteID func() string) {
+	b.OnLoadResource(func(super func(typeVal int, name *qt.QUrl) *qt.QVariant, typeVal int, name *qt.QUrl) *qt.QVariant {
+		if name.Scheme() != attachmentScheme {
+			return super(typeVal, name)
+		}
+		id, file, ok := resolveAttachment(noteID(), name.ToString())
+		if !ok {
+			return super(typeVal, name)
+		}
+		data, err := s.ReadAttachment(id, file)
+		if err != nil {
+			notesLog.Warnf("Unable to load attachment %s of %s: %s", file, id, err)
+			return super(typeVal, name)
+		}
+		return qt.NewQVariant15(data)
+	})
+	b.OnAnchorClicked(func(url *qt.QUrl) {
+		if url.Scheme() != attachmentScheme {
+			qt.QDesktopServices_OpenUrl(url)
+			return
+		}
+		id, file, ok := resolveAttachment(noteID(), url.ToString())
+		if !ok {
+			return
+		}
+		saveAttachmentQt(b.QWidget, s, id, file)
+	})
+}
+
+// saveAttachmentQt writes decrypted attachment to the file selected by the user
+func saveAttachmentQt(parent *qt.QWidget, s *NoteService, noteID, name string) {
+	data, err := s.ReadAttachment(noteID, name)
+	if err != nil {
+		qt.QMessageBox_Critical(parent, "Error", err.Error())
+		return
+	}
+	home, _ := os.UserHomeDir()
+	out := qt.QFileDialog_GetSaveFileName3(parent, "Save attachment", filepath.Join(home, name))
+	if out == "" {
+		return
+	}
+	if err := os.WriteFile(out, data, 0600); err != nil {
+		qt.QMessageBox_Critical(parent, "Error", err.Error())
+	}
+}
+
+// imagePNG encodes the image as png
+func imagePNG(img *qt.QImage) []byte {
+	if img == nil || img.IsNull() {
+		return nil
+	}
+	buf := qt.NewQBuffer()
+	buf.Open(qt.QIODevice__WriteOnly)
+	img.Save4(buf.QIODevice, "PNG")
+	return buf.Data()
+}
+
+// mimeImagePNG returns pasted or dropped image as png
+func mimeImagePNG(source *qt.QMimeData) []byte {
+	if data := source.Data("image/png"); len(data) > 0 {
+		return data
+	}
+	for _, f := range source.Formats() {
+		if strings.HasPrefix(f, "image/") {
+			if data := imagePNG(qt.QImage_FromDataWithData(source.Data(f))); len(data) > 0 {
+				return data
+			}
+		}
+	}
+	// some platforms provide the image only through the clipboard
+	return imagePNG(qt.QGuiApplication_Clipboard().Image())
+}
+
+// insertAttachmentsQt stores pasted image or dropped local files as attachments of the current note
+// and inserts links into the editor, returns false if there was nothing to attach
+func (nw *NoteWindowQt) insertAttachmentsQt(source *qt.QMimeData) bool {
+	if nw.current == nil {
+		return false
+	}
+	var links []string
+	if source.HasUrls() {
+		for _, u := range source.Urls() {
+			if !u.IsLocalFile() {
+				continue
+			}
+			if link, ok := nw.attachFileQt(u.ToLocalFile()); ok {
+				links = append(links, link)
+			}
+		}
+	}
+	if len(links) == 0 && source.HasImage() {
+		data := mimeImagePNG(source)
+		if len(data) == 0 {
+			return false
+		}
+		name, err := nw.service.AddAttachment(nw.current.ID, "pasted-"+time.Now().Format("20060102-150405")+".png", data)
+		if err != nil {
+			qt.QMessageBox_Critical(nw.win.QWidget, "Error", err.Error())
+			return true
+		}
+		links = append(links, attachmentLink(name))
+	}
+	if len(links) == 0 {
+		return false
+	}
+	nw.editor.InsertPlainText(strings.Join(links, "\n"))
+	return true
+}
+
+// attachFileQt stores the local file as attachment of the current note and returns its link
+func (nw *NoteWindowQt) attachFileQt(path string) (string, bool) {
+	fi, err := os.Stat(path)
+	if err != nil || fi.IsDir() {
+		return "", false
+	}
+	if fi.Size() > maxAttachmentSize {
+		qt.QMessageBox_Warning(nw.win.QWidget, "Attachment", filepath.Base(path)+" is too big to attach.")
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		qt.QMessageBox_Critical(nw.win.QWidget, "Error", err.Error())
+		return "", false
+	}
+	name, err := nw.service.AddAttachment(nw.current.ID, filepath.Base(path), data)
+	if err != nil {
+		qt.QMessageBox_Critical(nw.win.QWidget, "Error", err.Error())
+		return "", false
+	}
+	return attachmentLink(name), true
+}
+
+// doAttachQt asks for files and appends links to them to the current note
+func (nw *NoteWindowQt) doAttachQt() {
+	if nw.current == nil {
+		return
+	}
+	files := qt.QFileDialog_GetOpenFileNames2(nw.win.QWidget, "Attach files")
+	var links []string
+	for _, f := range files {
+		if link, ok := nw.attachFileQt(f); ok {
+			links = append(links, link)
+		}
+	}
+	if len(links) == 0 {
+		return
+	}
+	body := strings.TrimRight(nw.editor.ToPlainText(), "\n") + "\n\n" + strings.Join(links, "\n") + "\n"
+	nw.editor.SetPlainText(body)
+	nw.saveNoteQt()
+	nw.viewer.SetMarkdown(body)
+}
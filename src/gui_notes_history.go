@@ -0,0 +1,272 @@
+package main
+
+/* Notes history browser
+Lists revisions of the note, shows unified or side by side diff against the previous
+revision or the current note and restores the selected revision.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+
+	"github.com/mappu/miqt/qt"
+)
+
+const (
+	diffColorDelete = "#ffe0e0"
+	diffColorInsert = "#ddf7dd"
+	diffColorHunk   = "#e8eef7"
+)
+
+// showHistoryQt opens history browser of the current note
+func (nw *NoteWindowQt) showHistoryQt() {
+	if nw.current == nil {
+		return
+	}
+	// unsaved changes in the editor become the newest revision
+	if string(nw.current.Body) != nw.editor.ToPlainText() {
+		nw.saveNoteQt()
+	}
+	note := nw.current
+	if len(note.History) == 0 {
+		qt.QMessageBox_Information(nw.win.QWidget, "History", "This note has no saved revisions yet.")
+		return
+	}
+
+	dialog := qt.NewQDialog(nw.win.QWidget)
+	dialog.SetWindowTitle("History - " + note.Meta.Title)
+	dialog.Resize(1000, 600)
+
+	revList := qt.NewQListWidget(nil)
+	revList.SetMaximumWidth(220)
+
+	compareCombo := qt.NewQComboBox(nil)
+	compareCombo.AddItem("Compare with previous revision")
+	compareCombo.AddItem("Compare with current note")
+	modeCombo := qt.NewQComboBox(nil)
+	modeCombo.AddItem("Unified")
+	modeCombo.AddItem("Side by side")
+
+	unified := qt.NewQTextEdit(nil)
+	unified.SetReadOnly(true)
+	unified.SetLineWrapMode(qt.QTextEdit__NoWrap)
+
+	leftPane := qt.NewQTextEdit(nil)
+	leftPane.SetReadOnly(true)
+	leftPane.SetLineWrapMode(qt.QTextEdit__NoWrap)
+	rightPane := qt.NewQTextEdit(nil)
+	rightPane.SetReadOnly(true)
+	rightPane.SetLineWrapMode(qt.QTextEdit__NoWrap)
+	// scroll both sides together
+	leftPane.VerticalScrollBar().OnValueChanged(func(v int) { rightPane.VerticalScrollBar().SetValue(v) })
+	rightPane.VerticalScrollBar().OnValueChanged(func(v int) { leftPane.VerticalScrollBar().SetValue(v) })
+	sideBySide := qt.NewQSplitter3(qt.Horizontal)
+	sideBySide.AddWidget(leftPane.QWidget)
+	sideBySide.AddWidget(rightPane.QWidget)
+	sideBySide.SetVisible(false)
+
+	// revisions are listed newest first
+	snapshotAt := func(row int) int { return len(note.History) - 1 - row }
+	fillList := func() {
+		revList.Clear()
+		for i := len(note.History) - 1; i >= 0; i-- {
+			revList.AddItem(fmt.Sprintf("#%d  %s", i+1, ToLocalTime(note.History[i].Timestamp).Format("2006-01-02 15:04:05")))
+		}
+	}
+
+	render := func() {
+		row := revList.CurrentRow()
+		if row < 0 {
+			return
+		}
+		i := snapshotAt(row)
+		newData, err := nw.service.ReadSnapshot(note.History[i])
+		if err != nil {
+			unified.SetPlainText("Unable to read revision: " + err.Error())
+			return
+		}
+		var oldData []byte
+		oldName, newName := "", fmt.Sprintf("#%d", i+1)
+		if compareCombo.CurrentIndex() == 1 {
+			// selected revision -> current note
+			oldData, oldName = newData, newName
+			newData, newName = note.Raw, "current"
+		} else if i > 0 {
+			if oldData, err = nw.service.ReadSnapshot(note.History[i-1]); err != nil {
+				unified.SetPlainText("Unable to read revision: " + err.Error())
+				return
+			}
+			oldName = fmt.Sprintf("#%d", i)
+		}
+		lines := diffLines(string(oldData), string(newData))
+		if modeCombo.CurrentIndex() == 1 {
+			l, r := sideBySideDiffHTML(lines)
+			leftPane.SetHtml(l)
+			rightPane.SetHtml(r)
+		} else {
+			unified.SetHtml(unifiedDiffHTML(oldName, newName, lines))
+		}
+	}
+
+	revList.OnCurrentRowChanged(func(row int) { render() })
+	compareCombo.OnCurrentIndexChanged(func(index int) { render() })
+	modeCombo.OnCurrentIndexChanged(func(index int) {
+		unified.SetVisible(index == 0)
+		sideBySide.SetVisible(index == 1)
+		render()
+	})
+
+	restoreBtn := qt.NewQPushButton3("Restore this revision")
+	restoreBtn.OnClicked(func() {
+		row := revList.CurrentRow()
+		if row < 0 {
+			return
+		}
+		snap := note.History[snapshotAt(row)]
+		reply := qt.QMessageBox_Question4(dialog.QWidget, "Restore", "Restore note to the revision from "+ToLocalTime(snap.Timestamp).Format("2006-01-02 15:04:05")+"?", qt.QMessageBox__Yes, qt.QMessageBox__No)
+		if reply != int(qt.QMessageBox__Yes) {
+			return
+		}
+		if err := nw.service.Restore(note, snap); err != nil {
+			qt.QMessageBox_Critical(dialog.QWidget, "Error", err.Error())
+			return
+		}
+		nw.reloadCurrentQt()
+		note = nw.current
+		fillList()
+		revList.SetCurrentRow(0)
+	})
+	closeBtn := qt.NewQPushButton3("Close")
+	closeBtn.OnClicked(func() { dialog.Accept() })
+
+	controls := qt.NewQHBoxLayout2()
+	controls.AddWidget(compareCombo.QWidget)
+	controls.AddWidget(modeCombo.QWidget)
+	controls.AddStretch()
+
+	right := qt.NewQVBoxLayout2()
+	right.AddLayout(controls.QLayout)
+	right.AddWidget(unified.QWidget)
+	right.AddWidget(sideBySide.QWidget)
+
+	body := qt.NewQHBoxLayout2()
+	body.AddWidget(revList.QWidget)
+	body.AddLayout(right.QLayout)
+
+	buttons := qt.NewQHBoxLayout2()
+	buttons.AddStretch()
+	buttons.AddWidget(restoreBtn.QWidget)
+	buttons.AddWidget(closeBtn.QWidget)
+
+	layout := qt.NewQVBoxLayout2()
+	layout.AddLayout(body.QLayout)
+	layout.AddLayout(buttons.QLayout)
+	dialog.SetLayout(layout.QLayout)
+
+	fillList()
+	revList.SetCurrentRow(0)
+	dialog.Exec()
+}
+
+// reloadCurrentQt loads the current note again from disk and refreshes the views
+func (nw *NoteWindowQt) reloadCurrentQt() {
+	if nw.current == nil {
+		return
+	}
+	note, err := nw.service.Load(nw.current.ID)
+	if err != nil {
+		return
+	}
+	nw.current = note
+	nw.editor.SetPlainText(string(note.Body))
+	nw.viewer.SetMarkdown(string(note.Body))
+	nw.updateHeaderQt()
+	if sm, ok := Stickies[filepath.Base(nw.service.NotesDir)]; ok {
+		sm.Refresh()
+	}
+}
+
+func diffLineHTML(prefix, text, color string) string {
+	line := html.EscapeString(prefix + text)
+	if line == "" {
+		line = "&nbsp;"
+	}
+	if color == "" {
+		return line + "\n"
+	}
+	return `<span style="background-color:` + color + `">` + line + "</span>\n"
+}
+
+// unifiedDiffHTML renders the diff as colored unified diff
+func unifiedDiffHTML(oldName, newName string, lines []diffLine) string {
+	text := unifiedDiff(oldName, newName, lines, 3)
+	if text == "" {
+		return "<i>No differences</i>"
+	}
+	var b strings.Builder
+	b.WriteString("<pre>")
+	for _, l := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(l, "@@"):
+			b.WriteString(diffLineHTML("", l, diffColorHunk))
+		case strings.HasPrefix(l, "---") || strings.HasPrefix(l, "+++"):
+			b.WriteString("<b>" + diffLineHTML("", l, "") + "</b>")
+		case strings.HasPrefix(l, "-"):
+			b.WriteString(diffLineHTML("", l, diffColorDelete))
+		case strings.HasPrefix(l, "+"):
+			b.WriteString(diffLineHTML("", l, diffColorInsert))
+		default:
+			b.WriteString(diffLineHTML("", l, ""))
+		}
+	}
+	b.WriteString("</pre>")
+	return b.String()
+}
+
+// sideBySideDiffHTML renders old and new text with aligned rows, deleted and inserted lines are paired
+func sideBySideDiffHTML(lines []diffLine) (string, string) {
+	var left, right strings.Builder
+	left.WriteString("<pre>")
+	right.WriteString("<pre>")
+	num := func(n int) string {
+		if n == 0 {
+			return "     "
+		}
+		return fmt.Sprintf("%4d ", n)
+	}
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == diffEqual {
+			left.WriteString(diffLineHTML(num(lines[i].Old), lines[i].Text, ""))
+			right.WriteString(diffLineHTML(num(lines[i].New), lines[i].Text, ""))
+			i++
+			continue
+		}
+		// block of changes: deletes on the left, inserts on the right
+		var dels, ins []diffLine
+		for ; i < len(lines) && lines[i].Kind != diffEqual; i++ {
+			if lines[i].Kind == diffDelete {
+				dels = append(dels, lines[i])
+			} else {
+				ins = append(ins, lines[i])
+			}
+		}
+		for k := 0; k < len(dels) || k < len(ins); k++ {
+			if k < len(dels) {
+				left.WriteString(diffLineHTML(num(dels[k].Old), dels[k].Text, diffColorDelete))
+			} else {
+				left.WriteString("&nbsp;\n")
+			}
+			if k < len(ins) {
+				right.WriteString(diffLineHTML(num(ins[k].New), ins[k].Text, diffColorInsert))
+			} else {
+				right.WriteString("&nbsp;\n")
+			}
+		}
+	}
+	left.WriteString("</pre>")
+	right.WriteString("</pre>")
+	return left.String(), right.String()
+}
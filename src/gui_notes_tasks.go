@@ -0,0 +1,223 @@
+package main
+
+/* Tasks window and reminders
+Tasks of all notes grouped to overdue, today, upcoming and without date,
+tray notification is shown once when the due date of the task arrives, sent reminders are
+remembered in ~/.config/conan/cache/reminders.json.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mappu/miqt/qt"
+)
+
+var (
+	tasksWindow     *qt.QWidget
+	tasksRefreshQt  func()
+	taskReminded    map[string]time.Time // sent reminders, loaded on the first check
+	taskGroupsOrder = []string{TaskOverdue, TaskToday, TaskUpcoming, TaskNoDate, TaskDone}
+)
+
+// showTasksQt shows tasks of all notes
+func showTasksQt() {
+	if tasksWindow != nil {
+		tasksRefreshQt()
+		tasksWindow.Show()
+		tasksWindow.Raise()
+		tasksWindow.ActivateWindow()
+		return
+	}
+	tasksWindow = qt.NewQWidget(nil)
+	tasksWindow.SetWindowTitle("Tasks")
+	tasksWindow.SetWindowIcon(globalIcon)
+	tasksWindow.Resize(800, 500)
+	tasksWindow.OnCloseEvent(func(super func(event *qt.QCloseEvent), event *qt.QCloseEvent) {
+		event.Ignore()
+		tasksWindow.Hide()
+	})
+
+	tree := qt.NewQTreeWidget(nil)
+	tree.SetColumnCount(3)
+	tree.SetHeaderLabels([]string{"Task", "Note", "Due"})
+	tree.SetColumnWidth(0, 420)
+	tree.SetColumnWidth(1, 200)
+	showDone := qt.NewQCheckBox4("Show completed", nil)
+
+	var shown []NoteTask
+	selected := func() (NoteTask, bool) {
+		item := tree.CurrentItem()
+		if item == nil {
+			return NoteTask{}, false
+		}
+		i := item.Data(0, int(qt.UserRole)).ToInt()
+		if i < 1 || i > len(shown) {
+			return NoteTask{}, false
+		}
+		return shown[i-1], true
+	}
+
+	tasksRefreshQt = func() {
+		tree.Clear()
+		shown = allTasks()
+		now := time.Now()
+		groups := make(map[string]*qt.QTreeWidgetItem)
+		for _, g := range taskGroupsOrder {
+			if g == TaskDone && !showDone.IsChecked() {
+				continue
+			}
+			item := qt.NewQTreeWidgetItem3(tree)
+			item.SetText(0, strings.ToUpper(g[:1])+g[1:])
+			font := item.Font(0)
+			font.SetBold(true)
+			item.SetFont(0, font)
+			tree.AddTopLevelItem(item)
+			groups[g] = item
+		}
+		for i, t := range shown {
+			parent, ok := groups[t.Group(now)]
+			if !ok {
+				continue
+			}
+			item := qt.NewQTreeWidgetItem6(parent)
+			item.SetText(0, t.Text)
+			item.SetText(1, t.Title)
+			item.SetToolTip(1, t.Ref())
+			item.SetText(2, taskDueText(t))
+			// 0 is used by the group items
+			item.SetData(0, int(qt.UserRole), qt.NewQVariant7(i+1))
+			if t.Group(now) == TaskOverdue {
+				item.SetForeground(2, qt.NewQBrush3(qt.NewQColor6("#c0392b")))
+			}
+			parent.AddChild(item)
+		}
+		for g, item := range groups {
+			item.SetText(0, fmt.Sprintf("%s (%d)", item.Text(0), item.ChildCount()))
+			// long lists without date and completed tasks stay collapsed
+			item.SetExpanded((g != TaskNoDate && g != TaskDone) || item.ChildCount() < 20)
+		}
+	}
+
+	openNote := func() {
+		t, ok := selected()
+		if !ok {
+			return
+		}
+		for _, s := range allNoteServices() {
+			if s.NotesDir == t.NotesDir {
+				OpenNoteQt(s.NotesDir, s.Gist, t.NoteID)
+				return
+			}
+		}
+	}
+	markDone := func() {
+		t, ok := selected()
+		if !ok || t.Done {
+			return
+		}
+		for _, s := range allNoteServices() {
+			if s.NotesDir != t.NotesDir {
+				continue
+			}
+			if err := s.CompleteTask(t); err != nil {
+				qt.QMessageBox_Critical(tasksWindow, "Error", err.Error())
+			}
+			break
+		}
+		tasksRefreshQt()
+	}
+
+	tree.OnItemDoubleClicked(func(item *qt.QTreeWidgetItem, column int) { openNote() })
+	showDone.OnStateChanged(func(state int) { tasksRefreshQt() })
+
+	doneBtn := qt.NewQPushButton3("Mark done")
+	doneBtn.OnClicked(markDone)
+	openBtn := qt.NewQPushButton3("Open note")
+	openBtn.OnClicked(openNote)
+	refreshBtn := qt.NewQPushButton3("Refresh")
+	refreshBtn.OnClicked(func() { tasksRefreshQt() })
+	closeBtn := qt.NewQPushButton3("Close")
+	closeBtn.OnClicked(func() { tasksWindow.Hide() })
+
+	buttons := qt.NewQHBoxLayout2()
+	buttons.AddWidget(showDone.QWidget)
+	buttons.AddStretch()
+	buttons.AddWidget(doneBtn.QWidget)
+	buttons.AddWidget(openBtn.QWidget)
+	buttons.AddWidget(refreshBtn.QWidget)
+	buttons.AddWidget(closeBtn.QWidget)
+
+	layout := qt.NewQVBoxLayout2()
+	layout.AddWidget(tree.QWidget)
+	layout.AddLayout(buttons.QLayout)
+	tasksWindow.SetLayout(layout.QLayout)
+
+	tasksRefreshQt()
+	tasksWindow.Show()
+}
+
+// closeTasksQt closes the tasks window, it shows decrypted text of the notes, it is created again on next use
+func closeTasksQt() {
+	if tasksWindow == nil {
+		return
+	}
+	tasksWindow.Hide()
+	tasksWindow.DeleteLater()
+	tasksWindow = nil
+	tasksRefreshQt = nil
+}
+
+// startTaskReminders checks tasks every minute and shows tray notification for the newly due ones
+func startTaskReminders() {
+	timer := qt.NewQTimer()
+	timer.OnTimeout(checkTaskReminders)
+	timer.Start(60 * 1000)
+}
+
+func checkTaskReminders() {
+	if tray == nil || appLocked || !settings.NotesSettings.Reminders {
+		return
+	}
+	if taskReminded == nil {
+		taskReminded = loadTaskReminders()
+	}
+	now := time.Now()
+	var due []NoteTask
+	for _, t := range allTasks() {
+		if t.Done || t.Due.IsZero() || t.Due.After(now) {
+			continue
+		}
+		id := taskReminderID(t)
+		if _, sent := taskReminded[id]; sent {
+			continue
+		}
+		taskReminded[id] = now
+		due = append(due, t)
+	}
+	if len(due) > 0 {
+		saveTaskReminders(taskReminded)
+	}
+	switch len(due) {
+	case 0:
+		return
+	case 1:
+		tray.ShowMessage4("Task due", due[0].Text+"\n"+due[0].Title, qt.QSystemTrayIcon__Information)
+	default:
+		lines := make([]string, 0, 4)
+		for i, t := range due {
+			if i == 3 {
+				lines = append(lines, fmt.Sprintf("… and %d more", len(due)-3))
+				break
+			}
+			lines = append(lines, "• "+t.Text)
+		}
+		tray.ShowMessage4(fmt.Sprintf("%d tasks due", len(due)), strings.Join(lines, "\n"), qt.QSystemTrayIcon__Information)
+	}
+	notesLog.Infof("%d tasks are due", len(due))
+	if tasksWindow != nil && tasksWindow.IsVisible() {
+		tasksRefreshQt()
+	}
+}
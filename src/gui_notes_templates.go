@@ -0,0 +1,191 @@
+package main
+
+/* Note templates and quick capture in the Qt GUI
+New note dialog with the template selection, runbook from the servers table
+and the small always on top quick capture window.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mappu/miqt/qt"
+)
+
+const emptyTemplate = "(empty)"
+
+// newNoteDialogQt asks for the note name and template, empty template means plain note
+func newNoteDialogQt(parent *qt.QWidget, s *NoteService, name, tmpl string) (string, string, bool) {
+	dialog := qt.NewQDialog(parent)
+	dialog.SetWindowTitle("New note")
+	defer dialog.Destroy()
+
+	form := qt.NewQFormLayout(nil)
+	nameEdit := qt.NewQLineEdit(nil)
+	nameEdit.SetText(name)
+	form.AddRow3("Note name:", nameEdit.QWidget)
+	tmplCombo := qt.NewQComboBox(nil)
+	tmplCombo.AddItem(emptyTemplate)
+	for _, t := range s.Templates() {
+		tmplCombo.AddItem(t.Name)
+	}
+	if tmpl != "" {
+		tmplCombo.SetCurrentText(tmpl)
+	}
+	tmplCombo.SetToolTip("Templates are stored in " + filepath.Join(s.NotesDir, templatesDir))
+	form.AddRow3("Template:", tmplCombo.QWidget)
+
+	buttonBox := qt.NewQDialogButtonBox5(qt.QDialogButtonBox__Ok|qt.QDialogButtonBox__Cancel, qt.Horizontal)
+	buttonBox.OnAccepted(func() { dialog.Accept() })
+	buttonBox.OnRejected(func() { dialog.Reject() })
+	nameEdit.OnReturnPressed(func() { dialog.Accept() })
+
+	layout := qt.NewQVBoxLayout(nil)
+	layout.AddLayout(form.QLayout)
+	layout.AddWidget(buttonBox.QWidget)
+	dialog.SetLayout(layout.QLayout)
+	dialog.Resize(360, 0)
+
+	if dialog.Exec() != int(qt.QDialog__Accepted) || strings.TrimSpace(nameEdit.Text()) == "" {
+		return "", "", false
+	}
+	tmpl = tmplCombo.CurrentText()
+	if tmpl == emptyTemplate {
+		tmpl = ""
+	}
+	return strings.TrimSpace(nameEdit.Text()), tmpl, true
+}
+
+// newServerNoteQt creates note linked to the server from the template and opens it
+func newServerNoteQt(parent *qt.QWidget, srv Server) {
+	s := newNoteServiceFor(srv.SourceName)
+	if s == nil {
+		return
+	}
+	name, tmpl, ok := newNoteDialogQt(parent, s, srv.Host+" runbook", "runbook")
+	if !ok {
+		return
+	}
+	id, err := normalizeNoteID(name)
+	if err != nil {
+		qt.QMessageBox_Warning(parent, "New note", err.Error())
+		return
+	}
+	if _, err := os.Stat(filepath.Join(s.NotesDir, id)); err == nil {
+		qt.QMessageBox_Warning(parent, "New note", "Note "+strings.TrimSuffix(id, ".md")+" already exists.")
+		return
+	}
+	if err := os.MkdirAll(filepath.Join(s.NotesDir, filepath.Dir(id)), 0755); err != nil {
+		qt.QMessageBox_Critical(parent, "Error", err.Error())
+		return
+	}
+	parentRel, base := filepath.Split(id)
+	parentRel = strings.TrimSuffix(parentRel, string(os.PathSeparator))
+	base = strings.TrimSuffix(base, ".md")
+	if tmpl != "" {
+		err = s.NewNoteFromTemplate(parentRel, base, tmpl, &srv)
+	} else {
+		err = s.NewNote(parentRel, base)
+	}
+	if err != nil {
+		qt.QMessageBox_Critical(parent, "Error", err.Error())
+		return
+	}
+	if nw, ok := notesWindowsQt[filepath.Base(s.NotesDir)]; ok {
+		nw.refreshTreeQt()
+	}
+	OpenNoteQt(s.NotesDir, s.Gist, id)
+}
+
+var (
+	captureWindow *qt.QWidget
+	captureEdit   *qt.QPlainTextEdit
+	captureFolder *qt.QComboBox
+)
+
+// showQuickCaptureQt shows small window appending the text to the inbox note
+func showQuickCaptureQt() {
+	services := allNoteServices()
+	if len(services) == 0 {
+		return
+	}
+	if captureWindow == nil {
+		captureWindow = qt.NewQWidget(nil)
+		captureWindow.SetWindowTitle("Quick capture")
+		captureWindow.SetWindowIcon(globalIcon)
+		captureWindow.SetWindowFlags(qt.Tool | qt.WindowStaysOnTopHint)
+		captureWindow.Resize(420, 180)
+
+		captureEdit = qt.NewQPlainTextEdit(nil)
+		captureEdit.SetPlaceholderText("Ctrl+Enter saves to the inbox, Esc closes")
+		captureFolder = qt.NewQComboBox(nil)
+		saveBtn := qt.NewQPushButton3("Save")
+		saveBtn.OnClicked(saveQuickCaptureQt)
+
+		captureEdit.OnKeyPressEvent(func(super func(event *qt.QKeyEvent), event *qt.QKeyEvent) {
+			switch {
+			case (event.Key() == int(qt.Key_Return) || event.Key() == int(qt.Key_Enter)) && event.Modifiers()&qt.ControlModifier != 0:
+				saveQuickCaptureQt()
+			case event.Key() == int(qt.Key_Escape):
+				captureWindow.Hide()
+			default:
+				super(event)
+			}
+		})
+
+		bottom := qt.NewQHBoxLayout2()
+		bottom.AddWidget(captureFolder.QWidget)
+		bottom.AddStretch()
+		bottom.AddWidget(saveBtn.QWidget)
+		layout := qt.NewQVBoxLayout2()
+		layout.AddWidget(captureEdit.QWidget)
+		layout.AddLayout(bottom.QLayout)
+		captureWindow.SetLayout(layout.QLayout)
+	}
+	// servers files could change since the last use
+	current := captureFolder.CurrentText()
+	captureFolder.Clear()
+	for _, s := range services {
+		captureFolder.AddItem(filepath.Base(s.NotesDir))
+	}
+	if current != "" {
+		captureFolder.SetCurrentText(current)
+	}
+	captureFolder.SetVisible(len(services) > 1)
+	captureWindow.Show()
+	captureWindow.Raise()
+	captureWindow.ActivateWindow()
+	captureEdit.SetFocus()
+}
+
+func saveQuickCaptureQt() {
+	text := captureEdit.ToPlainText()
+	if strings.TrimSpace(text) == "" {
+		captureWindow.Hide()
+		return
+	}
+	for _, s := range allNoteServices() {
+		if filepath.Base(s.NotesDir) != captureFolder.CurrentText() {
+			continue
+		}
+		id, err := s.Capture(text)
+		if err != nil {
+			qt.QMessageBox_Critical(captureWindow, "Error", err.Error())
+			return
+		}
+		captureEdit.Clear()
+		captureWindow.Hide()
+		if nw, ok := notesWindowsQt[filepath.Base(s.NotesDir)]; ok {
+			nw.refreshTreeQt()
+			if nw.current != nil && nw.current.ID == id {
+				nw.selectNoteQt(id)
+			}
+		}
+		if sm, ok := Stickies[filepath.Base(s.NotesDir)]; ok {
+			sm.Refresh()
+		}
+		return
+	}
+}
@@ -0,0 +1,345 @@
+package main
+
+/* Servers import wizard
+Imports servers from Excel (.xlsx) or csv files: sheet preview, column mapping to the server fields
+(detected from the header row, can be changed), target servers file and the preview of the servers
+with duplicates highlighted, only checked servers are imported.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mappu/miqt/qt"
+)
+
+const (
+	importNoColumn   = "(none)"
+	importPreviewMax = 50
+)
+
+var importFieldLabels = map[string]string{
+	FieldHost:        "Hostname",
+	FieldIP:          "IP / address",
+	FieldUser:        "Username",
+	FieldPassword:    "Password",
+	FieldPort:        "Port",
+	FieldType:        "Type",
+	FieldDescription: "Description",
+	FieldTags:        "Tags",
+	FieldGroup:       "Group",
+	FieldJump:        "Jump host",
+	FieldPrivateKey:  "Private key",
+}
+
+// importWizard keeps the state between the wizard pages
+type importWizard struct {
+	wizard  *qt.QWizard
+	sheets  []XLSXSheet
+	fileIn  *qt.QLineEdit
+	sheetIn *qt.QComboBox
+	header  *qt.QCheckBox
+	sheet   *qt.QTableWidget
+	columns map[string]*qt.QComboBox
+	target  *qt.QComboBox
+	preview *qt.QTableWidget
+	list    []Server
+}
+
+// showImportWizardQt imports servers from xlsx or csv file to the selected servers file
+func showImportWizardQt(parent *qt.QWidget) {
+	if len(ymlfiles) == 0 {
+		QTshowError(parent, "Import", "No servers files found.")
+		return
+	}
+	w := &importWizard{wizard: qt.NewQWizard(parent), columns: make(map[string]*qt.QComboBox)}
+	w.wizard.SetWindowTitle("Import servers")
+	w.wizard.SetOption(qt.QWizard__NoBackButtonOnStartPage)
+	w.wizard.SetButtonText(qt.QWizard__FinishButton, "Import")
+	w.wizard.Resize(760, 520)
+	defer w.wizard.Destroy()
+
+	w.wizard.AddPage(w.sourcePage())
+	w.wizard.AddPage(w.mappingPage())
+	w.wizard.AddPage(w.previewPage())
+
+	w.wizard.OnValidateCurrentPage(func(super func() bool) bool {
+		if w.wizard.CurrentId() == 0 && len(w.rows()) < 2 {
+			QTshowError(w.wizard.QWidget, "Import", "Select a file with at least one server row.")
+			return false
+		}
+		return super()
+	})
+	w.wizard.OnCurrentIdChanged(func(id int) {
+		switch id {
+		case 1:
+			w.detectMapping()
+		case 2:
+			w.updatePreview()
+		}
+	})
+
+	if w.wizard.Exec() != int(qt.QDialog__Accepted) {
+		return
+	}
+	w.importChecked()
+}
+
+func (w *importWizard) sourcePage() *qt.QWizardPage {
+	page := qt.NewQWizardPage(nil)
+	page.SetTitle("Select file")
+	page.SetSubTitle("Excel workbook (.xlsx) or csv file with one server per row.")
+
+	w.fileIn = qt.NewQLineEdit(nil)
+	w.fileIn.SetReadOnly(true)
+	browse := qt.NewQPushButton3("Browse...")
+	w.sheetIn = qt.NewQComboBox(nil)
+	w.header = qt.NewQCheckBox4("First row is the header", nil)
+	w.header.SetChecked(true)
+	w.sheet = qt.NewQTableWidget(nil)
+	w.sheet.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+
+	browse.OnClicked(func() {
+		file := qt.QFileDialog_GetOpenFileName4(w.wizard.QWidget, "Import servers", env.homeDir, "Spreadsheets (*.xlsx *.csv);;All files (*)")
+		if file == "" {
+			return
+		}
+		sheets, err := ReadTableFile(file)
+		if err != nil {
+			QTshowError(w.wizard.QWidget, "Import", err.Error())
+			return
+		}
+		w.sheets = sheets
+		w.fileIn.SetText(file)
+		w.sheetIn.Clear()
+		for _, s := range sheets {
+			w.sheetIn.AddItem(s.Name)
+		}
+		w.sheetIn.SetVisible(len(sheets) > 1)
+		w.showSheet()
+	})
+	w.sheetIn.OnCurrentIndexChanged(func(int) { w.showSheet() })
+	w.sheetIn.SetVisible(false)
+
+	fileRow := qt.NewQHBoxLayout2()
+	fileRow.AddWidget(w.fileIn.QWidget)
+	fileRow.AddWidget(browse.QWidget)
+	fileRow.AddWidget(w.sheetIn.QWidget)
+	layout := qt.NewQVBoxLayout2()
+	layout.AddLayout(fileRow.QLayout)
+	layout.AddWidget(w.header.QWidget)
+	layout.AddWidget(w.sheet.QWidget)
+	page.SetLayout(layout.QLayout)
+	return page
+}
+
+// rows returns rows of the selected sheet
+func (w *importWizard) rows() [][]string {
+	i := w.sheetIn.CurrentIndex()
+	if i < 0 || i >= len(w.sheets) {
+		return nil
+	}
+	return w.sheets[i].Rows
+}
+
+// columnNames returns names of the sheet columns, letters with the header names when there is the header row
+func (w *importWizard) columnNames() []string {
+	rows := w.rows()
+	count := 0
+	for _, r := range rows {
+		count = max(count, len(r))
+	}
+	names := make([]string, count)
+	for i := range names {
+		names[i] = XLSXColumnName(i)
+		if w.header.IsChecked() && len(rows) > 0 && i < len(rows[0]) && strings.TrimSpace(rows[0][i]) != "" {
+			names[i] += ": " + strings.TrimSpace(rows[0][i])
+		}
+	}
+	return names
+}
+
+// dataRows returns rows without the header row
+func (w *importWizard) dataRows() [][]string {
+	rows := w.rows()
+	if w.header.IsChecked() && len(rows) > 0 {
+		return rows[1:]
+	}
+	return rows
+}
+
+func (w *importWizard) showSheet() {
+	rows := w.rows()
+	names := w.columnNames()
+	w.sheet.Clear()
+	w.sheet.SetColumnCount(len(names))
+	w.sheet.SetHorizontalHeaderLabels(names)
+	w.sheet.SetRowCount(min(len(rows), importPreviewMax))
+	for r := 0; r < len(rows) && r < importPreviewMax; r++ {
+		for c, v := range rows[r] {
+			w.sheet.SetItem(r, c, qt.NewQTableWidgetItem2(v))
+		}
+	}
+	w.sheet.ResizeColumnsToContents()
+}
+
+func (w *importWizard) mappingPage() *qt.QWizardPage {
+	page := qt.NewQWizardPage(nil)
+	page.SetTitle("Map columns")
+	page.SetSubTitle("Columns are detected by the header names, check the mapping and select the servers file.")
+
+	form := qt.NewQFormLayout(nil)
+	for _, field := range ImportFields {
+		combo := qt.NewQComboBox(nil)
+		w.columns[field] = combo
+		form.AddRow3(importFieldLabels[field]+":", combo.QWidget)
+	}
+	w.target = qt.NewQComboBox(nil)
+	for _, name := range baseNames(ymlfiles) {
+		w.target.AddItem(name)
+	}
+	form.AddRow3("Import to:", w.target.QWidget)
+	page.SetLayout(form.QLayout)
+	return page
+}
+
+// detectMapping fills the column combos, the selection is detected from the header row
+func (w *importWizard) detectMapping() {
+	names := w.columnNames()
+	mapping := make(map[string]int)
+	if w.header.IsChecked() && len(w.rows()) > 0 {
+		mapping = DetectColumns(w.rows()[0])
+	}
+	if _, ok := mapping[FieldIP]; !ok {
+		if col := DetectAddressColumn(w.dataRows()); col >= 0 {
+			mapping[FieldIP] = col
+		}
+	}
+	for _, field := range ImportFields {
+		combo := w.columns[field]
+		combo.Clear()
+		combo.AddItem(importNoColumn)
+		for _, name := range names {
+			combo.AddItem(name)
+		}
+		if col, ok := mapping[field]; ok {
+			combo.SetCurrentIndex(col + 1)
+		}
+	}
+}
+
+// mapping returns the selected columns of the fields
+func (w *importWizard) mapping() map[string]int {
+	mapping := make(map[string]int)
+	for field, combo := range w.columns {
+		if i := combo.CurrentIndex(); i > 0 {
+			mapping[field] = i - 1
+		}
+	}
+	return mapping
+}
+
+func (w *importWizard) previewPage() *qt.QWizardPage {
+	page := qt.NewQWizardPage(nil)
+	page.SetTitle("Preview")
+	page.SetSubTitle("Only checked servers are imported, servers that already exist in the servers file are unchecked.")
+	page.SetCommitPage(true)
+
+	w.preview = qt.NewQTableWidget(nil)
+	w.preview.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+	w.preview.VerticalHeader().SetVisible(false)
+	layout := qt.NewQVBoxLayout2()
+	layout.AddWidget(w.preview.QWidget)
+	page.SetLayout(layout.QLayout)
+	return page
+}
+
+func (w *importWizard) targetPath() string {
+	path, err := fullPathFor(w.target.CurrentText(), ymlfiles)
+	if err != nil {
+		guiLog.Errorf("Error finding full path for %s: %v", w.target.CurrentText(), err)
+	}
+	return path
+}
+
+// updatePreview builds the servers from the rows by the mapping and marks the duplicates
+func (w *importWizard) updatePreview() {
+	w.list = ServersFromRows(w.dataRows(), w.mapping())
+	target := w.targetPath()
+	var existing, others []Server
+	for _, srv := range servers {
+		if srv.SourcePath == target {
+			existing = append(existing, srv)
+		} else {
+			others = append(others, srv)
+		}
+	}
+
+	labels := []string{"Host", "IP", "User", "Port", "Type", "Tags", "Description", "Status"}
+	w.preview.Clear()
+	w.preview.SetColumnCount(len(labels))
+	w.preview.SetHorizontalHeaderLabels(labels)
+	w.preview.SetRowCount(len(w.list))
+	var seen []Server
+	for r, srv := range w.list {
+		status, color := "new", ""
+		switch {
+		case isDuplicateServer(existing, srv):
+			status, color = "exists in "+w.target.CurrentText(), "#f5b7b1"
+		case isDuplicateServer(seen, srv):
+			status, color = "duplicate row", "#f5b7b1"
+		case isDuplicateServer(others, srv):
+			status, color = "exists in another file", "#f9e79f"
+		}
+		seen = append(seen, srv)
+		for c, v := range []string{srv.Host, srv.IP, srv.User, srv.Port, srv.Type, srv.Tags, srv.Description, status} {
+			item := qt.NewQTableWidgetItem2(v)
+			if color != "" {
+				item.SetBackground(qt.NewQBrush3(qt.NewQColor6(color)))
+			}
+			if c == 0 {
+				item.SetFlags(item.Flags() | qt.ItemIsUserCheckable)
+				if strings.HasPrefix(status, "new") || color == "#f9e79f" {
+					item.SetCheckState(qt.Checked)
+				} else {
+					item.SetCheckState(qt.Unchecked)
+				}
+			}
+			w.preview.SetItem(r, c, item)
+		}
+	}
+	w.preview.ResizeColumnsToContents()
+}
+
+// importChecked adds the checked servers to the servers file
+func (w *importWizard) importChecked() {
+	target := w.targetPath()
+	if target == "" {
+		return
+	}
+	count := 0
+	for r, srv := range w.list {
+		if item := w.preview.Item(r, 0); item == nil || item.CheckState() != qt.Checked {
+			continue
+		}
+		srv.ID = uuid.NewString()
+		srv.SourcePath = target
+		srv.SourceName = filepath.Base(target)
+		// plain text from the sheet, encrypted with the key of the target file
+		srv.Password = srv.EncryptPassword(srv.Password)
+		servers = append(servers, srv)
+		count++
+	}
+	if count == 0 {
+		return
+	}
+	pushServersToFile()
+	auditEvent(AuditImportServers, "", filepath.Base(target), fmt.Sprintf("%s: %d servers", filepath.Base(w.fileIn.Text()), count))
+	importLog.Infof("Imported %d servers from %s to %s", count, w.fileIn.Text(), target)
+	updateServerTable()
+	updateTrayMenu()
+	QTshowInfo(serverTableWindow, "Import", fmt.Sprintf("%d servers imported to %s.", count, filepath.Base(target)))
+}
@@ -7,7 +7,6 @@ sshexperiment - Conan project
 
 import (
 	"fmt"
-	"log"
 	"runtime"
 	"sort"
 	"strings"
@@ -23,6 +22,7 @@ var serverTableWindow *qt.QWidget
 var lastSearch string // last‐used search term
 var lastFoundRow int  // index of last match
 var ServersListTable *qt.QTableWidget
+var serverLintBanner *qt.QLabel // problems of the servers files
 var draggedRow int = -1
 
 var ServerTableColumns = []string{
@@ -32,8 +32,10 @@ var ServerTableColumns = []string{
 	"User",
 	"Description",
 	"Tags",
+	"Group",
 	"Source",
 	"Availability",
+	"Notes",
 }
 
 func ShowConfirmDialog(parent *qt.QWidget, title, text string) bool {
@@ -268,6 +270,13 @@ func showServerTable() {
 	// Handle double-click
 	ServersListTable.OnCellDoubleClicked(func(row, col int) {
 		if row >= 0 && row < len(servers) {
+			// double click on linked notes opens the first one instead of connecting
+			if col == colNotes {
+				if links := linkedNotes(servers[row]); len(links) > 0 {
+					OpenNoteQt(links[0].NotesDir, findGist(servers[row].SourceName), links[0].ID)
+					return
+				}
+			}
 			println("Double clicked on:", servers[row].Host)
 			go ClientConnect(servers[row])
 		}
@@ -280,7 +289,7 @@ func showServerTable() {
 			if currentRow >= 0 {
 				// Call your function here to connect or act
 				selectedServer := servers[currentRow] // adapt if you have a filtered list
-				log.Printf("ENTER pressed, connecting to: %s\n", selectedServer.Host)
+				guiLog.Debugf("ENTER pressed, connecting to: %s", selectedServer.Host)
 				go ClientConnect(selectedServer)
 			}
 		default:
@@ -331,17 +340,21 @@ func showServerTable() {
 	//newIcon := qt.NewQIcon4(":/qt-project.org/styles/commonstyle/images/file-128.png")
 
 	deletefunc := func() {
-		idx := ServersListTable.CurrentRow()
+		idx := currentServerRow()
+		if idx >= 0 && idx < len(servers) && servers[idx].ReadOnly() {
+			QTshowError(serverTableWindow, "Error", errReadOnly(servers[idx]).Error())
+			return
+		}
 		if idx >= 0 && idx < len(servers) {
 			if ShowConfirmDialog(serverTableWindow, "Delete?", "Are you sure you want to delete "+servers[idx].Host+" server?") {
 				// User confirmed (Yes)
-				log.Printf("deleting\n")
+				guiLog.Debugf("deleting")
 				servers = append(servers[:idx], servers[idx+1:]...)
 				pushServersToFile()
 				updateServerTable() // refresh the table
 				updateTrayMenu()    // update tray menu
 			} else {
-				log.Printf("delete canceled\n")
+				guiLog.Debugf("delete canceled")
 			}
 		} else {
 			QTshowError(nil, "Error", "No server selected!")
@@ -351,22 +364,22 @@ func showServerTable() {
 
 	addToolBtn(addIcon, "Add server", "Add a new server", func() {
 		// show add dialog for servers[row]
-		log.Printf("servers table add\n")
+		guiLog.Debugf("servers table add")
 		showServerForm(nil, nil)
 	})
 	addToolBtn(editIcon, "Edit", "Edit selected server", func() {
-		row := ServersListTable.CurrentRow()
+		row := currentServerRow()
 		if row >= 0 && row < len(servers) {
 			// show edit dialog for servers[row]
-			log.Printf("servers table edit\n")
+			guiLog.Debugf("servers table edit")
 			showServerForm(&servers[row], nil)
 		}
 	})
 	addToolBtn(deleteIcon, "Delete", "Delete selected server", func() {
-		row := ServersListTable.CurrentRow()
+		row := currentServerRow()
 		if row >= 0 && row < len(servers) {
 			// confirm and delete servers[row]
-			log.Printf("servers table delete\n")
+			guiLog.Debugf("servers table delete")
 			deletefunc()
 
 		}
@@ -377,7 +390,7 @@ func showServerTable() {
 	searchfunc := func() {
 		if len(servers) > 0 {
 
-			log.Printf("servers table search \n")
+			guiLog.Debugf("servers table search")
 			showCustomSearchDialog(serverTableWindow, func(query string) {
 				idx := searchTable(query, 0)
 				if idx >= 0 {
@@ -431,7 +444,7 @@ func showServerTable() {
 	addToolBtn(pingIcon, "Ping", "Ping all servers to check availability", func() {
 		if len(servers) > 0 {
 			// servers ping
-			log.Printf("servers table ping\n")
+			guiLog.Debugf("servers table ping")
 			pingAll()
 		}
 	})
@@ -440,7 +453,7 @@ func showServerTable() {
 	addToolBtn(pushIcon, "Push", "Push serverrs to remote storage", func() {
 		if len(servers) > 0 {
 			// servers sync push
-			log.Printf("servers table push\n")
+			guiLog.Debugf("servers table push")
 			err := UploadGists()
 			if err != nil {
 				QTshowError(nil, "Error", fmt.Sprintf("Unable to push servers: %s", err))
@@ -465,43 +478,53 @@ func showServerTable() {
 		QTshowInfo(nil, "Info", "All servers pulled successfully.")
 	})
 	toolbar.AddSeparator()
-	addToolBtn(importIcon, "Import", "Import servers from a file", func() {
-		row := ServersListTable.CurrentRow()
-		if row >= 0 && row < len(servers) {
-			// Import servers
-			log.Printf("servers table import\n")
-			QTshowInfo(nil, "Infp", "This feature is not implemented yet.")
-		}
+	addToolBtn(importIcon, "Import", "Import servers from Excel or csv file", func() {
+		guiLog.Debugf("servers table import")
+		showImportWizardQt(serverTableWindow)
 	})
 	addToolBtn(exportIcon, "Export", "Export servers to a file", func() {
-		row := ServersListTable.CurrentRow()
+		row := currentServerRow()
 		if row >= 0 && row < len(servers) {
 			// Export servers
-			log.Printf("servers table export\n")
+			guiLog.Debugf("servers table export")
 			QTshowInfo(nil, "Infp", "This feature is not implemented yet.")
 		}
 	})
 	toolbar.AddSeparator()
 	addToolBtn(connectIcon, "Connect", "Connect to the selected server", func() {
-		row := ServersListTable.CurrentRow()
+		row := currentServerRow()
 		if row >= 0 && row < len(servers) {
 			// Connect to server
-			log.Printf("servers table Connect\n")
+			guiLog.Debugf("servers table Connect")
 			go ClientConnect(servers[row])
 		}
 	})
 
+	treeAction := qt.NewQAction6(qt.QApplication_Style().StandardIcon(qt.QStyle__SP_DirIcon, nil, nil), "Tree", serverTableWindow.QObject)
+	treeAction.SetToolTip("Show the servers in groups, click on the column header to group by the column")
+	treeAction.SetCheckable(true)
+	treeAction.OnToggled(func(checked bool) {
+		guiLog.Debugf("servers table tree view: %v", checked)
+		if checked {
+			updateServerTree()
+			serverViews.SetCurrentIndex(1)
+		} else {
+			serverViews.SetCurrentIndex(0)
+		}
+	})
+	toolbar.AddActions([]*qt.QAction{treeAction})
+
 	addToolBtn(resizeIcon, "AutoSize", "Autosize all columns of the table depending on the text length", func() {
-		row := ServersListTable.CurrentRow()
+		row := currentServerRow()
 		if row >= 0 && row < len(servers) {
 			// Autoresize servers table columns
-			log.Printf("servers table AutoSize\n")
+			guiLog.Debugf("servers table AutoSize")
 			autoSizeColumns()
 		}
 	})
 	toolbar.AddSeparator()
 	addToolBtn(quitIcon, "Close", "Close this window", func() {
-		log.Printf("servers table close\n")
+		guiLog.Debugf("servers table close")
 		serverTableWindow.Close()
 	})
 
@@ -527,7 +550,7 @@ func showServerTable() {
 			row := ServersListTable.CurrentRow()
 			if row >= 0 && row < len(servers) {
 				// server row edit context menu item
-				log.Printf("servers table edit\n")
+				guiLog.Debugf("servers table edit")
 				showServerForm(&servers[row], nil)
 			}
 		})
@@ -536,11 +559,19 @@ func showServerTable() {
 		deleteAction.SetToolTip("Delete this server")
 		deleteAction.OnTriggered(func() {
 			// server row delete context menu item
-			log.Printf("delete action")
+			guiLog.Debugf("delete action")
 			deletefunc()
 		})
 
-		menu.AddActions([]*qt.QAction{connectAction, editAction})
+		noteAction := qt.NewQAction2("New note from template...")
+		noteAction.SetToolTip("Create note linked to this server")
+		noteAction.OnTriggered(func() {
+			newServerNoteQt(serverTableWindow, servers[row])
+		})
+
+		menu.AddActions([]*qt.QAction{connectAction, editAction, noteAction})
+		menu.AddSeparator()
+		qtAddCopyMenu(menu, servers[row])
 		menu.AddSeparator()
 		menu.AddActions([]*qt.QAction{deleteAction})
 		// launch context menu in the middle of row
@@ -551,7 +582,12 @@ func showServerTable() {
 	// Layout
 	layout := qt.NewQVBoxLayout(nil)
 	layout.AddWidget(toolbar.QWidget)
-	layout.AddWidget(ServersListTable.QWidget)
+	layout.AddWidget(serverLintBanner.QWidget)
+	ServersTreeView = newServersTreeQt()
+	serverViews = qt.NewQStackedWidget(nil)
+	serverViews.AddWidget(ServersListTable.QWidget)
+	serverViews.AddWidget(ServersTreeView.QWidget)
+	layout.AddWidget(serverViews.QWidget)
 	// set content bounds to match the window
 	layout.SetContentsMargins(0, 0, 0, 0)
 	layout.SetSpacing(0)
@@ -601,8 +637,22 @@ func updateServerTable() {
 		useritem := qt.NewQTableWidgetItem2(s.User)
 		descitem := qt.NewQTableWidgetItem2(s.Description)
 		tagsitem := qt.NewQTableWidgetItem2(s.Tags)
-		srcitem := qt.NewQTableWidgetItem2(s.SourceName)
+		groupitem := qt.NewQTableWidgetItem2(s.Group)
+		srcitem := qt.NewQTableWidgetItem2(s.SourceRel())
 		srcavail := qt.NewQTableWidgetItem2(s.Availability)
+		notesitem := qt.NewQTableWidgetItem2("")
+		if s.ReadOnly() {
+			// servers of the dynamic inventory are read-only
+			gray := qt.NewQBrush3(qt.NewQColor6("#808080"))
+			for _, item := range []*qt.QTableWidgetItem{hostitem, typeitem, ipitem, useritem, descitem, tagsitem, groupitem, srcitem} {
+				item.SetForeground(gray)
+			}
+			srcitem.SetToolTip("Read-only, from the inventory " + s.Inventory)
+		}
+		if links := linkedNotes(s); len(links) > 0 {
+			notesitem.SetText("📓 " + linkedNoteTitles(links))
+			notesitem.SetToolTip("Double click to open " + links[0].Title)
+		}
 		if !settings.ServerTableGui.DisableTooltips {
 			hostitem.SetToolTip(s.Host)
 			typeitem.SetToolTip(s.Type)
@@ -610,7 +660,10 @@ func updateServerTable() {
 			useritem.SetToolTip(s.User)
 			descitem.SetToolTip(s.Description)
 			tagsitem.SetToolTip(s.Tags)
-			srcitem.SetToolTip(s.SourceName)
+			groupitem.SetToolTip(s.Group)
+			if !s.ReadOnly() {
+				srcitem.SetToolTip(s.SourcePath)
+			}
 			srcavail.SetToolTip(s.Availability)
 		}
 		ServersListTable.SetItem(row, 0, hostitem)
@@ -619,8 +672,10 @@ func updateServerTable() {
 		ServersListTable.SetItem(row, 3, useritem)
 		ServersListTable.SetItem(row, 4, descitem)
 		ServersListTable.SetItem(row, 5, tagsitem)
-		ServersListTable.SetItem(row, 6, srcitem)
-		ServersListTable.SetItem(row, 7, srcavail)
+		ServersListTable.SetItem(row, 6, groupitem)
+		ServersListTable.SetItem(row, 7, srcitem)
+		ServersListTable.SetItem(row, 8, srcavail)
+		ServersListTable.SetItem(row, 9, notesitem)
 	}
 
 	// FIXME should be loaded from the config file is specified
@@ -636,15 +691,53 @@ func updateServerTable() {
 	ServersListTable.SetColumnWidth(4, 150)
 	// tags column size
 	ServersListTable.SetColumnWidth(5, 120)
-	// source column size
+	// group column size
 	ServersListTable.SetColumnWidth(6, 120)
-	// availability column size
+	// source column size
 	ServersListTable.SetColumnWidth(7, 120)
+	// availability column size
+	ServersListTable.SetColumnWidth(8, 120)
+	// notes column size
+	ServersListTable.SetColumnWidth(9, 150)
 
+	updateServerTree()
+	updateLintBannerQt()
+}
+
+// updateLintBannerQt shows problems of the servers files above the servers table
+func updateLintBannerQt() {
+	if serverLintBanner == nil {
+		serverLintBanner = qt.NewQLabel5("", nil)
+		serverLintBanner.SetWordWrap(true)
+		serverLintBanner.SetStyleSheet("background-color: #f9e79f; color: #000000; padding: 4px;")
+	}
+	// keys are wiped while the app is locked, passwords can't be checked
+	if appLocked {
+		return
+	}
+	issues := LintServers(ymlfiles)
+	serverLintBanner.SetVisible(len(issues) > 0)
+	if len(issues) == 0 {
+		return
+	}
+	serverLintBanner.SetText("⚠ " + lintSummary(issues))
+	var lines []string
+	for i, issue := range issues {
+		if i == 30 {
+			lines = append(lines, fmt.Sprintf("... %d more", len(issues)-i))
+			break
+		}
+		lines = append(lines, issue.String())
+	}
+	serverLintBanner.SetToolTip(strings.Join(lines, "\n"))
 }
 
 // showServerForm - create or edit server window
 func showServerForm(s *Server, parent *qt.QWidget) {
+	if s != nil && s.ReadOnly() {
+		QTshowError(parent, "Error", errReadOnly(*s).Error())
+		return
+	}
 	isNew := s == nil
 	var srv Server
 	if isNew {
@@ -669,6 +762,15 @@ func showServerForm(s *Server, parent *qt.QWidget) {
 	idEdit.SetText(srv.ID)
 	formLayout.AddRow(qt.NewQLabel5("ID", dialog.QWidget).QWidget, idEdit.QWidget)
 
+	// -- Link ID (readonly), can be used in notes servers: list
+	if !isNew {
+		linkEdit := qt.NewQLineEdit(dialog.QWidget)
+		linkEdit.SetReadOnly(true)
+		linkEdit.SetText(srv.StableID())
+		linkEdit.SetToolTip("Use host, IP or this id in the servers: list of a note to link it to this server")
+		formLayout.AddRow(qt.NewQLabel5("Link ID", dialog.QWidget).QWidget, linkEdit.QWidget)
+	}
+
 	// -- Source file (YAML), Combobox
 	ymlnames := baseNames(ymlfiles)
 	nameCombo := qt.NewQComboBox(dialog.QWidget)
@@ -721,11 +823,24 @@ func showServerForm(s *Server, parent *qt.QWidget) {
 	pwRowLayout.AddWidget(showPwCheck.QWidget)
 	formLayout.AddRow(qt.NewQLabel5("Password", dialog.QWidget).QWidget, pwRowWidget)
 
+	// -- TOTP secret (as password field)
+	totpEdit := qt.NewQLineEdit(dialog.QWidget)
+	totpEdit.SetEchoMode(qt.QLineEdit__Password)
+	totpEdit.SetPlaceholderText("base32 secret or otpauth:// uri")
+	totpEdit.SetText(srv.DecryptTOTP())
+	formLayout.AddRow(qt.NewQLabel5("TOTP secret", dialog.QWidget).QWidget, totpEdit.QWidget)
+
 	// -- PrivateKey
 	keyEdit := qt.NewQLineEdit(dialog.QWidget)
 	keyEdit.SetText(srv.PrivateKey)
 	formLayout.AddRow(qt.NewQLabel5("PrivateKey", dialog.QWidget).QWidget, keyEdit.QWidget)
 
+	// -- Jump host
+	jumpEdit := qt.NewQLineEdit(dialog.QWidget)
+	jumpEdit.SetPlaceholderText("[user@]host[:port]")
+	jumpEdit.SetText(srv.Jump)
+	formLayout.AddRow(qt.NewQLabel5("Jump host", dialog.QWidget).QWidget, jumpEdit.QWidget)
+
 	// -- Port
 	portEdit := qt.NewQLineEdit(dialog.QWidget)
 	portEdit.SetText(srv.Port)
@@ -751,6 +866,21 @@ func showServerForm(s *Server, parent *qt.QWidget) {
 	tagsEdit.SetText(srv.Tags)
 	formLayout.AddRow(qt.NewQLabel5("Tags", dialog.QWidget).QWidget, tagsEdit.QWidget)
 
+	// -- Group
+	groupEdit := qt.NewQLineEdit(dialog.QWidget)
+	groupEdit.SetText(srv.Group)
+	groupEdit.SetPlaceholderText("DC1/Rack4/Routers")
+	groupEdit.SetToolTip("Group of the server, levels are separated by /")
+	groupEdit.SetCompleter(qt.NewQCompleter6(folderGroups(serverGroups(servers), srv.folderGroup()), dialog.QObject))
+	formLayout.AddRow(qt.NewQLabel5("Group", dialog.QWidget).QWidget, groupEdit.QWidget)
+
+	// -- Linked notes
+	notesEdit := qt.NewQLineEdit(dialog.QWidget)
+	notesEdit.SetText(strings.Join(srv.Notes, ", "))
+	notesEdit.SetPlaceholderText("ops/backup, runbooks/restore")
+	notesEdit.SetToolTip("Comma separated notes (paths in the notes of the servers file) linked to this server")
+	formLayout.AddRow(qt.NewQLabel5("Notes", dialog.QWidget).QWidget, notesEdit.QWidget)
+
 	// -- Description (multiline)
 	descEdit := qt.NewQTextEdit(dialog.QWidget)
 	descEdit.SetText(srv.Description)
@@ -785,20 +915,31 @@ func showServerForm(s *Server, parent *qt.QWidget) {
 			srv.SourceName = nameCombo.CurrentText()
 			selectedFileFullPath, err := fullPathFor(srv.SourceName, ymlfiles)
 			if err != nil {
-				log.Printf("Error finding full path for %s: %v\n", srv.SourceName, err)
+				guiLog.Errorf("Error finding full path for %s: %v", srv.SourceName, err)
 			}
 			srv.SourcePath = selectedFileFullPath
 		}
 
+		if secret := strings.TrimSpace(totpEdit.Text()); secret != "" {
+			if _, err := parseTOTPSecret(secret); err != nil {
+				qt.QMessageBox_Warning(dialog.QWidget, "Info", "Invalid TOTP secret: "+err.Error())
+				return
+			}
+		}
+
 		srv.Host = hostEdit.Text()
 		srv.IP = ipEdit.Text()
 		srv.User = userEdit.Text()
 		srv.Port = portEdit.Text()
 		srv.PrivateKey = keyEdit.Text()
+		srv.Jump = strings.TrimSpace(jumpEdit.Text())
 		srv.Type = typeCombo.CurrentText()
 		srv.Tags = tagsEdit.Text()
+		srv.Group = cleanGroup(groupEdit.Text())
+		srv.Notes = splitList(notesEdit.Text())
 		srv.Description = descEdit.ToPlainText()
 		srv.Password = srv.EncryptPassword(passEdit.Text())
+		srv.TOTP = srv.EncryptTOTP(totpEdit.Text())
 
 		if isNew {
 			servers = append(servers, srv)
@@ -854,7 +995,7 @@ func pingAll() {
 						servers[idx].Availability = "Down"
 					}
 				}
-				log.Printf("Server: %s availability: %s\n", servers[idx].Host, servers[idx].Availability)
+				guiLog.Debugf("Server: %s availability: %s", servers[idx].Host, servers[idx].Availability)
 				CallOnQtMain(updateServerTable)
 			}(i)
 
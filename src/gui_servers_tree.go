@@ -0,0 +1,240 @@
+package main
+
+/* Servers tree
+Tree view of the servers table: servers files with their groups, clicking on the column header
+groups the servers by the column instead (click again to return to the groups).
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mappu/miqt/qt"
+)
+
+var ServersTreeView *qt.QTreeWidget
+var serverViews *qt.QStackedWidget // table and tree of the servers window
+
+var serverTreeGroupBy = -1                      // column the tree is grouped by, -1 groups by the servers files and groups
+var serverTreeCollapsed = make(map[string]bool) // collapsed group paths, kept between updates
+
+// roles of the tree item data
+var (
+	serverTreeIDRole   = int(qt.UserRole)     // server ID
+	serverTreePathRole = int(qt.UserRole) + 1 // group path
+)
+
+// column indexes of ServerTableColumns
+const (
+	colTags  = 5
+	colGroup = 6
+	colNotes = 9
+)
+
+// serverColumnTexts returns texts of the server in the columns of the servers table
+func serverColumnTexts(s Server) []string {
+	notes := ""
+	if links := linkedNotes(s); len(links) > 0 {
+		notes = "📓 " + linkedNoteTitles(links)
+	}
+	return []string{s.Host, s.Type, s.IP, s.User, s.Description, s.Tags, s.Group, s.SourceRel(), s.Availability, notes}
+}
+
+// serverTreePaths returns paths of the server in the tree grouped by the column
+func serverTreePaths(s Server, col int) [][]string {
+	switch col {
+	case -1:
+		return [][]string{append([]string{serverFileName(s)}, s.GroupPath()...)}
+	case colGroup:
+		if levels := s.GroupPath(); len(levels) > 0 {
+			return [][]string{levels}
+		}
+	case colTags:
+		var paths [][]string
+		for _, t := range s.TagsList() {
+			paths = append(paths, []string{t})
+		}
+		if len(paths) > 0 {
+			return paths
+		}
+	default:
+		if v := strings.TrimSpace(serverColumnTexts(s)[col]); v != "" {
+			return [][]string{{v}}
+		}
+	}
+	return [][]string{{"(none)"}}
+}
+
+// newServersTreeQt creates the tree view of the servers
+func newServersTreeQt() *qt.QTreeWidget {
+	tree := qt.NewQTreeWidget(nil)
+	tree.SetColumnCount(len(ServerTableColumns))
+	tree.SetAlternatingRowColors(true)
+	tree.SetSelectionMode(qt.QAbstractItemView__SingleSelection)
+	tree.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+	tree.Header().SetSectionsClickable(true)
+	tree.Header().SetToolTip("Click on the column to group the servers by it, click again to group by the servers groups")
+
+	tree.Header().OnSectionClicked(func(col int) {
+		if col == serverTreeGroupBy {
+			serverTreeGroupBy = -1
+		} else {
+			serverTreeGroupBy = col
+		}
+		updateServerTree()
+	})
+	tree.OnItemExpanded(func(item *qt.QTreeWidgetItem) {
+		delete(serverTreeCollapsed, item.Data(0, serverTreePathRole).ToString())
+	})
+	tree.OnItemCollapsed(func(item *qt.QTreeWidgetItem) {
+		serverTreeCollapsed[item.Data(0, serverTreePathRole).ToString()] = true
+	})
+	tree.OnItemDoubleClicked(func(item *qt.QTreeWidgetItem, col int) {
+		idx := serverTreeIndex(item)
+		if idx < 0 {
+			return
+		}
+		// double click on linked notes opens the first one instead of connecting
+		if col == colNotes {
+			if links := linkedNotes(servers[idx]); len(links) > 0 {
+				OpenNoteQt(links[0].NotesDir, findGist(servers[idx].SourceName), links[0].ID)
+				return
+			}
+		}
+		go ClientConnect(servers[idx])
+	})
+	tree.OnKeyPressEvent(func(super func(event *qt.QKeyEvent), event *qt.QKeyEvent) {
+		switch event.Key() {
+		case int(qt.Key_Return), int(qt.Key_Enter):
+			if idx := serverTreeIndex(tree.CurrentItem()); idx >= 0 {
+				go ClientConnect(servers[idx])
+				return
+			}
+		}
+		super(event)
+	})
+
+	tree.SetContextMenuPolicy(qt.CustomContextMenu)
+	tree.OnCustomContextMenuRequested(func(pos *qt.QPoint) {
+		idx := serverTreeIndex(tree.ItemAt(pos))
+		if idx < 0 {
+			return
+		}
+		srv := servers[idx]
+		menu := qt.NewQMenu(tree.QWidget)
+		connectAction := menu.AddAction("Connect")
+		connectAction.OnTriggered(func() { go ClientConnect(srv) })
+		editAction := menu.AddAction("Edit")
+		editAction.OnTriggered(func() {
+			if idx := currentServerRow(); idx >= 0 {
+				showServerForm(&servers[idx], nil)
+			}
+		})
+		noteAction := menu.AddAction("New note from template...")
+		noteAction.OnTriggered(func() { newServerNoteQt(serverTableWindow, srv) })
+		menu.AddSeparator()
+		qtAddCopyMenu(menu, srv)
+		menu.ExecWithPos(tree.Viewport().MapToGlobal(pos))
+	})
+	return tree
+}
+
+// updateServerTree fills the tree view with the servers
+func updateServerTree() {
+	if ServersTreeView == nil {
+		return
+	}
+	selected := ""
+	if idx := serverTreeIndex(ServersTreeView.CurrentItem()); idx >= 0 {
+		selected = servers[idx].ID
+	}
+	ServersTreeView.Clear()
+
+	headers := append([]string{}, ServerTableColumns...)
+	if serverTreeGroupBy >= 0 {
+		headers[serverTreeGroupBy] = "▾ " + headers[serverTreeGroupBy]
+	}
+	ServersTreeView.SetHeaderLabels(headers)
+
+	style := qt.QApplication_Style()
+	dirIcon := style.StandardIcon(qt.QStyle__SP_DirIcon, nil, nil)
+	tree := groupServersBy(servers, func(s Server) [][]string { return serverTreePaths(s, serverTreeGroupBy) })
+
+	var current *qt.QTreeWidgetItem
+	var addGroup func(parent *qt.QTreeWidgetItem, g *ServerGroup)
+	addGroup = func(parent *qt.QTreeWidgetItem, g *ServerGroup) {
+		for _, sub := range g.Groups {
+			item := qt.NewQTreeWidgetItem()
+			item.SetText(0, fmt.Sprintf("%s (%d)", sub.Name, sub.Count()))
+			item.SetIcon(0, dirIcon)
+			item.SetData(0, serverTreePathRole, qt.NewQVariant14(sub.Path))
+			if parent == nil {
+				ServersTreeView.AddTopLevelItem(item)
+			} else {
+				parent.AddChild(item)
+			}
+			item.SetFirstColumnSpanned(true)
+			addGroup(item, sub)
+			item.SetExpanded(!serverTreeCollapsed[sub.Path])
+		}
+		for _, s := range g.Servers {
+			texts := serverColumnTexts(s)
+			item := qt.NewQTreeWidgetItem2(texts)
+			item.SetData(0, serverTreeIDRole, qt.NewQVariant14(s.ID))
+			if s.ReadOnly() {
+				gray := qt.NewQBrush3(qt.NewQColor6("#808080"))
+				for col := range texts {
+					item.SetForeground(col, gray)
+				}
+			}
+			if !settings.ServerTableGui.DisableTooltips {
+				for col, text := range texts {
+					item.SetToolTip(col, text)
+				}
+			}
+			if parent == nil {
+				ServersTreeView.AddTopLevelItem(item)
+			} else {
+				parent.AddChild(item)
+			}
+			if s.ID == selected && current == nil {
+				current = item
+			}
+		}
+	}
+	addGroup(nil, tree)
+	if current != nil {
+		ServersTreeView.SetCurrentItem(current)
+	}
+
+	ServersTreeView.SetColumnWidth(0, 200)
+	for col := 1; col < len(ServerTableColumns); col++ {
+		ServersTreeView.SetColumnWidth(col, ServersListTable.ColumnWidth(col))
+	}
+}
+
+// serverTreeIndex returns index of the server of the tree item, -1 for groups
+func serverTreeIndex(item *qt.QTreeWidgetItem) int {
+	if item == nil {
+		return -1
+	}
+	id := item.Data(0, serverTreeIDRole).ToString()
+	if id == "" {
+		return -1
+	}
+	for i, s := range servers {
+		if s.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// currentServerRow returns index of the server selected in the table or in the tree, -1 when none is selected
+func currentServerRow() int {
+	if serverViews != nil && serverViews.CurrentIndex() == 1 {
+		return serverTreeIndex(ServersTreeView.CurrentItem())
+	}
+	return ServersListTable.CurrentRow()
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -84,12 +86,18 @@ func showSettingsWindow(parent *qt.QWidget, configPath string) {
 	generalLayout.AddRow3("RDP Cmd", rdpCmd.QWidget)
 	generalLayout.AddRow3("WinBox Cmd", winboxCmd.QWidget)
 
-	// IGNORE SERVERS FILE - MULTI-LIST
-	rawIgnore := general.Key("ignore").String()
-	ignoreOptions := []string{}
-	if rawIgnore != "" {
-		ignoreOptions = strings.Split(rawIgnore, ",")
-	}
+	// SERVERS FILES DISCOVERY
+	pathsEdit := qt.NewQLineEdit4(general.Key("paths").String(), nil)
+	pathsEdit.SetPlaceholderText("~/.config/conan/servers/**, ~/.config/conan, " + env.appPath)
+	pathsEdit.SetToolTip("Comma separated folders searched for servers files, /** searches the subfolders too.\n" + serverPathsEnv + " environment variable has priority.")
+	includeEdit := qt.NewQLineEdit4(general.Key("include").String(), nil)
+	includeEdit.SetPlaceholderText("all .yml and .yaml files")
+	includeEdit.SetToolTip("Comma separated glob patterns of the file name or the relative path, e.g. prod-*.yml, team/*")
+	generalLayout.AddRow3("Servers paths", pathsEdit.QWidget)
+	generalLayout.AddRow3("Include servers files", includeEdit.QWidget)
+
+	// EXCLUDE SERVERS FILES - MULTI-LIST, ignore is the older key of the same
+	ignoreOptions := splitPatterns(general.Key("exclude").String() + "," + general.Key("ignore").String())
 
 	// Create combo box
 	ignoreCombo := qt.NewQComboBox(nil)
@@ -127,7 +135,7 @@ func showSettingsWindow(parent *qt.QWidget, configPath string) {
 	})
 
 	// Horizontal layout for combo + buttons
-	ignoreLabel := qt.NewQLabel3("Ignore servers file")
+	ignoreLabel := qt.NewQLabel3("Exclude servers files")
 	// FIX to align to the same line as the combobox
 	ignoreLabel.SetFixedHeight(50)
 
@@ -141,7 +149,7 @@ func showSettingsWindow(parent *qt.QWidget, configPath string) {
 	generalLayout.AddRow(ignoreLabel.QWidget, rowWidget)
 
 	// After creating QLineEdit/QComboBox for each field
-	for _, w := range []*qt.QWidget{enckeyEdit.QWidget, sshClientCombo.QWidget, sshCmd.QWidget, rdpCmd.QWidget, winboxCmd.QWidget} {
+	for _, w := range []*qt.QWidget{enckeyEdit.QWidget, sshClientCombo.QWidget, sshCmd.QWidget, rdpCmd.QWidget, winboxCmd.QWidget, pathsEdit.QWidget, includeEdit.QWidget} {
 		w.SetMinimumWidth(400)
 		w.SetSizePolicy2(qt.QSizePolicy__Expanding, qt.QSizePolicy__Fixed)
 	}
@@ -286,10 +294,46 @@ func showSettingsWindow(parent *qt.QWidget, configPath string) {
 	syncCheckbox.SetChecked(general.Key("sync").MustBool())
 	notesOnTopCheckbox := qt.NewQCheckBox4("Enable always on top", nil)
 	notesOnTopCheckbox.SetChecked(notes.Key("alwaysontop").MustBool())
+	notesPopupCheckbox := qt.NewQCheckBox4("Show linked notes when connecting", nil)
+	notesPopupCheckbox.SetChecked(notes.Key("popuponconnect").MustBool())
+	notesPopupCheckbox.SetToolTip("Notes linked to the server (servers: in note or notes: in server) pop up as stickies")
+	remindersCheckbox := qt.NewQCheckBox4("Notify when tasks are due", nil)
+	remindersCheckbox.SetChecked(notes.Key("reminders").MustBool(true))
+	inboxEdit := qt.NewQLineEdit4(notes.Key("inbox").String(), nil)
+	inboxEdit.SetPlaceholderText(defaultInboxNote)
+	inboxEdit.SetToolTip("Note where quick capture (ctrl+shift+space) appends the entries")
+	historyKeep := qt.NewQSpinBox(nil)
+	historyKeep.SetRange(0, 100000)
+	historyKeep.SetSuffix(" revisions")
+	historyKeep.SetSpecialValueText("Unlimited")
+	historyKeep.SetValue(notes.Key("history_keep").MustInt(0))
+	historyDays := qt.NewQSpinBox(nil)
+	historyDays.SetRange(0, 36500)
+	historyDays.SetSuffix(" days")
+	historyDays.SetSpecialValueText("Forever")
+	historyDays.SetValue(notes.Key("history_days").MustInt(0))
 	defaultSSHKey := qt.NewQLineEdit4(general.Key("defaultsshkey").String(), nil)
+	clipboardClear := qt.NewQSpinBox(nil)
+	clipboardClear.SetRange(0, 3600)
+	clipboardClear.SetSuffix(" s")
+	clipboardClear.SetSpecialValueText("Never")
+	clipboardClear.SetValue(general.Key("clipboard_clear").MustInt(30))
+	autoLock := qt.NewQSpinBox(nil)
+	autoLock.SetRange(0, 1440)
+	autoLock.SetSuffix(" min")
+	autoLock.SetSpecialValueText("Never")
+	autoLock.SetValue(general.Key("autolock").MustInt(15))
+	autoLock.SetToolTip("Lock after inactivity, works only when settings are protected with password")
 	expertLayout.AddRow3("Gist Sync", syncCheckbox.QWidget)
 	expertLayout.AddRow3("Default SSH key", defaultSSHKey.QWidget)
+	expertLayout.AddRow3("Clear copied passwords after", clipboardClear.QWidget)
+	expertLayout.AddRow3("Auto-lock after", autoLock.QWidget)
 	expertLayout.AddRow3("Notes stickies", notesOnTopCheckbox.QWidget)
+	expertLayout.AddRow3("Runbooks", notesPopupCheckbox.QWidget)
+	expertLayout.AddRow3("Task reminders", remindersCheckbox.QWidget)
+	expertLayout.AddRow3("Quick capture inbox", inboxEdit.QWidget)
+	expertLayout.AddRow3("Notes history keep", historyKeep.QWidget)
+	expertLayout.AddRow3("Notes history for", historyDays.QWidget)
 	expertTab.SetLayout(expertLayout.QLayout)
 
 	// Add tabs
@@ -333,20 +377,42 @@ func showSettingsWindow(parent *qt.QWidget, configPath string) {
 
 		general.Key("sync").SetValue(strconv.FormatBool(syncCheckbox.IsChecked()))
 		general.Key("defaultsshkey").SetValue(defaultSSHKey.Text())
+		general.Key("clipboard_clear").SetValue(strconv.Itoa(clipboardClear.Value()))
+		settings.ClipboardClear = clipboardClear.Value()
+		general.Key("autolock").SetValue(strconv.Itoa(autoLock.Value()))
+		settings.AutoLock = autoLock.Value()
 
 		notes.Key("alwaysontop").SetValue(strconv.FormatBool(notesOnTopCheckbox.IsChecked()))
-
-		// Save ignore files
-		ignore := []string{}
+		notes.Key("popuponconnect").SetValue(strconv.FormatBool(notesPopupCheckbox.IsChecked()))
+		settings.NotesSettings.PopupOnConnect = notesPopupCheckbox.IsChecked()
+		notes.Key("reminders").SetValue(strconv.FormatBool(remindersCheckbox.IsChecked()))
+		settings.NotesSettings.Reminders = remindersCheckbox.IsChecked()
+		notes.Key("inbox").SetValue(strings.TrimSpace(inboxEdit.Text()))
+		settings.NotesSettings.Inbox = strings.TrimSpace(inboxEdit.Text())
+		notes.Key("history_keep").SetValue(strconv.Itoa(historyKeep.Value()))
+		notes.Key("history_days").SetValue(strconv.Itoa(historyDays.Value()))
+		settings.NotesSettings.HistoryKeep = historyKeep.Value()
+		settings.NotesSettings.HistoryDays = historyDays.Value()
+
+		// Save servers files discovery
+		general.Key("paths").SetValue(strings.TrimSpace(pathsEdit.Text()))
+		general.Key("include").SetValue(strings.TrimSpace(includeEdit.Text()))
+		exclude := []string{}
 		for i := 0; i < ignoreCombo.Count(); i++ {
-			ignore = append(ignore, ignoreCombo.ItemText(i))
+			exclude = append(exclude, ignoreCombo.ItemText(i))
 		}
-		general.Key("ignore").SetValue(strings.Join(ignore, ","))
+		general.Key("exclude").SetValue(strings.Join(exclude, ","))
+		general.DeleteKey("ignore")
 
 		// Save selected gist section
 		name := selectedGist.CurrentText()
+		encryptionToggled := false
+		oldKey := ""
 		if name != "" {
 			sec := cfg.Section("gist " + name)
+			oldKey = sec.Key("enckey").String()
+			encryptionToggled = sec.Key("encrypt_notes").MustBool() != gistNotesEncryption.IsChecked() ||
+				sec.Key("enckey").String() != gistEnc.Text()
 			sec.Key("gistid").SetValue(gistID.Text())
 			sec.Key("gistsec").SetValue(gistSec.Text())
 			sec.Key("enckey").SetValue(gistEnc.Text())
@@ -355,7 +421,7 @@ func showSettingsWindow(parent *qt.QWidget, configPath string) {
 
 		// Encryption handling as needed (add dialog if you wish)
 		// Save config file
-		log.Printf("saving the configuration...\n")
+		guiLog.Debugf("saving the configuration...")
 		if encryptSettingsCheckbox.IsChecked() && encryptionPass != "" {
 			settings.DecryptPassword = encryptionPass
 			if err := SaveEncryptedINI(cfg, configPath, encryptionPass); err != nil {
@@ -365,9 +431,12 @@ func showSettingsWindow(parent *qt.QWidget, configPath string) {
 			cfg.SaveTo(configPath)
 		}
 		settingsWindow.Hide()
+		if encryptionToggled {
+			applyNotesEncryptionQt(name, gistNotesEncryption.IsChecked(), gistEnc.Text(), oldKey)
+		}
 	})
 	btnBox.OnRejected(func() {
-		log.Printf("Dismissing settings window...\n")
+		guiLog.Debugf("Dismissing settings window...")
 		settingsWindow.Hide()
 	})
 
@@ -383,3 +452,50 @@ func showSettingsWindow(parent *qt.QWidget, configPath string) {
 	settingsWindow.ActivateWindow()
 	settingsWindow.SetFocus()
 }
+
+// applyNotesEncryptionQt converts existing notes of the servers file when the notes encryption or its key
+// was changed, notes encrypted with the old key are decrypted with it and encrypted with the new key
+func applyNotesEncryptionQt(name string, encrypt bool, key, oldKey string) {
+	for i := range gists {
+		if gists[i].Name == name {
+			gists[i].EncryptNotes = encrypt
+			gists[i].EncKey = key
+		}
+	}
+	for _, item := range ymlfiles {
+		if filepath.Base(item) != name {
+			continue
+		}
+		s := newNoteServiceFor(item)
+		if s == nil {
+			return
+		}
+		// open windows keep their own copy of the gist settings
+		k := filepath.Base(s.NotesDir)
+		if sm, ok := Stickies[k]; ok {
+			sm.service.Gist = s.Gist
+		}
+		if nw, ok := notesWindowsQt[k]; ok {
+			nw.gist = s.Gist
+			nw.service.Gist = s.Gist
+		}
+		var report *EncryptionReport
+		var err error
+		// the gist content has to be encrypted with the new key even when the notes are not
+		if oldKey != key {
+			report, err = s.RekeyNotes(oldKey)
+		} else {
+			report, err = s.VerifyEncryption(false)
+		}
+		if err != nil {
+			qt.QMessageBox_Critical(nil, "Notes encryption", err.Error())
+			return
+		}
+		if len(report.Unreadable) > 0 {
+			qt.QMessageBox_Warning(nil, "Notes encryption", fmt.Sprintf("%d notes files could not be decrypted with the current key, see `conan notes verify`.", len(report.Unreadable)))
+		} else if report.Converted > 0 && tray != nil {
+			tray.ShowMessage4("Notes encryption", fmt.Sprintf("%d notes files of %s converted", report.Converted, name), qt.QSystemTrayIcon__Information)
+		}
+		return
+	}
+}
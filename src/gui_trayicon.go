@@ -1,7 +1,7 @@
 package main
 
 import (
-	"log"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -50,8 +50,13 @@ func showLogin(parent *qt.QWidget, onSuccess func()) {
 	icon := qt.NewQIcon4(":/Icon.png")
 	loginWin.SetWindowIcon(icon)
 
-	// Prevent window from closing with the X button: exit instead
+	// Prevent window from closing with the X button: exit instead,
+	// unless the app was locked, then it just stays locked
 	loginWin.OnCloseEvent(func(super func(event *qt.QCloseEvent), event *qt.QCloseEvent) {
+		if appLocked {
+			super(event)
+			return
+		}
 		os.Exit(1)
 	})
 
@@ -110,7 +115,7 @@ func trayIcon() {
 	qt.QGuiApplication_SetWindowIcon(globalIcon)
 
 	qtapp.OnLastWindowClosed(func() {
-		log.Printf("last window is closed\n")
+		guiLog.Debugf("last window is closed")
 	})
 
 	if runtime.GOOS == "windows" {
@@ -122,14 +127,14 @@ func trayIcon() {
 
 	encrypted, err := IsEncryptedINI(env.settingsFile)
 	if err != nil {
-		log.Printf("Unable to determine if settings file is encrypted %s\n", err)
+		guiLog.Errorf("Unable to determine if settings file is encrypted %s", err)
 		return
 	}
 
 	GUIMODE = true
 
 	if encrypted {
-		log.Printf("Settings file is encrypted!")
+		guiLog.Debugf("Settings file is encrypted!")
 		// we need to create dummy tray icon, because if we do not make it on runtime, we can't make it later
 		DummyTrayIcon()
 		//// 1) Kick off the login flow:
@@ -141,6 +146,16 @@ func trayIcon() {
 	} else {
 		trayIconLoad()
 	}
+	startAutoLock()
+	startTaskReminders()
+	// servers of the stale inventories are fetched in the background
+	if onInventoryFetched(func() {
+		CallOnQtMain(reloadServersQt)
+	}) {
+		reloadServersQt()
+	}
+	// geometry of the stickies whose notes were deleted outside of the app (cli, pull)
+	cleanStaleStickySections()
 
 	for _, item := range ymlfiles {
 		fname := trimYML(filepath.Base(item))
@@ -152,11 +167,11 @@ func trayIcon() {
 			sm := NewStickyManagerQt(service, nil)
 			// store in registry by folder name
 			key := filepath.Base(notesdir)
-			log.Printf("key is: %s\n", key)
+			guiLog.Debugf("key is: %s", key)
 			Stickies[key] = sm
 			// initial spawn
 			sm.Refresh()
-			log.Printf("Stickies %v\n", Stickies)
+			guiLog.Debugf("Stickies %v", Stickies)
 		}
 	}
 
@@ -164,9 +179,22 @@ func trayIcon() {
 	qt.QApplication_Exec()
 }
 
+// reloadServersQt reads the servers again and updates the servers table and the tray menu,
+// the servers are loaded by unlockApp when the app is locked
+func reloadServersQt() {
+	if appLocked {
+		return
+	}
+	fetchServersFromFiles()
+	if ServersListTable != nil {
+		updateServerTable()
+	}
+	updateTrayMenu()
+}
+
 // initial function of this file
 func trayIconLoad() {
-	log.Printf("Continue loading tray icon...\n")
+	guiLog.Debugf("Continue loading tray icon...")
 
 	updateTrayMenu()
 	//showFuzzySearchWindow(true)
@@ -179,12 +207,17 @@ func trayIconLoad() {
 			switch cmd {
 			case "show":
 				CallOnQtMain(func() {
-					showFuzzySearchWindow()
-
+					requireUnlocked(showFuzzySearchWindow)
+				})
+			case "capture":
+				CallOnQtMain(func() {
+					requireUnlocked(showQuickCaptureQt)
 				})
 			case "hide":
 				CallOnQtMain(func() {
-					searchWindow.Hide()
+					if searchWindow != nil {
+						searchWindow.Hide()
+					}
 				})
 			}
 		}
@@ -233,7 +266,7 @@ func updateTrayMenu() {
 	tray.SetToolTip("Conan - Server Manager")
 	tray.OnActivated(func(reason qt.QSystemTrayIcon__ActivationReason) {
 		if reason == qt.QSystemTrayIcon__Trigger && env.os == "windows" {
-			log.Printf("Tray icon clicked, showing search window...\n")
+			guiLog.Debugf("Tray icon clicked, showing search window...")
 			showFuzzySearchWindow()
 		}
 	})
@@ -244,20 +277,38 @@ func updateTrayMenu() {
 	showAction := menu.AddAction("Show")
 	showAction.SetVisible(true)
 	showAction.OnTriggered(func() {
-		showFuzzySearchWindow()
+		requireUnlocked(showFuzzySearchWindow)
 	})
 
 	srvTableItem := menu.AddAction("Servers table")
 	srvTableItem.OnTriggered(func() {
-		showServerTable()
+		requireUnlocked(showServerTable)
+	})
+
+	menu.AddAction("Tasks").OnTriggered(func() {
+		requireUnlocked(showTasksQt)
+	})
+	menu.AddAction("Quick capture").OnTriggered(func() {
+		requireUnlocked(showQuickCaptureQt)
 	})
 
+	// copy one time password submenu, only servers with TOTP secret set
+	createOTPMenu(menu, servers)
+
+	if lockSupported() {
+		menu.AddAction("Lock").OnTriggered(func() {
+			lockApp()
+		})
+	}
+
 	for _, item := range ymlfiles {
 		fname := trimYML(filepath.Base(item))
 		if fname != "" {
 			gst := findGist(filepath.Base(item))
 			menu.AddAction("-> " + fname + " notes").OnTriggered(func() {
-				ShowNotesWindowQt(nil, filepath.Join(env.configDir, fname+"-notes"), gst)
+				requireUnlocked(func() {
+					ShowNotesWindowQt(nil, filepath.Join(env.configDir, fname+"-notes"), gst)
+				})
 			})
 		}
 	}
@@ -267,35 +318,52 @@ func updateTrayMenu() {
 
 	settingsItem := optionsMenu.AddAction("Settings")
 	settingsItem.OnTriggered(func() {
-		showSettingsWindow(nil, env.settingsFile)
+		requireUnlocked(func() { showSettingsWindow(nil, env.settingsFile) })
 		//showSettingsWindow(a, w, env.settingsFile)
 	})
 
 	welcomeDlgItem := optionsMenu.AddAction("Welcome dialog")
 	welcomeDlgItem.OnTriggered(func() {
-		showWelcomeWindow()
+		requireUnlocked(showWelcomeWindow)
 	})
 
 	configLocItem := optionsMenu.AddAction("Config location")
 	configLocItem.OnTriggered(func() {
-		openFileOrDir(env.configDir)
+		requireUnlocked(func() { openFileOrDir(env.configDir) })
 	})
 
 	logFileItem := optionsMenu.AddAction("Logfile")
 	logFileItem.OnTriggered(func() {
-		openFileOrDir(env.appDebugLog)
+		requireUnlocked(func() { openFileOrDir(env.appDebugLog) })
 	})
 
 	restartItem := optionsMenu.AddAction("Restart app")
 	restartItem.OnTriggered(func() {
-		doRestart()
+		requireUnlocked(doRestart)
 	})
 
 	updateTrayItem := optionsMenu.AddAction("Update traymenu")
 	updateTrayItem.OnTriggered(func() {
-		updateTrayMenu()
+		requireUnlocked(updateTrayMenu)
 	})
 
+	if len(inventories) > 0 {
+		inventoryItem := optionsMenu.AddAction("Refresh inventories")
+		inventoryItem.OnTriggered(func() {
+			requireUnlocked(func() {
+				go func() {
+					errs := refreshInventories()
+					CallOnQtMain(func() {
+						reloadServersQt()
+						if len(errs) > 0 {
+							QTshowError(nil, "Error", fmt.Sprintf("Unable to refresh %d inventories, cached servers are used: %s", len(errs), errs[0]))
+						}
+					})
+				}()
+			})
+		})
+	}
+
 	aboutItem := optionsMenu.AddAction("About...")
 	aboutItem.OnTriggered(func() {
 		showAboutQt(nil)
@@ -323,25 +391,19 @@ func updateTrayMenu() {
 
 // Returns a slice of *qt.QMenu representing your server group structure
 func createServerMenus(parentMenu *qt.QMenu, servers []Server) {
-	// 1. Group by config file basename
-	fileGroups := make(map[string][]Server)
-	for _, srv := range servers {
-		base := strings.TrimSuffix(filepath.Base(srv.SourceName), filepath.Ext(srv.SourceName))
-		fileGroups[base] = append(fileGroups[base], srv)
-	}
+	// 1. Group by config file basename and the group path of the servers
+	tree := serverGroupTree(servers)
 
-	// 2. Sorted file names
-	fileNames := make([]string, 0, len(fileGroups))
-	for fn := range fileGroups {
-		fileNames = append(fileNames, fn)
-	}
-	sort.Strings(fileNames)
-
-	for _, fn := range fileNames {
-		srvGroup := fileGroups[fn]
-		// Group by tag
+	for _, file := range tree.Groups {
+		fileMenu := qt.NewQMenu(nil)
+		fileMenu.SetTitle(file.Name)
+		// 2. Nested submenus of the groups
+		for _, g := range file.Groups {
+			buildGroupMenu(fileMenu, g)
+		}
+		// 3. Servers without group are grouped by tag
 		tags := make(map[string][]Server)
-		for _, srv := range srvGroup {
+		for _, srv := range file.Servers {
 			if strings.TrimSpace(srv.Tags) != "" {
 				for _, t := range strings.Split(srv.Tags, ",") {
 					trimmed := strings.TrimSpace(t)
@@ -360,8 +422,9 @@ func createServerMenus(parentMenu *qt.QMenu, servers []Server) {
 		sort.Strings(tagNames)
 
 		// Build tag submenus (even if only "Untagged")
-		fileMenu := qt.NewQMenu(nil)
-		fileMenu.SetTitle(fn)
+		if len(file.Groups) > 0 && len(tagNames) > 0 {
+			fileMenu.AddSeparator()
+		}
 		for _, t := range tagNames {
 			group := tags[t]
 			tagMenu := qt.NewQMenu(nil)
@@ -373,6 +436,57 @@ func createServerMenus(parentMenu *qt.QMenu, servers []Server) {
 	}
 }
 
+// buildGroupMenu adds submenu of the group with its subgroups and servers
+func buildGroupMenu(parentMenu *qt.QMenu, g *ServerGroup) {
+	groupMenu := qt.NewQMenu(nil)
+	groupMenu.SetTitle(g.Name)
+	for _, sub := range g.Groups {
+		buildGroupMenu(groupMenu, sub)
+	}
+	if len(g.Groups) > 0 && len(g.Servers) > 0 {
+		groupMenu.AddSeparator()
+	}
+	if len(g.Servers) > 0 {
+		buildSplitMenu(groupMenu, append([]Server{}, g.Servers...))
+	}
+	parentMenu.AddMenu(groupMenu)
+}
+
+// createOTPMenu adds "Copy OTP" submenu listing servers that have TOTP secret defined
+func createOTPMenu(parentMenu *qt.QMenu, servers []Server) {
+	var otpServers []Server
+	for _, srv := range servers {
+		if srv.TOTP != "" {
+			otpServers = append(otpServers, srv)
+		}
+	}
+	if len(otpServers) == 0 {
+		return
+	}
+	sort.Slice(otpServers, func(i, j int) bool { return otpServers[i].Host < otpServers[j].Host })
+	otpMenu := qt.NewQMenu(nil)
+	otpMenu.SetTitle("Copy OTP")
+	for _, s := range otpServers {
+		srvCopy := s // closure safety
+		act := otpMenu.AddAction(s.Host)
+		act.OnTriggered(func() { requireUnlocked(func() { qtCopyOTP(srvCopy) }) })
+	}
+	parentMenu.AddMenu(otpMenu)
+}
+
+// qtCopyOTP generates the current OTP code of the server and puts it into clipboard
+func qtCopyOTP(srv Server) {
+	code, err := srv.OTP()
+	if err != nil {
+		QTshowError(nil, "Error", "Unable to generate OTP: "+err.Error())
+		return
+	}
+	copySecretToClipboard(code)
+	if tray != nil {
+		tray.ShowMessage2("Conan", "OTP code for "+srv.Host+" copied to clipboard")
+	}
+}
+
 // Recursively build menu or submenus if needed
 func buildSplitMenu(menu *qt.QMenu, srvList []Server) {
 	sort.Slice(srvList, func(i, j int) bool { return srvList[i].Host < srvList[j].Host })
@@ -420,7 +534,7 @@ func buildLeafItems(menu *qt.QMenu, list []Server) {
 	for _, s := range list {
 		srvCopy := s // closure safety
 		act := menu.AddAction(s.Host)
-		act.OnTriggered(func() { ClientConnect(srvCopy) })
+		act.OnTriggered(func() { requireUnlocked(func() { ClientConnect(srvCopy) }) })
 	}
 }
 
@@ -438,7 +552,7 @@ func chunkServers(sv []Server, size int) [][]Server {
 }
 
 func openFileOrDir(file string) {
-	log.Printf("Opening external: %s\n", file)
+	guiLog.Debugf("Opening external: %s", file)
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
 	case "darwin":
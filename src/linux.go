@@ -5,5 +5,53 @@
 
 package main
 
-//import "log"
+import (
+	"bufio"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+)
 
+// linux_watchScreenLock listens on the session dbus for screensaver (GNOME, KDE, XFCE...) signals,
+// dbus-monitor is used to avoid linking against dbus libraries
+func linux_watchScreenLock(onLock func()) {
+	if _, err := exec.LookPath("dbus-monitor"); err != nil {
+		log.Printf("dbus-monitor not found, lock on screen lock is disabled\n")
+		return
+	}
+	rules := []string{
+		"type='signal',interface='org.freedesktop.ScreenSaver',member='ActiveChanged'",
+		"type='signal',interface='org.gnome.ScreenSaver',member='ActiveChanged'",
+	}
+	for {
+		cmd := exec.Command("dbus-monitor", append([]string{"--session"}, rules...)...)
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			log.Printf("Unable to watch screen lock: %s\n", err)
+			return
+		}
+		if err := cmd.Start(); err != nil {
+			log.Printf("Unable to watch screen lock: %s\n", err)
+			return
+		}
+		// ActiveChanged signal carries the state on the next line: "boolean true"
+		activeChanged := false
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			switch {
+			case strings.Contains(line, "member=ActiveChanged"):
+				activeChanged = true
+			case activeChanged && strings.HasPrefix(line, "boolean"):
+				activeChanged = false
+				if line == "boolean true" {
+					onLock()
+				}
+			}
+		}
+		cmd.Wait()
+		// session bus restarted or dbus-monitor killed, try again later
+		time.Sleep(10 * time.Second)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+// this file will not be used in Linux operating systems it's dummy file just to declare the function
+
+package main
+
+func linux_watchScreenLock(onLock func()) {
+	// dummy version
+}
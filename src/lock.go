@@ -0,0 +1,246 @@
+package main
+
+/* Auto-lock of the tray application
+When the settings file is encrypted, the settings password and everything decrypted with it
+stays in memory while tray is running. Lock wipes it after inactivity, on explicit "Lock"
+tray action or when the OS screen gets locked and asks for the password again on next use.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mappu/miqt/qt"
+	"gopkg.in/ini.v1"
+)
+
+var (
+	appLocked    bool // only accessed from Qt main thread
+	unlocking    bool // login dialog is already shown
+	activityMu   sync.Mutex
+	lastActivity = time.Now()
+	idleTimer    *qt.QTimer
+	lockedNotes  []lockedNoteWindow // notes windows reopened after unlock
+)
+
+// lockedNoteWindow is the notes window that was open when the app got locked
+type lockedNoteWindow struct {
+	notesDir string
+	note     string
+}
+
+// lockSupported returns true if there is a password that can be asked again
+func lockSupported() bool {
+	encrypted, err := IsEncryptedINI(env.settingsFile)
+	if err != nil {
+		return false
+	}
+	return encrypted
+}
+
+// touchActivity resets the inactivity counter
+func touchActivity() {
+	activityMu.Lock()
+	lastActivity = time.Now()
+	activityMu.Unlock()
+}
+
+func idleDuration() time.Duration {
+	activityMu.Lock()
+	defer activityMu.Unlock()
+	return time.Since(lastActivity)
+}
+
+// startAutoLock installs user activity tracking, idle timer and OS screen lock watcher,
+// must be called from Qt main thread after the application is created
+func startAutoLock() {
+	if !lockSupported() {
+		return
+	}
+	// every key press or mouse click in any of our windows counts as activity
+	qtapp.OnNotify(func(super func(param1 *qt.QObject, param2 *qt.QEvent) bool, param1 *qt.QObject, param2 *qt.QEvent) bool {
+		switch param2.Type() {
+		case qt.QEvent__KeyPress, qt.QEvent__MouseButtonPress, qt.QEvent__Wheel:
+			touchActivity()
+		}
+		return super(param1, param2)
+	})
+
+	idleTimer = qt.NewQTimer()
+	idleTimer.OnTimeout(func() {
+		if appLocked || settings.AutoLock <= 0 {
+			return
+		}
+		if idleDuration() >= time.Duration(settings.AutoLock)*time.Minute {
+			guiLog.Infof("No activity for %d minutes, locking", settings.AutoLock)
+			lockApp()
+		}
+	})
+	idleTimer.Start(30 * 1000)
+
+	go watchScreenLock(func() {
+		CallOnQtMain(func() {
+			if !appLocked {
+				guiLog.Infof("Screen locked, locking")
+				lockApp()
+			}
+		})
+	})
+}
+
+// watchScreenLock blocks and calls onLock every time the OS signals the screen lock
+func watchScreenLock(onLock func()) {
+	switch env.os {
+	case "linux":
+		linux_watchScreenLock(onLock)
+	case "windows":
+		win32_watchScreenLock(onLock)
+	case "darwin":
+		darwin_watchScreenLock(onLock)
+	}
+}
+
+// lockApp wipes the settings password and all secrets decrypted with it,
+// hides windows that show them and replaces the tray menu with the locked one
+func lockApp() {
+	if appLocked || !lockSupported() {
+		return
+	}
+	appLocked = true
+
+	flushClipboardSecret()
+
+	settings = Settings{}
+	gists = nil
+	servers = nil
+	filteredServers = nil
+	filteredItems = nil
+	resetNoteIndexes()
+	if Store != nil {
+		Store.cfg = ini.Empty()
+	}
+
+	if searchWindow != nil {
+		entry.SetText("")
+		listWidget.Clear()
+		searchWindow.Hide()
+	}
+	if serverTableWindow != nil {
+		updateServerTable()
+		serverTableWindow.Hide()
+	}
+	// settings dialog holds decrypted values in its widgets, it will be recreated on next use
+	if settingsWindow != nil {
+		settingsWindow.Hide()
+		settingsWindow.DeleteLater()
+		settingsWindow = nil
+	}
+	for _, sm := range Stickies {
+		sm.HideAll()
+		sm.service.Gist = GistConfig{}
+	}
+	closeNotesWindowsQt()
+	closeTasksQt()
+	resetTaskCache()
+
+	lockedTrayMenu()
+	auditEvent(AuditLock, "", "", "")
+	guiLog.Infof("Application locked")
+}
+
+// unlockApp reloads settings and servers with the entered password
+func unlockApp() {
+	decryptSettings()
+	appLocked = false
+	touchActivity()
+	updateTrayMenu()
+	if serverTableWindow != nil {
+		updateServerTable()
+	}
+	auditEvent(AuditUnlock, "", "", "")
+	for _, sm := range Stickies {
+		sm.service.Gist = notesGistFor(sm.service.NotesDir)
+		sm.Refresh()
+		sm.ShowAll()
+	}
+	for _, l := range lockedNotes {
+		OpenNoteQt(l.notesDir, notesGistFor(l.notesDir), l.note)
+	}
+	lockedNotes = nil
+	guiLog.Infof("Application unlocked")
+}
+
+// closeNotesWindowsQt closes the notes windows, they show decrypted notes and their services hold
+// the key, unsaved changes are saved first and the visible windows are reopened after unlock
+func closeNotesWindowsQt() {
+	lockedNotes = nil
+	for key, nw := range notesWindowsQt {
+		if nw.current != nil && !nw.viewMode && nw.editor.ToPlainText() != string(nw.current.Body) {
+			nw.saveNoteQt()
+		}
+		if nw.win.IsVisible() {
+			lockedNotes = append(lockedNotes, lockedNoteWindow{notesDir: nw.service.NotesDir, note: nw.selectedUID})
+		}
+		nw.editor.Clear()
+		nw.viewer.Clear()
+		nw.current = nil
+		nw.gist = GistConfig{}
+		nw.service.Gist = GistConfig{}
+		nw.win.Hide()
+		nw.win.DeleteLater()
+		delete(notesWindowsQt, key)
+	}
+}
+
+// requireUnlocked runs fn immediately or after successful login when the app is locked
+func requireUnlocked(fn func()) {
+	touchActivity()
+	if !appLocked {
+		fn()
+		return
+	}
+	if unlocking {
+		return
+	}
+	unlocking = true
+	showLogin(nil, func() {
+		unlockApp()
+		fn()
+	})
+	unlocking = false
+}
+
+// lockedTrayMenu replaces the tray menu with the minimal one, where every action asks for password
+func lockedTrayMenu() {
+	if tray != nil {
+		tray.Delete()
+	}
+	tray = qt.NewQSystemTrayIcon()
+	tray.SetIcon(globalIcon)
+	tray.SetVisible(true)
+	tray.SetToolTip("Conan - Locked")
+	tray.OnActivated(func(reason qt.QSystemTrayIcon__ActivationReason) {
+		if reason == qt.QSystemTrayIcon__Trigger && env.os == "windows" {
+			requireUnlocked(showFuzzySearchWindow)
+		}
+	})
+
+	menu := qt.NewQMenu(nil)
+	menu.AddAction("Unlock").OnTriggered(func() {
+		requireUnlocked(func() {})
+	})
+	menu.AddAction("Show").OnTriggered(func() {
+		requireUnlocked(showFuzzySearchWindow)
+	})
+	menu.AddAction("Servers table").OnTriggered(func() {
+		requireUnlocked(showServerTable)
+	})
+	menu.AddSeparator()
+	menu.AddAction("Quit").OnTriggered(func() {
+		qt.QCoreApplication_Exit()
+		os.Exit(0)
+	})
+	tray.SetContextMenu(menu)
+}
@@ -18,7 +18,18 @@ var debugging = "false"
 var lines string
 
 func main() {
-	fmt.Printf("\n%s v%s (build: %s).\n\nCopyright (c) 2025 by Justinas K (e1z0@icloud.com)\n\n", RepresentativeName, version, build)
+	// ssh and git run the askpass helper with the prompt as the only argument
+	if os.Getenv(askpassEnv) != "" && len(os.Args) == 2 && !isSubcommand(os.Args[1]) {
+		InitializeEnvironment()
+		if err := runAskpass(os.Args[1]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// banner goes to stderr, so the output of the commands can be used in scripts
+	fmt.Fprintf(os.Stderr, "\n%s v%s (build: %s).\n\nCopyright (c) 2025 by Justinas K (e1z0@icloud.com)\n\n", RepresentativeName, version, build)
 
 	if env.os == "windows" {
 		gui, err := isWindowsGUI()
@@ -27,9 +38,9 @@ func main() {
 			os.Exit(1)
 		}
 		if gui {
-			fmt.Println("I was built as a GUI app!")
+			fmt.Fprintln(os.Stderr, "I was built as a GUI app!")
 		} else {
-			fmt.Println("I was built as a console app.")
+			fmt.Fprintln(os.Stderr, "I was built as a console app.")
 		}
 	}
 	if env.os == "darwin" {
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditEventFlag string
+	auditHostFlag  string
+	auditSinceFlag string
+	auditLimitFlag int
+	auditJSONFlag  bool
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show audit log of secret access and connections",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := readAuditLog()
+		if err != nil {
+			if os.IsNotExist(err) {
+				fmt.Println("Audit log is empty")
+				return nil
+			}
+			return err
+		}
+		var since time.Time
+		if auditSinceFlag != "" {
+			since, err = parseSince(auditSinceFlag)
+			if err != nil {
+				return err
+			}
+		}
+		filtered := make([]AuditEntry, 0, len(entries))
+		for _, e := range entries {
+			if auditEventFlag != "" && !strings.HasPrefix(e.Event, auditEventFlag) {
+				continue
+			}
+			if auditHostFlag != "" && !strings.Contains(strings.ToLower(e.Host), strings.ToLower(auditHostFlag)) {
+				continue
+			}
+			if !since.IsZero() && e.Time.Before(since) {
+				continue
+			}
+			filtered = append(filtered, e)
+		}
+		if auditLimitFlag > 0 && len(filtered) > auditLimitFlag {
+			filtered = filtered[len(filtered)-auditLimitFlag:]
+		}
+		if auditJSONFlag {
+			enc := json.NewEncoder(os.Stdout)
+			for _, e := range filtered {
+				if err := enc.Encode(e); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SEQ\tTIME\tEVENT\tUSER\tHOST\tSOURCE\tDETAIL")
+		for _, e := range filtered {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", e.Seq, ToLocalTime(e.Time).Format("2006-01-02 15:04:05"), e.Event, e.User, e.Host, e.Source, e.Detail)
+		}
+		return w.Flush()
+	},
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the audit log hash chain",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := verifyAuditLog()
+		if err != nil {
+			if os.IsNotExist(err) {
+				fmt.Println("Audit log is empty")
+				return nil
+			}
+			fmt.Printf("❌ Audit log verification failed after %d valid entries: %s\n", n, err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Audit log is intact, %d entries verified\n", n)
+		return nil
+	},
+}
+
+// parseSince accepts durations (24h, 30m) or dates (2006-01-02, 2006-01-02T15:04:05)
+func parseSince(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value %q, use duration (24h) or date (2006-01-02)", s)
+}
+
+func init() {
+	auditCmd.Flags().StringVar(&auditEventFlag, "event", "", "Filter by event name prefix (e.g. secret, connect, gist)")
+	auditCmd.Flags().StringVar(&auditHostFlag, "host", "", "Filter by server host")
+	auditCmd.Flags().StringVar(&auditSinceFlag, "since", "", "Show entries since duration (24h) or date (2006-01-02)")
+	auditCmd.Flags().IntVar(&auditLimitFlag, "limit", 0, "Show only last N entries")
+	auditCmd.Flags().BoolVar(&auditJSONFlag, "json", false, "Output raw JSON lines")
+	auditCmd.AddCommand(auditVerifyCmd)
+	rootCmd.AddCommand(auditCmd)
+}
@@ -25,6 +25,9 @@ var (
 	dbsFlag      bool
 	showAtStart  bool
 	globalHotkey int
+	// Logging flags
+	logLevelFlag  string
+	logFormatFlag string
 )
 
 var rootCmd = &cobra.Command{
@@ -34,6 +37,19 @@ var rootCmd = &cobra.Command{
 		// Mandatory startup
 		InitializeEnvironment()
 
+		if cmd.Flags().Changed("log-format") {
+			if err := setLogFormat(logFormatFlag); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+		if cmd.Flags().Changed("log-level") {
+			if err := setLogLevel(logLevelFlag); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+
 		// Adjust tray default on unsupported OS only if user didn't override
 		if !cmd.PersistentFlags().Changed("tray") {
 			//log.Printf("tray: %s\n", trayFlag)
@@ -90,7 +106,7 @@ func initApp() {
 
 	if dbFlag != "" {
 		if err, _ := checkServYmlFiles(dbFlag); err != nil {
-			log.Printf("Servers DB Not found at: %s: %w\n", dbFlag, err)
+			log.Printf("Servers DB Not found at: %s: %s\n", dbFlag, err)
 			os.Exit(1)
 		}
 	} else {
@@ -99,6 +115,16 @@ func initApp() {
 	fetchServersFromFiles()
 }
 
+// initCLI loads settings and servers for subcommands, asking for the settings password if required
+func initCLI() {
+	inventoryWait = true // the command exits before the inventories would be fetched in the background
+	initApp()
+	if err := tuiCheckProtection(); err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
 // handleActionFlags runs when standalone flags are provided at the root level
 func handleActionFlags() error {
 	if mkeyFlag {
@@ -168,6 +194,8 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&dbsFlag, "dbs", false, "Show available server database files")
 	rootCmd.PersistentFlags().BoolVar(&dirtyFlag, "q", false, "Quick and dirty server selection (old one)")
 	rootCmd.PersistentFlags().BoolVar(&testFlag, "test", false, "Test some functions")
+	rootCmd.PersistentFlags().StringVar(&logLevelFlag, "log-level", "info", "Log level: debug, info, warn or error")
+	rootCmd.PersistentFlags().StringVar(&logFormatFlag, "log-format", "text", "Log file format: text or json")
 
 	// Global flags can go here...
 
@@ -0,0 +1,152 @@
+package main
+
+/* Connect from the command line
+conan connect <host|query> resolves the server by host, IP, stable id or fuzzy query
+(asks which one when more servers match) and connects with the configured client.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/lithammer/fuzzysearch/fuzzy"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	connectTypeFlag  string
+	connectUserFlag  string
+	connectPrintFlag bool
+)
+
+var connectCmd = &cobra.Command{
+	Use:   "connect <host|query>",
+	Short: "Connect to the server",
+	Example: `  conan connect web1
+  conan connect 10.0.0.11 --user admin
+  conan connect prodweb --print`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeServerHosts,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		srv, err := resolveServer(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		if connectTypeFlag != "" {
+			if srv.Type = serverType(connectTypeFlag); srv.Type == "" {
+				return fmt.Errorf("unknown server type %s, supported: %s", connectTypeFlag, strings.Join(ServerTypes, ", "))
+			}
+		}
+		if connectUserFlag != "" {
+			srv.User = connectUserFlag
+		}
+		if _, ok := connectCommandKeys[srv.Type]; !ok {
+			return fmt.Errorf("%s servers are not supported yet", srv.Type)
+		}
+		if connectPrintFlag {
+			cmdline, err := ConnectCommandLine(srv)
+			if err != nil {
+				return err
+			}
+			fmt.Println(cmdline)
+			return nil
+		}
+		connectAttached = true
+		ClientConnect(srv)
+		return nil
+	},
+}
+
+// resolveServer finds the server by host, IP or stable id, then by fuzzy query,
+// asks for the choice when more servers match
+func resolveServer(query string) (Server, error) {
+	var found []Server
+	for _, srv := range servers {
+		if serverMatches(query, srv) {
+			found = append(found, srv)
+		}
+	}
+	if len(found) == 0 {
+		q := strings.ToLower(query)
+		for _, srv := range servers {
+			if fuzzy.Match(q, strings.ToLower(srv.Host)) || fuzzy.Match(q, strings.ToLower(srv.IP)) || fuzzy.Match(q, strings.ToLower(srv.Description)) {
+				found = append(found, srv)
+			}
+		}
+		// the closest host names first, servers matched by address or description last
+		rank := func(srv Server) int {
+			if r := fuzzy.RankMatchFold(query, srv.Host); r >= 0 {
+				return r
+			}
+			return len(srv.Host) + 1000
+		}
+		sort.SliceStable(found, func(i, j int) bool { return rank(found[i]) < rank(found[j]) })
+	}
+	switch len(found) {
+	case 0:
+		return Server{}, fmt.Errorf("no server matches %s", query)
+	case 1:
+		return found[0], nil
+	}
+	return chooseServer(query, found)
+}
+
+// chooseServer asks which of the servers should be used
+func chooseServer(query string, found []Server) (Server, error) {
+	if !term.IsTerminal(int(syscall.Stdin)) {
+		return Server{}, fmt.Errorf("%d servers match %s, use the host name or stable id", len(found), query)
+	}
+	for i, srv := range found {
+		fmt.Fprintf(os.Stderr, "%3d) %s (%s) %s [%s]\n", i+1, srv.Host, srv.IP, srv.Type, srv.SourceName)
+	}
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Fprintf(os.Stderr, "Select server 1-%d (empty to cancel): ", len(found))
+		input, err := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+		if input == "" || err != nil {
+			return Server{}, fmt.Errorf("canceled")
+		}
+		if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(found) {
+			return found[n-1], nil
+		}
+	}
+}
+
+// completeServerHosts completes host names of the servers for the shells
+func completeServerHosts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	// settings protection is not asked for, servers files are not encrypted
+	initApp()
+	var hosts []string
+	for _, srv := range servers {
+		if strings.HasPrefix(strings.ToLower(srv.Host), strings.ToLower(toComplete)) {
+			hosts = append(hosts, srv.Host+"\t"+strings.TrimSpace(srv.IP+" "+srv.Description))
+		}
+	}
+	return hosts, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	connectCmd.Flags().StringVar(&connectTypeFlag, "type", "", "Connect as this type instead: "+strings.Join(ServerTypes, ", "))
+	connectCmd.Flags().StringVar(&connectUserFlag, "user", "", "Connect as this user")
+	connectCmd.Flags().BoolVar(&connectPrintFlag, "print", false, "Print the command instead of running it (password is redacted)")
+	connectCmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return ServerTypes, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	for _, c := range []*cobra.Command{serverShowCmd, serverEditCmd, serverRmCmd, serverMvCmd} {
+		c.ValidArgsFunction = completeServerHosts
+	}
+	rootCmd.AddCommand(connectCmd)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	exportFormat    string
+	exportFile      string
+	exportSource    string
+	exportPasswords string
+	exportVaultFile string
+	exportColSep    string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export servers as ssh config, Ansible inventory, CSV or JSON",
+	Example: `  conan export --format sshconfig >> ~/.ssh/config
+  conan export --format ansible-ini --file inventory.ini
+  conan export --format ansible-yaml --passwords vault --vault-password-file ~/.vault_pass
+  conan export --format csv --source servers.yml`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		list, err := exportServerList(exportSource)
+		if err != nil {
+			return err
+		}
+		opts := ExportOptions{Format: exportFormat, Passwords: exportPasswords, Separator: exportColSep}
+		if opts.Passwords == PasswordsVault {
+			if opts.VaultPassword, err = exportVaultPassword(exportVaultFile); err != nil {
+				return err
+			}
+		}
+		var w io.Writer = os.Stdout
+		var tmp *os.File
+		if exportFile != "" {
+			perm := os.FileMode(0644)
+			if opts.Passwords == PasswordsPlain {
+				perm = 0600
+			}
+			// the file is replaced only when the export succeeds
+			tmp, err = os.CreateTemp(filepath.Dir(exportFile), "."+filepath.Base(exportFile)+".*")
+			if err != nil {
+				return err
+			}
+			defer os.Remove(tmp.Name())
+			defer tmp.Close()
+			if err := tmp.Chmod(perm); err != nil {
+				return err
+			}
+			w = tmp
+		}
+		if err := ExportServers(w, list, opts); err != nil {
+			return err
+		}
+		if tmp != nil {
+			if err := tmp.Close(); err != nil {
+				return err
+			}
+			if err := os.Rename(tmp.Name(), exportFile); err != nil {
+				return err
+			}
+		}
+		detail := fmt.Sprintf("%s: %d servers", exportFormat, len(list))
+		if opts.Passwords == PasswordsPlain || opts.Passwords == PasswordsVault {
+			detail += ", passwords " + opts.Passwords
+		}
+		auditEvent(AuditExportServers, "", exportSource, detail)
+		if exportFile != "" {
+			fmt.Fprintf(os.Stderr, "Exported %d servers to %s\n", len(list), exportFile)
+		}
+		return nil
+	},
+}
+
+// exportVaultPassword reads the ansible-vault password from the file or asks for it
+func exportVaultPassword(path string) (string, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		pass := strings.TrimRight(string(data), "\r\n")
+		if pass == "" {
+			return "", fmt.Errorf("vault password file %s is empty", path)
+		}
+		return pass, nil
+	}
+	if !term.IsTerminal(int(syscall.Stdin)) {
+		return "", fmt.Errorf("vault password is required, use --vault-password-file")
+	}
+	fmt.Fprint(os.Stderr, "New vault password: ")
+	pass, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(os.Stderr, "Confirm new vault password: ")
+	pass2, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if string(pass) != string(pass2) {
+		return "", fmt.Errorf("vault passwords do not match")
+	}
+	if len(pass) == 0 {
+		return "", fmt.Errorf("vault password is empty")
+	}
+	return string(pass), nil
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", ExportSSHConfig, "Export format: "+strings.Join(ExportFormats, ", "))
+	exportCmd.Flags().StringVar(&exportFile, "file", "", "Write to the file instead of standard output")
+	exportCmd.Flags().StringVar(&exportSource, "source", "", "Export only servers of the servers file")
+	exportCmd.Flags().StringVar(&exportPasswords, "passwords", PasswordsNone, "Passwords: none, plain (decrypted) or vault (ansible-vault, ansible-yaml only)")
+	exportCmd.Flags().StringVar(&exportVaultFile, "vault-password-file", "", "File with the ansible-vault password (asked when not set)")
+	exportCmd.Flags().StringVar(&exportColSep, "colsep", ";", "CSV column separator (, ; or |)")
+
+	rootCmd.AddCommand(exportCmd)
+}
@@ -5,7 +5,10 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -13,6 +16,11 @@ import (
 
 var (
 	importFile    string
+	importFormat  string
+	importTo      string
+	importYes     bool
+	importDryRun  bool
+	importAll     bool
 	importIpCol   string
 	importPortCol string
 	importHostCol string
@@ -21,31 +29,102 @@ var (
 	importDescCol string
 	importTypeCol string
 	importColSep  string
+	importSheet   string
 )
 
 var importCmd = &cobra.Command{
 	Use:   "import",
-	Short: "Import servers from CSV",
+	Short: "Import servers from ssh config, Remmina, mRemoteNG, PuTTY sessions, CSV or Excel",
+	Example: `  conan import --format sshconfig
+  conan import --format remmina --to workstations.yml
+  conan import --format mremoteng --file confCons.xml --dry-run
+  conan import --format putty-reg --file putty.reg --yes
+  conan import --format xlsx --file servers.xlsx --sheet Production`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if importFile == "" {
-			return fmt.Errorf("--file is required for import")
+		initCLI()
+		list, err := ImportServers(importFormat, importFile, CSVColumns{
+			Host:        importHostCol,
+			IP:          importIpCol,
+			User:        importUserCol,
+			Password:    importPassCol,
+			Port:        importPortCol,
+			Description: importDescCol,
+			Type:        importTypeCol,
+			Separator:   importColSep,
+			Sheet:       importSheet,
+		})
+		if err != nil {
+			return err
 		}
-		// call import logic
-		importServersFromCSV(
-			importFile,
-			importIpCol,
-			importUserCol,
-			importPassCol,
-			importPortCol,
-			importHostCol,
-			importDescCol,
-			importTypeCol,
-			importColSep,
-		)
-		return nil
+		target, err := importTarget(importTo)
+		if err != nil {
+			return err
+		}
+		return importPreview(list, target)
 	},
 }
 
+// importTarget returns servers file the servers are imported to, a new file is created in the servers folder
+func importTarget(name string) (string, error) {
+	if name == "" {
+		if len(ymlfiles) == 0 {
+			return "", fmt.Errorf("no servers files found, select one with --to")
+		}
+		return ymlfiles[0], nil
+	}
+	if f, err := resolveServersFile(name); err == nil {
+		return f, nil
+	}
+	if !isServersFile(name) {
+		name += ".yml"
+	}
+	if strings.ContainsRune(name, os.PathSeparator) {
+		return name, nil
+	}
+	return filepath.Join(serverFilesPaths[0], name), nil
+}
+
+// importPreview prints servers to be imported and writes them after confirmation
+func importPreview(list []Server, target string) error {
+	var existing []Server
+	for _, srv := range servers {
+		if srv.SourcePath == target {
+			existing = append(existing, srv)
+		}
+	}
+	var add []Server
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tHOST\tADDRESS\tTYPE\tUSER\tPORT\tJUMP\tTAGS")
+	for _, srv := range list {
+		status := "new"
+		if isDuplicateServer(existing, srv) || isDuplicateServer(add, srv) {
+			status = "exists"
+			if !importAll {
+				status = "skip"
+			}
+		}
+		if status != "skip" {
+			add = append(add, srv)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", status, srv.Host, srv.IP, srv.Type, srv.User, srv.Port, srv.Jump, srv.Tags)
+	}
+	w.Flush()
+	fmt.Printf("\n%d servers found, %d to import into %s\n", len(list), len(add), target)
+	if len(add) == 0 || importDryRun {
+		return nil
+	}
+	if !importYes && askYesNo(fmt.Sprintf("Import %d servers?", len(add))) != "yes" {
+		return nil
+	}
+	if err := appendServersToFile(target, add); err != nil {
+		return err
+	}
+	auditEvent(AuditImportServers, "", filepath.Base(target), fmt.Sprintf("%s: %d servers", importFormat, len(add)))
+	fmt.Printf("✅ Imported %d servers into %s\n", len(add), target)
+	return nil
+}
+
 var importSettingsCmd = &cobra.Command{
 	Use:   "importsettings",
 	Short: "Import settings from .cnn file",
@@ -126,6 +205,7 @@ func exportSettingsFile(path string) {
 		return
 	}
 
+	auditEvent(AuditExportSettings, "", path, "cli")
 	log.Printf("Export is complete! file is located at: %s\n", path)
 }
 
@@ -174,17 +254,18 @@ func importSettingsFile(path string) {
 		log.Printf("Error restoring files: %s\n", err)
 		os.Exit(1)
 	}
+	auditEvent(AuditImportSettings, "", path, "cli")
 	fmt.Printf("Restore succeeded!\nRe-Run the application\n")
 	os.Exit(0)
 }
 
-// Function to import servers from a CSV file
-func importServersFromCSV(filename, ipCol, userCol, passCol, portCol, hostCol, descCol, typeCol, colSep string) {
-
-}
-
 func init() {
-	importCmd.Flags().StringVar(&importFile, "file", "", "Path to CSV file (required)")
+	importCmd.Flags().StringVar(&importFormat, "format", ImportSSHConfig, "Import format: "+strings.Join(ImportFormats, ", "))
+	importCmd.Flags().StringVar(&importFile, "file", "", "File or folder to import (default ~/.ssh/config, ~/.local/share/remmina or %APPDATA%/mRemoteNG/confCons.xml)")
+	importCmd.Flags().StringVar(&importTo, "to", "", "Servers file to import into, created when it does not exist (default the first servers file)")
+	importCmd.Flags().BoolVarP(&importYes, "yes", "y", false, "Import without confirmation")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Only show the servers that would be imported")
+	importCmd.Flags().BoolVar(&importAll, "all", false, "Import also servers that already exist in the target file")
 	importCmd.Flags().StringVar(&importIpCol, "ipcol", "ip", "Column name for IP Address")
 	importCmd.Flags().StringVar(&importPortCol, "portcol", "port", "Column name for Port")
 	importCmd.Flags().StringVar(&importHostCol, "hostcol", "hostname", "Column name for Hostname")
@@ -193,6 +274,7 @@ func init() {
 	importCmd.Flags().StringVar(&importDescCol, "desccol", "description", "Column name for Description")
 	importCmd.Flags().StringVar(&importTypeCol, "typecol", "type", "Column name for Type")
 	importCmd.Flags().StringVar(&importColSep, "colsep", ";", "Column separator (, ; or |)")
+	importCmd.Flags().StringVar(&importSheet, "sheet", "", "Sheet of the xlsx workbook (default the first sheet)")
 
 	importSettingsCmd.Flags().StringVar(&importFile, "file", "", "Import .cnn file (required)")
 	exportSettingsCmd.Flags().StringVar(&importFile, "file", "", "Export .cnn file (required)")
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "List and refresh the dynamic inventories",
+}
+
+var inventoryLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List the inventories with their cache state",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tTYPE\tSOURCE\tTTL\tFETCHED\tSERVERS\tERROR")
+		for _, inv := range inventories {
+			list, fetched, err := inv.Servers(false)
+			when, problem := "-", ""
+			if !fetched.IsZero() {
+				when = fetched.Format(time.DateTime)
+			}
+			if err != nil {
+				problem = err.Error()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", inv.Name, inv.Type, inv.Source, inv.TTL, when, len(list), problem)
+		}
+		return w.Flush()
+	},
+}
+
+var inventoryRefreshCmd = &cobra.Command{
+	Use:     "refresh [name...]",
+	Short:   "Fetch the inventories again ignoring the cache TTL",
+	Example: "  conan inventory refresh\n  conan inventory refresh aws dns",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		for _, name := range args {
+			if _, ok := findInventory(name); !ok {
+				return fmt.Errorf("inventory %s not found", name)
+			}
+		}
+		errs := refreshInventories(args...)
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if len(errs) > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d inventories failed to refresh", len(errs))
+		}
+		return nil
+	},
+}
+
+func init() {
+	inventoryCmd.AddCommand(inventoryLsCmd, inventoryRefreshCmd)
+	rootCmd.AddCommand(inventoryCmd)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintJSONFlag   bool
+	lintStrictFlag bool
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [servers file...]",
+	Short: "Check the servers files, exits with error when problems are found (for CI)",
+	Example: `  conan lint
+  conan lint servers.yml work.yml --strict
+  conan lint --json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		files := ymlfiles
+		if len(args) > 0 {
+			files = nil
+			for _, arg := range args {
+				path := arg
+				if _, err := os.Stat(path); err != nil {
+					if path, err = resolveServersFile(arg); err != nil {
+						return err
+					}
+				}
+				files = append(files, path)
+			}
+		}
+		issues := LintServers(files)
+		if lintJSONFlag {
+			if issues == nil {
+				issues = []LintIssue{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(issues); err != nil {
+				return err
+			}
+		} else {
+			for _, i := range issues {
+				fmt.Println(i)
+			}
+		}
+		errors, warnings := lintCounts(issues)
+		fmt.Fprintf(os.Stderr, "%d servers files checked: %d errors, %d warnings\n", len(files), errors, warnings)
+		if errors > 0 || (lintStrictFlag && warnings > 0) {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("servers files have problems")
+		}
+		return nil
+	},
+}
+
+func init() {
+	lintCmd.Flags().BoolVar(&lintJSONFlag, "json", false, "Output as JSON")
+	lintCmd.Flags().BoolVar(&lintStrictFlag, "strict", false, "Fail on warnings too")
+
+	rootCmd.AddCommand(lintCmd)
+}
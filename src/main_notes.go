@@ -0,0 +1,870 @@
+package main
+
+/* Notes command line interface
+Notes are addressed by their path relative to the notes folder, e.g. "ops/backup" or "ops/backup.md",
+--file selects the servers file whose notes are used when the same path exists in several of them.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	notesLimitFlag    int
+	notesJSONFlag     bool
+	notesFileFlag     string
+	notesRawFlag      bool
+	notesYesFlag      bool
+	notesGistFlag     bool
+	notesEditFlag     bool
+	notesShowFlag     int
+	notesDiffFlag     string
+	notesPruneFlag    bool
+	notesFormatFlag   string
+	notesOutputFlag   string
+	notesAllFlag      bool
+	notesDryRunFlag   bool
+	notesTemplateFlag string
+	notesServerFlag   string
+	notesInstallFlag  bool
+)
+
+var notesCmd = &cobra.Command{
+	Use:   "notes",
+	Short: "Work with notes of the server files",
+}
+
+var notesSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Full-text search in notes (title, tags, author and content)",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		results := searchAllNotes(strings.Join(args, " "))
+		if notesLimitFlag > 0 && len(results) > notesLimitFlag {
+			results = results[:notesLimitFlag]
+		}
+		if notesJSONFlag {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(results)
+		}
+		if len(results) == 0 {
+			fmt.Println("No notes found")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NOTES\tNOTE\tTITLE\tMATCH")
+		for _, r := range results {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", filepath.Base(r.NotesDir), r.ID, r.Title, r.Snippet)
+		}
+		return w.Flush()
+	},
+}
+
+// notesListItem is a single row of notes list
+type notesListItem struct {
+	Notes   string   `json:"notes"`
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Tags    []string `json:"tags,omitempty"`
+	Updated string   `json:"updated"`
+	Sticky  bool     `json:"sticky,omitempty"`
+}
+
+var notesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List notes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		services, err := cliNoteServices()
+		if err != nil {
+			return err
+		}
+		items := []notesListItem{}
+		for _, s := range services {
+			ids, err := s.ListNotes()
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				n, err := s.Load(id)
+				if err != nil {
+					notesLog.Warnf("Unable to load note %s: %s", id, err)
+					continue
+				}
+				item := notesListItem{Notes: filepath.Base(s.NotesDir), ID: filepath.ToSlash(id), Title: n.Meta.Title, Tags: n.Meta.Tags, Sticky: n.Meta.Sticky}
+				if !n.Meta.Updated.IsZero() {
+					item.Updated = n.Meta.Updated.Local().Format("2006-01-02 15:04")
+				}
+				items = append(items, item)
+			}
+		}
+		if notesJSONFlag {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(items)
+		}
+		if len(items) == 0 {
+			fmt.Println("No notes found")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NOTES\tNOTE\tTITLE\tUPDATED\tTAGS")
+		for _, i := range items {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", i.Notes, i.ID, i.Title, i.Updated, strings.Join(i.Tags, ","))
+		}
+		return w.Flush()
+	},
+}
+
+var notesShowCmd = &cobra.Command{
+	Use:   "show <note>",
+	Short: "Print decrypted note",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		s, rel, err := cliResolveNote(args[0])
+		if err != nil {
+			return err
+		}
+		n, err := s.Load(rel)
+		if err != nil {
+			return err
+		}
+		if notesRawFlag {
+			_, err = os.Stdout.Write(n.Raw)
+			return err
+		}
+		if n.Meta.Title != "" {
+			fmt.Printf("Title:   %s\n", n.Meta.Title)
+		}
+		if len(n.Meta.Tags) > 0 {
+			fmt.Printf("Tags:    %s\n", strings.Join(n.Meta.Tags, ", "))
+		}
+		if !n.Meta.Updated.IsZero() {
+			fmt.Printf("Updated: %s\n", n.Meta.Updated.Local().Format("2006-01-02 15:04"))
+		}
+		fmt.Println()
+		_, err = os.Stdout.Write(n.Body)
+		return err
+	},
+}
+
+var notesEditCmd = &cobra.Command{
+	Use:   "edit <note>",
+	Short: "Edit note in $EDITOR (encrypted notes are re-encrypted on save)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		s, rel, err := cliResolveNote(args[0])
+		if err != nil {
+			return err
+		}
+		changed, err := editNoteExternal(s, rel)
+		if err != nil {
+			return err
+		}
+		if changed {
+			fmt.Printf("✅ Note %s saved\n", filepath.ToSlash(rel))
+		} else {
+			fmt.Println("No changes")
+		}
+		return nil
+	},
+}
+
+var notesNewCmd = &cobra.Command{
+	Use:   "new <note>",
+	Short: "Create a new note, parent folders are created as needed",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		var srv *Server
+		if notesServerFlag != "" {
+			found, err := cliFindServer(notesServerFlag)
+			if err != nil {
+				return err
+			}
+			srv = &found
+			// the note belongs to the servers file of the server
+			if notesFileFlag == "" {
+				notesFileFlag = srv.SourceName
+			}
+			if notesTemplateFlag == "" {
+				notesTemplateFlag = "runbook"
+			}
+		}
+		s, err := cliNoteService(true)
+		if err != nil {
+			return err
+		}
+		rel, err := normalizeNoteID(args[0])
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(s.NotesDir, rel)); err == nil {
+			return fmt.Errorf("note %s already exists", filepath.ToSlash(rel))
+		}
+		parent := filepath.Dir(rel)
+		if parent == "." {
+			parent = ""
+		}
+		if err := os.MkdirAll(filepath.Join(s.NotesDir, parent), 0755); err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.Base(rel), ".md")
+		if notesTemplateFlag != "" {
+			err = s.NewNoteFromTemplate(parent, name, notesTemplateFlag, srv)
+		} else {
+			err = s.NewNote(parent, name)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Note %s created in %s\n", filepath.ToSlash(rel), filepath.Base(s.NotesDir))
+		if notesEditFlag {
+			_, err = editNoteExternal(s, rel)
+		}
+		return err
+	},
+}
+
+var notesRmCmd = &cobra.Command{
+	Use:   "rm <note|folder>",
+	Short: "Delete note and its history, or folder with all its notes",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		s, rel, err := cliResolvePath(args[0])
+		if err != nil {
+			return err
+		}
+		if rel == "" {
+			return fmt.Errorf("refusing to delete the notes root folder")
+		}
+		folder := !strings.HasSuffix(rel, ".md")
+		what := "note"
+		if folder {
+			what = "folder with all its notes"
+		}
+		if !notesYesFlag && askYesNo("Delete "+what+" "+filepath.ToSlash(rel)+"?") != "yes" {
+			return nil
+		}
+		deleted := []string{rel}
+		if folder {
+			deleted, err = s.DeleteFolder(rel)
+		} else {
+			err = s.DeleteNote(rel)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ %s deleted (%d notes)\n", filepath.ToSlash(rel), len(deleted))
+		if notesGistFlag && s.Gist.GistID != "" && len(deleted) > 0 {
+			if err := s.DeleteFromGist(deleted...); err != nil {
+				return err
+			}
+			fmt.Println("✅ Deleted from gist")
+		}
+		return nil
+	},
+}
+
+var notesMvCmd = &cobra.Command{
+	Use:   "mv <note|folder> <new path>",
+	Short: "Rename or move note or folder, the next push removes the old path from gist",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		s, rel, err := cliResolvePath(args[0])
+		if err != nil {
+			return err
+		}
+		if rel == "" {
+			return fmt.Errorf("unable to move the notes root folder")
+		}
+		newRel := filepath.Clean(filepath.FromSlash(strings.Trim(strings.TrimSpace(args[1]), "/")))
+		if err := s.Move(rel, newRel); err != nil {
+			return err
+		}
+		fmt.Printf("✅ %s moved to %s\n", filepath.ToSlash(rel), filepath.ToSlash(newRel))
+		return nil
+	},
+}
+
+var notesHistoryCmd = &cobra.Command{
+	Use:   "history <note>",
+	Short: "List saved revisions of the note",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		s, rel, err := cliResolveNote(args[0])
+		if err != nil {
+			return err
+		}
+		n, err := s.Load(rel)
+		if err != nil {
+			return err
+		}
+		if notesPruneFlag {
+			fmt.Printf("Removed %d revisions\n", s.PruneHistory(n))
+			return nil
+		}
+		if notesDiffFlag != "" {
+			return printNoteDiff(s, n, notesDiffFlag)
+		}
+		if notesShowFlag > 0 {
+			if notesShowFlag > len(n.History) {
+				return fmt.Errorf("note %s has %d revisions", filepath.ToSlash(rel), len(n.History))
+			}
+			data, err := os.ReadFile(n.History[notesShowFlag-1].Path)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(s.maybeDecrypt(data))
+			return err
+		}
+		if len(n.History) == 0 {
+			fmt.Println("No revisions")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "#\tSAVED\tSIZE")
+		for i, snap := range n.History {
+			size := "-"
+			if fi, err := os.Stat(snap.Path); err == nil {
+				size = strconv.FormatInt(fi.Size(), 10)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", i+1, snap.Timestamp.Format("2006-01-02 15:04:05"), size)
+		}
+		return w.Flush()
+	},
+}
+
+var notesRestoreCmd = &cobra.Command{
+	Use:   "restore <note> <revision>",
+	Short: "Restore the note to revision N (see notes history)",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		s, rel, err := cliResolveNote(args[0])
+		if err != nil {
+			return err
+		}
+		n, err := s.Load(rel)
+		if err != nil {
+			return err
+		}
+		rev, err := strconv.Atoi(args[1])
+		if err != nil || rev < 1 || rev > len(n.History) {
+			return fmt.Errorf("invalid revision %s, note %s has %d revisions", args[1], filepath.ToSlash(rel), len(n.History))
+		}
+		snap := n.History[rev-1]
+		if !notesYesFlag && askYesNo("Restore note "+filepath.ToSlash(rel)+" to revision from "+snap.Timestamp.Format("2006-01-02 15:04:05")+"?") != "yes" {
+			return nil
+		}
+		if err := s.Restore(n, snap); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Note %s restored\n", filepath.ToSlash(rel))
+		return nil
+	},
+}
+
+var notesExportCmd = &cobra.Command{
+	Use:   "export [note|folder]",
+	Short: "Export note, folder or all notes to html, pdf or zip of markdown files",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		arg := ""
+		if len(args) > 0 {
+			arg = args[0]
+		}
+		s, rel, err := cliResolvePath(arg)
+		if err != nil {
+			return err
+		}
+		format := strings.ToLower(notesFormatFlag)
+		out := notesOutputFlag
+		if out == "" {
+			name := strings.TrimSuffix(filepath.Base(rel), ".md")
+			if rel == "" {
+				name = filepath.Base(s.NotesDir)
+			}
+			out = name + "." + format
+		}
+		if err := s.Export(rel, format, out); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Exported to %s\n", out)
+		return nil
+	},
+}
+
+var notesTasksCmd = &cobra.Command{
+	Use:   "tasks",
+	Short: "List tasks: notes with due date and checkbox items (- [ ]) of all notes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		tasks, err := cliTasks()
+		if err != nil {
+			return err
+		}
+		if !notesAllFlag {
+			open := tasks[:0]
+			for _, t := range tasks {
+				if !t.Done {
+					open = append(open, t)
+				}
+			}
+			tasks = open
+		}
+		if notesJSONFlag {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(tasks)
+		}
+		if len(tasks) == 0 {
+			fmt.Println("No tasks")
+			return nil
+		}
+		now := time.Now()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STATUS\tDUE\tTASK\tREF")
+		for _, t := range tasks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.Group(now), taskDueText(t), t.Text, t.Ref())
+		}
+		return w.Flush()
+	},
+}
+
+var notesTasksDoneCmd = &cobra.Command{
+	Use:   "done <note[:line]>",
+	Short: "Mark task done, the reference is shown by notes tasks",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		tasks, err := cliTasks()
+		if err != nil {
+			return err
+		}
+		t, err := findTask(tasks, args[0])
+		if err != nil {
+			return err
+		}
+		if t.Done {
+			fmt.Printf("Task %s is already done\n", t.Ref())
+			return nil
+		}
+		for _, s := range allNoteServices() {
+			if s.NotesDir == t.NotesDir {
+				if err := s.CompleteTask(t); err != nil {
+					return err
+				}
+				fmt.Printf("✅ %s done\n", t.Text)
+				return nil
+			}
+		}
+		return fmt.Errorf("notes folder %s not found", t.NotesDir)
+	},
+}
+
+var notesVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check that notes, history and attachments match the notes encryption setting and convert them",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		services, err := cliNoteServices()
+		if err != nil {
+			return err
+		}
+		var reports []*EncryptionReport
+		failed := false
+		for _, s := range services {
+			report, err := s.VerifyEncryption(notesDryRunFlag)
+			if err != nil {
+				return err
+			}
+			reports = append(reports, report)
+			failed = failed || len(report.Unreadable) > 0 || (notesDryRunFlag && len(report.Mismatched) > 0)
+		}
+		if notesJSONFlag {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(reports); err != nil {
+				return err
+			}
+		} else {
+			for _, r := range reports {
+				state := "plain text"
+				if r.Encrypt {
+					state = "encrypted"
+				}
+				fmt.Printf("%s (%s): %d files checked\n", r.NotesDir, state, r.Checked)
+				for _, rel := range r.Mismatched {
+					if notesDryRunFlag {
+						fmt.Printf("  ❌ %s is not %s\n", filepath.ToSlash(rel), state)
+					} else {
+						fmt.Printf("  🔄 %s converted to %s\n", filepath.ToSlash(rel), state)
+					}
+				}
+				for _, rel := range r.Unreadable {
+					fmt.Printf("  ⚠️ %s can't be decrypted with the current key\n", filepath.ToSlash(rel))
+				}
+			}
+		}
+		if failed {
+			return fmt.Errorf("notes are not consistently encrypted")
+		}
+		return nil
+	},
+}
+
+var notesTemplatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List note templates, used by notes new --template",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		s, err := cliNoteService(true)
+		if err != nil {
+			return err
+		}
+		if notesInstallFlag {
+			dir, err := s.InstallTemplates()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✅ Built-in templates copied to %s\n", dir)
+		}
+		templates := s.Templates()
+		if notesJSONFlag {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(templates)
+		}
+		for _, t := range templates {
+			if t.Builtin {
+				fmt.Printf("%s (built-in)\n", t.Name)
+			} else {
+				fmt.Println(t.Name)
+			}
+		}
+		return nil
+	},
+}
+
+var notesCaptureCmd = &cobra.Command{
+	Use:   "capture [text]",
+	Short: "Append timestamped entry to the inbox note, text is read from stdin when not given",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		s, err := cliNoteService(true)
+		if err != nil {
+			return err
+		}
+		text := strings.Join(args, " ")
+		if len(args) == 0 {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			text = string(data)
+		}
+		id, err := s.Capture(text)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Captured to %s\n", filepath.ToSlash(strings.TrimSuffix(id, ".md")))
+		return nil
+	},
+}
+
+// cliFindServer returns server by host, ip or stable id
+func cliFindServer(query string) (Server, error) {
+	var found []Server
+	for _, srv := range servers {
+		if serverMatches(query, srv) {
+			found = append(found, srv)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Server{}, fmt.Errorf("server %s not found", query)
+	case 1:
+		return found[0], nil
+	}
+	return Server{}, fmt.Errorf("%d servers match %s, use the stable id", len(found), query)
+}
+
+// cliTasks returns tasks of the --file notes or of all notes
+func cliTasks() ([]NoteTask, error) {
+	services, err := cliNoteServices()
+	if err != nil {
+		return nil, err
+	}
+	var tasks []NoteTask
+	for _, s := range services {
+		if _, err := os.Stat(s.NotesDir); err != nil {
+			continue
+		}
+		t, err := s.Tasks()
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t...)
+	}
+	sortTasks(tasks)
+	return tasks, nil
+}
+
+// cliResolvePath finds the notes folder containing the note or folder, empty arg is the whole notes folder
+func cliResolvePath(arg string) (*NoteService, string, error) {
+	if arg == "" {
+		s, err := cliNoteService(false)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := os.Stat(s.NotesDir); err != nil {
+			return nil, "", fmt.Errorf("no notes for %s", notesFileFlag)
+		}
+		return s, "", nil
+	}
+	dir := filepath.Clean(filepath.FromSlash(strings.Trim(strings.TrimSpace(arg), "/")))
+	if !insideNotesDir(dir) {
+		return nil, "", fmt.Errorf("invalid path %s, it must be relative to the notes folder", arg)
+	}
+	services, err := cliNoteServices()
+	if err != nil {
+		return nil, "", err
+	}
+	var found []*NoteService
+	for _, s := range services {
+		if fi, err := os.Stat(filepath.Join(s.NotesDir, dir)); err == nil && fi.IsDir() {
+			found = append(found, s)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return cliResolveNote(arg)
+	case 1:
+		return found[0], dir, nil
+	}
+	return nil, "", fmt.Errorf("folder %s exists in several notes folders, select one with --file", filepath.ToSlash(dir))
+}
+
+// printNoteDiff prints unified diff of revisions "N" (against the current note) or "N:M"
+func printNoteDiff(s *NoteService, n *Note, spec string) error {
+	revision := func(v string) ([]byte, string, error) {
+		if v == "" || v == "current" {
+			return n.Raw, "current", nil
+		}
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 1 || i > len(n.History) {
+			return nil, "", fmt.Errorf("invalid revision %s, note has %d revisions", v, len(n.History))
+		}
+		data, err := s.ReadSnapshot(n.History[i-1])
+		return data, fmt.Sprintf("#%d %s", i, n.History[i-1].Timestamp.Format("2006-01-02 15:04:05")), err
+	}
+	from, to, _ := strings.Cut(spec, ":")
+	oldData, oldName, err := revision(from)
+	if err != nil {
+		return err
+	}
+	newData, newName, err := revision(to)
+	if err != nil {
+		return err
+	}
+	out := unifiedDiff(oldName, newName, diffLines(string(oldData), string(newData)), 3)
+	if out == "" {
+		fmt.Println("No differences")
+		return nil
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// normalizeNoteID converts user supplied note path to note id
+func normalizeNoteID(arg string) (string, error) {
+	rel := filepath.Clean(filepath.FromSlash(strings.TrimSpace(arg)))
+	// notes outside of the notes folder are never read or written
+	if !insideNotesDir(rel) {
+		return "", fmt.Errorf("invalid note %s, it must be relative to the notes folder", arg)
+	}
+	if !strings.HasSuffix(rel, ".md") {
+		rel += ".md"
+	}
+	return rel, nil
+}
+
+// cliNoteServices returns notes of the --file servers file or of all server files
+func cliNoteServices() ([]*NoteService, error) {
+	if notesFileFlag == "" {
+		return allNoteServices(), nil
+	}
+	s, err := cliNoteService(false)
+	if err != nil {
+		return nil, err
+	}
+	return []*NoteService{s}, nil
+}
+
+// cliNoteService returns notes of the --file servers file, without the flag the first servers file is used
+func cliNoteService(create bool) (*NoteService, error) {
+	var s *NoteService
+	for _, item := range ymlfiles {
+		base := filepath.Base(item)
+		if notesFileFlag == "" || notesFileFlag == base || notesFileFlag == trimYML(base) {
+			s = newNoteServiceFor(item)
+			break
+		}
+	}
+	if s == nil {
+		if notesFileFlag == "" {
+			return nil, fmt.Errorf("no servers files found")
+		}
+		return nil, fmt.Errorf("servers file %s not found", notesFileFlag)
+	}
+	if create {
+		if err := os.MkdirAll(s.NotesDir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// cliResolveNote finds the notes folder containing the note
+func cliResolveNote(arg string) (*NoteService, string, error) {
+	rel, err := normalizeNoteID(arg)
+	if err != nil {
+		return nil, "", err
+	}
+	services, err := cliNoteServices()
+	if err != nil {
+		return nil, "", err
+	}
+	var found []*NoteService
+	for _, s := range services {
+		if fi, err := os.Stat(filepath.Join(s.NotesDir, rel)); err == nil && !fi.IsDir() {
+			found = append(found, s)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, "", fmt.Errorf("note %s not found", filepath.ToSlash(rel))
+	case 1:
+		return found[0], rel, nil
+	}
+	return nil, "", fmt.Errorf("note %s exists in several notes folders, select one with --file", filepath.ToSlash(rel))
+}
+
+// editorCommand returns the user's editor command line
+func editorCommand() []string {
+	for _, v := range []string{"VISUAL", "EDITOR"} {
+		if e := strings.Fields(os.Getenv(v)); len(e) > 0 {
+			return e
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// editNoteExternal opens decrypted copy of the note (with front-matter) in the user's editor
+// and saves it back through NoteService, so it gets encrypted and snapshotted as usual.
+// The temporary copy is wiped afterwards.
+func editNoteExternal(s *NoteService, rel string) (bool, error) {
+	n, err := s.Load(rel)
+	if err != nil {
+		return false, err
+	}
+	tmp, err := os.CreateTemp("", "conan-note-*.md")
+	if err != nil {
+		return false, err
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		// overwrite decrypted content before removing the file
+		if fi, err := os.Stat(tmpPath); err == nil {
+			os.WriteFile(tmpPath, make([]byte, fi.Size()), 0600)
+		}
+		os.Remove(tmpPath)
+	}()
+	_, err = tmp.Write(n.Raw)
+	tmp.Close()
+	if err != nil {
+		return false, err
+	}
+
+	editor := editorCommand()
+	c := exec.Command(editor[0], append(editor[1:], tmpPath)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return false, fmt.Errorf("editor %s failed: %s", editor[0], err)
+	}
+
+	edited, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(edited, n.Raw) {
+		return false, nil
+	}
+	// notepad and friends save with windows line endings
+	n.Meta, n.Body = stripYAMLFrontMatter(bytes.ReplaceAll(edited, []byte("\r\n"), []byte("\n")))
+	if err := s.Save(n); err != nil {
+		return false, err
+	}
+	notesLog.Infof("Note %s edited in external editor", rel)
+	return true, nil
+}
+
+func init() {
+	notesSearchCmd.Flags().IntVar(&notesLimitFlag, "limit", 20, "Show only first N results (0 for all)")
+	notesSearchCmd.Flags().BoolVar(&notesJSONFlag, "json", false, "Output results as JSON")
+	notesListCmd.Flags().BoolVar(&notesJSONFlag, "json", false, "Output notes as JSON")
+	notesShowCmd.Flags().BoolVar(&notesRawFlag, "raw", false, "Print note with front-matter")
+	notesNewCmd.Flags().BoolVar(&notesEditFlag, "edit", false, "Open the new note in $EDITOR")
+	notesNewCmd.Flags().StringVar(&notesTemplateFlag, "template", "", "Create the note from template (see notes templates)")
+	notesNewCmd.Flags().StringVar(&notesServerFlag, "server", "", "Link the note to the server (host, ip or stable id) and fill in the template with it")
+	notesTemplatesCmd.Flags().BoolVar(&notesInstallFlag, "install", false, "Copy built-in templates to the templates folder to customize them")
+	notesTemplatesCmd.Flags().BoolVar(&notesJSONFlag, "json", false, "Output templates as JSON")
+	notesRmCmd.Flags().BoolVarP(&notesYesFlag, "yes", "y", false, "Do not ask for confirmation")
+	notesRmCmd.Flags().BoolVar(&notesGistFlag, "gist", false, "Delete the note from gist too")
+	notesHistoryCmd.Flags().IntVar(&notesShowFlag, "show", 0, "Print revision N")
+	notesHistoryCmd.Flags().StringVar(&notesDiffFlag, "diff", "", "Show diff of revision N against the current note, or N:M between two revisions")
+	notesHistoryCmd.Flags().BoolVar(&notesPruneFlag, "prune", false, "Remove old revisions according to the history retention settings")
+	notesRestoreCmd.Flags().BoolVarP(&notesYesFlag, "yes", "y", false, "Do not ask for confirmation")
+	notesExportCmd.Flags().StringVar(&notesFormatFlag, "format", ExportHTML, "Export format: html, pdf or zip")
+	notesExportCmd.Flags().StringVarP(&notesOutputFlag, "output", "o", "", "Output file (default <name>.<format> in the current directory)")
+	notesTasksCmd.Flags().BoolVar(&notesAllFlag, "all", false, "Include completed tasks")
+	notesTasksCmd.Flags().BoolVar(&notesJSONFlag, "json", false, "Output tasks as JSON")
+	notesVerifyCmd.Flags().BoolVar(&notesDryRunFlag, "dry-run", false, "Only report files in the wrong state")
+	notesVerifyCmd.Flags().BoolVar(&notesJSONFlag, "json", false, "Output report as JSON")
+	notesTasksCmd.AddCommand(notesTasksDoneCmd)
+	notesCmd.PersistentFlags().StringVar(&notesFileFlag, "file", "", "Servers file whose notes are used (e.g. servers.yml)")
+	notesCmd.AddCommand(notesSearchCmd, notesListCmd, notesShowCmd, notesEditCmd, notesNewCmd, notesRmCmd, notesMvCmd, notesHistoryCmd, notesRestoreCmd, notesExportCmd, notesTasksCmd, notesVerifyCmd, notesTemplatesCmd, notesCaptureCmd)
+	rootCmd.AddCommand(notesCmd)
+}
@@ -0,0 +1,243 @@
+package main
+
+/* Secrets from the command line
+Encrypt and decrypt values with the key of the servers file (the gist key or the global key),
+print secrets of the servers for scripts and answer ssh/git password prompts as askpass helper:
+  CONAN_ASKPASS=web1 SSH_ASKPASS=/path/to/conan SSH_ASKPASS_REQUIRE=force ssh web1
+CONAN_ASKPASS is the server (host, IP or stable id), it is never taken from the prompt,
+prompts come from the remote host and could ask for the secrets of other servers.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// askpassEnv selects the server the askpass helper answers for
+const askpassEnv = "CONAN_ASKPASS"
+
+var secretFileFlag string
+
+var secretFields = []string{"password", "otp", "totp", "user", "host", "ip", "port", "key", "jump"}
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Encrypt, decrypt and get secrets of the servers",
+}
+
+var secretEncryptCmd = &cobra.Command{
+	Use:   "encrypt [text]",
+	Short: "Encrypt the text (or standard input) with the key of the servers file",
+	Example: `  echo -n "$PASS" | conan secret encrypt --file work.yml
+  conan secret encrypt "s3cret"`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		key, err := secretKey(secretFileFlag)
+		if err != nil {
+			return err
+		}
+		text, err := secretInput(args)
+		if err != nil {
+			return err
+		}
+		encrypted, err := encryptString(text, key)
+		if err != nil {
+			return err
+		}
+		fmt.Println(encrypted)
+		return nil
+	},
+}
+
+var secretDecryptCmd = &cobra.Command{
+	Use:   "decrypt [encrypted]",
+	Short: "Decrypt the value (or standard input) with the key of the servers file",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		key, err := secretKey(secretFileFlag)
+		if err != nil {
+			return err
+		}
+		text, err := secretInput(args)
+		if err != nil {
+			return err
+		}
+		plain, err := decryptString(strings.TrimSpace(text), key)
+		if err != nil {
+			return fmt.Errorf("unable to decrypt, wrong key or servers file: %w", err)
+		}
+		auditEvent(AuditSecretDecrypt, "", secretFileFlag, "cli")
+		fmt.Println(plain)
+		return nil
+	},
+}
+
+var secretGetCmd = &cobra.Command{
+	Use:   "get <server> [field]",
+	Short: "Print the field of the server, password when the field is not given",
+	Long:  "Print the field of the server: " + strings.Join(secretFields, ", ") + ". otp is the current one time password, totp the secret.",
+	Example: `  sshpass -p "$(conan secret get web1)" ssh root@web1
+  conan secret get web1 otp`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeServerHosts,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		srv, err := cliFindServer(args[0])
+		if err != nil {
+			return err
+		}
+		field := "password"
+		if len(args) > 1 {
+			field = strings.ToLower(args[1])
+		}
+		value, err := serverSecret(srv, field)
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+var secretAskpassCmd = &cobra.Command{
+	Use:   "askpass <prompt>",
+	Short: "Answer the ssh or git password prompt (" + askpassEnv + " selects the server)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAskpass(args[0])
+	},
+}
+
+// secretKey returns the encryption key of the servers file, empty (the global key) when no file is given
+func secretKey(file string) (string, error) {
+	if file == "" {
+		return "", nil
+	}
+	path, err := resolveServersFile(file)
+	if err != nil {
+		return "", err
+	}
+	_, gist := gistExists(filepath.Base(path))
+	return gist.EncKey, nil
+}
+
+// secretInput returns the argument or the standard input without the trailing new line
+func secretInput(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	data, err := io.ReadAll(bufio.NewReader(os.Stdin))
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimRight(string(data), "\r\n")
+	if text == "" {
+		return "", fmt.Errorf("nothing to do, the value is empty")
+	}
+	return text, nil
+}
+
+// serverSecret returns the field of the server
+func serverSecret(srv Server, field string) (string, error) {
+	var value string
+	switch field {
+	case "password":
+		value = srv.DecryptPassword()
+	case "otp":
+		return srv.OTP()
+	case "totp":
+		value = srv.DecryptTOTP()
+	case "user":
+		value = srv.User
+	case "host":
+		value = srv.Host
+	case "ip":
+		value = srv.IP
+	case "port":
+		value = srv.Port
+	case "key":
+		value = srv.PrivateKey
+	case "jump":
+		value = srv.Jump
+	default:
+		return "", fmt.Errorf("unknown field %s, supported: %s", field, strings.Join(secretFields, ", "))
+	}
+	if value == "" {
+		return "", fmt.Errorf("%s of %s is not set", field, srv.Host)
+	}
+	return value, nil
+}
+
+// quoted URLs and (user@host) are left out when looking for the field
+var askpassQuotedRe = regexp.MustCompile(`'[^']*'|\([^)]*\)`)
+
+// askpassField returns the field the prompt asks for, empty for the prompts that are not answered
+// (host key confirmations and anything that does not look like a password prompt)
+func askpassField(prompt string) string {
+	p := strings.ToLower(askpassQuotedRe.ReplaceAllString(prompt, ""))
+	switch {
+	case strings.Contains(p, "yes/no"), strings.Contains(p, "fingerprint"), strings.Contains(p, "continue connecting"):
+		return ""
+	case strings.Contains(p, "verification code"), strings.Contains(p, "one-time"), strings.Contains(p, "otp"), strings.Contains(p, "token"):
+		return "otp"
+	case strings.HasPrefix(p, "username"):
+		return "user"
+	case strings.Contains(p, "password"), strings.Contains(p, "passphrase"):
+		return "password"
+	}
+	return ""
+}
+
+// runAskpass prints the answer to the ssh or git prompt
+func runAskpass(prompt string) error {
+	field := askpassField(prompt)
+	if field == "" {
+		return fmt.Errorf("prompt %q is not answered", prompt)
+	}
+	initCLI()
+	query := strings.TrimSpace(os.Getenv(askpassEnv))
+	if query == "" || query == "1" || strings.EqualFold(query, "auto") {
+		return fmt.Errorf("%s has to be the server (host, IP or stable id), it is not taken from the prompt", askpassEnv)
+	}
+	srv, err := cliFindServer(query)
+	if err != nil {
+		return err
+	}
+	value, err := serverSecret(srv, field)
+	if err != nil {
+		return err
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// isSubcommand returns true when the argument is a command of the program
+func isSubcommand(arg string) bool {
+	if strings.HasPrefix(arg, "-") {
+		return true
+	}
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == arg || c.HasAlias(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	for _, c := range []*cobra.Command{secretEncryptCmd, secretDecryptCmd} {
+		c.Flags().StringVar(&secretFileFlag, "file", "", "Servers file whose key is used (default the global key)")
+	}
+	secretCmd.AddCommand(secretEncryptCmd, secretDecryptCmd, secretGetCmd, secretAskpassCmd)
+	rootCmd.AddCommand(secretCmd)
+}
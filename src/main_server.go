@@ -0,0 +1,407 @@
+package main
+
+/* Servers command line interface
+Add, change, remove and list servers without the GUI, e.g. from provisioning scripts.
+Servers are selected by host, IP or stable id (conan server ls shows it), --db selects the servers file.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	serverOutputFlag   string
+	serverFilterFlag   string
+	serverPassStdin    bool
+	serverTOTPStdin    bool
+	serverAskPassword  bool
+	serverShowPassword bool
+	serverYesFlag      bool
+	serverFields       Server
+)
+
+var serverCmd = &cobra.Command{
+	Use:   "server",
+	Short: "Add, change, remove and list servers",
+}
+
+var serverLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List servers",
+	Example: `  conan server ls
+  conan server ls --filter "type=SSH tag=prod" --output json
+  conan server ls --filter "host=web* file=work"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		filter, err := ParseServerFilter(serverFilterFlag)
+		if err != nil {
+			return err
+		}
+		return printServers(filter.Filter(servers), false)
+	},
+}
+
+var serverShowCmd = &cobra.Command{
+	Use:   "show <server>",
+	Short: "Show the server",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		srv, err := cliFindServer(args[0])
+		if err != nil {
+			return err
+		}
+		if serverOutputFlag == "table" {
+			printServerDetails(srv)
+			return nil
+		}
+		return printServers([]Server{srv}, true)
+	},
+}
+
+var serverAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a server",
+	Example: `  conan server add --host web1 --ip 10.0.0.11 --user deploy --tags web,prod
+  echo "$PASS" | conan --db work server add --host db1 --ip 10.0.0.20 --user root --password-stdin`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		target, err := serverTargetFile()
+		if err != nil {
+			return err
+		}
+		srv := serverFields
+		srv.Type = serverType(srv.Type)
+		srv.Group = cleanGroup(srv.Group)
+		if srv.Type == "" {
+			return fmt.Errorf("unknown server type, supported: %s", strings.Join(ServerTypes, ", "))
+		}
+		if srv.Host == "" || srv.IP == "" {
+			return fmt.Errorf("--host and --ip are required")
+		}
+		var existing []Server
+		for _, s := range servers {
+			if s.SourcePath == target {
+				existing = append(existing, s)
+			}
+		}
+		if isDuplicateServer(existing, srv) {
+			return fmt.Errorf("server %s already exists in %s", srv.Host, baseNames([]string{target})[0])
+		}
+		srv.ID = uuid.NewString()
+		srv.SourcePath = target
+		srv.SourceName = baseNames([]string{target})[0]
+		if err := serverReadSecrets(&srv); err != nil {
+			return err
+		}
+		servers = append(servers, srv)
+		pushServersToFile()
+		fmt.Printf("Server %s (%s) added to %s\n", srv.Host, srv.StableID(), srv.SourceName)
+		return nil
+	},
+}
+
+var serverEditCmd = &cobra.Command{
+	Use:     "edit <server>",
+	Short:   "Change fields of the server, only the given flags are changed",
+	Example: `  conan server edit web1 --ip 10.0.0.12 --tags web,prod,eu`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		i, err := cliServerIndex(args[0])
+		if err != nil {
+			return err
+		}
+		srv := servers[i]
+		flags := cmd.Flags()
+		set := func(name string, field *string, value string) {
+			if flags.Changed(name) {
+				*field = value
+			}
+		}
+		set("host", &srv.Host, serverFields.Host)
+		set("ip", &srv.IP, serverFields.IP)
+		set("user", &srv.User, serverFields.User)
+		set("port", &srv.Port, serverFields.Port)
+		set("key", &srv.PrivateKey, serverFields.PrivateKey)
+		set("description", &srv.Description, serverFields.Description)
+		set("tags", &srv.Tags, serverFields.Tags)
+		set("group", &srv.Group, cleanGroup(serverFields.Group))
+		set("jump", &srv.Jump, serverFields.Jump)
+		if flags.Changed("notes") {
+			srv.Notes = serverFields.Notes
+		}
+		if flags.Changed("type") {
+			if srv.Type = serverType(serverFields.Type); srv.Type == "" {
+				return fmt.Errorf("unknown server type, supported: %s", strings.Join(ServerTypes, ", "))
+			}
+		}
+		if srv.Host == "" || srv.IP == "" {
+			return fmt.Errorf("host and ip can't be empty")
+		}
+		if err := serverReadSecrets(&srv); err != nil {
+			return err
+		}
+		servers[i] = srv
+		pushServersToFile()
+		fmt.Printf("Server %s (%s) changed\n", srv.Host, srv.StableID())
+		return nil
+	},
+}
+
+var serverRmCmd = &cobra.Command{
+	Use:   "rm <server>",
+	Short: "Remove the server",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		i, err := cliServerIndex(args[0])
+		if err != nil {
+			return err
+		}
+		srv := servers[i]
+		if !serverYesFlag && !term.IsTerminal(int(syscall.Stdin)) {
+			return fmt.Errorf("use --yes to remove the server without confirmation")
+		}
+		if !serverYesFlag && askYesNo(fmt.Sprintf("Remove server %s (%s) from %s?", srv.Host, srv.IP, srv.SourceName)) != "yes" {
+			return nil
+		}
+		servers = append(servers[:i], servers[i+1:]...)
+		pushServersToFile(srv.SourcePath)
+		fmt.Printf("Server %s removed from %s\n", srv.Host, srv.SourceName)
+		return nil
+	},
+}
+
+var serverMvCmd = &cobra.Command{
+	Use:   "mv <server> <servers file>",
+	Short: "Move the server to another servers file",
+	Long:  "Move the server to another servers file, password and TOTP secret are encrypted with the key of the new file.",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initCLI()
+		i, err := cliServerIndex(args[0])
+		if err != nil {
+			return err
+		}
+		target, err := resolveServersFile(args[1])
+		if err != nil {
+			return err
+		}
+		srv := servers[i]
+		from := srv.SourcePath
+		if from == target {
+			return nil
+		}
+		pass, totp := srv.DecryptPassword(), srv.DecryptTOTP()
+		srv.SourcePath = target
+		srv.SourceName = baseNames([]string{target})[0]
+		srv.Password = srv.EncryptPassword(pass)
+		srv.TOTP = srv.EncryptTOTP(totp)
+		servers[i] = srv
+		pushServersToFile(from)
+		fmt.Printf("Server %s moved to %s\n", srv.Host, srv.SourceName)
+		return nil
+	},
+}
+
+// cliServerIndex returns index of the server in servers that can be changed
+func cliServerIndex(query string) (int, error) {
+	srv, err := cliFindServer(query)
+	if err != nil {
+		return -1, err
+	}
+	if srv.ReadOnly() {
+		return -1, errReadOnly(srv)
+	}
+	for i := range servers {
+		if servers[i].ID == srv.ID {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("server %s not found", query)
+}
+
+// serverTargetFile returns servers file new servers are added to, --db selects it when there are more files
+func serverTargetFile() (string, error) {
+	switch len(ymlfiles) {
+	case 0:
+		return "", fmt.Errorf("no servers files found")
+	case 1:
+		return ymlfiles[0], nil
+	}
+	return "", fmt.Errorf("%d servers files found, select one with --db (%s)", len(ymlfiles), strings.Join(baseNames(ymlfiles), ", "))
+}
+
+// serverType returns the type as written in ServerTypes, SSH when empty
+func serverType(t string) string {
+	if t == "" {
+		return "SSH"
+	}
+	for _, st := range ServerTypes {
+		if strings.EqualFold(st, t) {
+			return st
+		}
+	}
+	return ""
+}
+
+// serverReadSecrets reads password and TOTP secret from stdin (password on the first line, TOTP on the next one)
+// or asks for the password
+func serverReadSecrets(srv *Server) error {
+	if serverPassStdin || serverTOTPStdin {
+		r := bufio.NewReader(os.Stdin)
+		readLine := func() (string, error) {
+			line, err := r.ReadString('\n')
+			if err != nil && (err != io.EOF || line == "") {
+				return "", fmt.Errorf("unable to read from standard input: %w", err)
+			}
+			return strings.TrimRight(line, "\r\n"), nil
+		}
+		if serverPassStdin {
+			pass, err := readLine()
+			if err != nil {
+				return err
+			}
+			srv.Password = srv.EncryptPassword(pass)
+		}
+		if serverTOTPStdin {
+			secret, err := readLine()
+			if err != nil {
+				return err
+			}
+			if _, err := parseTOTPSecret(secret); err != nil {
+				return fmt.Errorf("invalid TOTP secret: %w", err)
+			}
+			srv.TOTP = srv.EncryptTOTP(secret)
+		}
+	}
+	if serverAskPassword {
+		fmt.Fprint(os.Stderr, "Password: ")
+		pass, err := term.ReadPassword(int(syscall.Stdin))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return err
+		}
+		srv.Password = srv.EncryptPassword(string(pass))
+	}
+	return nil
+}
+
+// printServers prints the servers in the --output format, passwords are shown only with --show-password
+func printServers(list []Server, details bool) error {
+	opts := ExportOptions{}
+	if serverShowPassword {
+		opts.Passwords = PasswordsPlain
+	}
+	records := make([]exportRecord, 0, len(list))
+	for _, srv := range list {
+		records = append(records, newExportRecord(srv, opts))
+	}
+	switch serverOutputFlag {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if details && len(records) == 1 {
+			return enc.Encode(records[0])
+		}
+		return enc.Encode(records)
+	case "yaml":
+		var v interface{} = records
+		if details && len(records) == 1 {
+			v = records[0]
+		}
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tHOST\tADDRESS\tUSER\tPORT\tTYPE\tTAGS\tGROUP\tFILE")
+		for _, r := range records {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.Host, r.IP, r.User, r.Port, r.Type, strings.Join(r.Tags, ","), r.Group, r.Source)
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("unknown output format %s, supported: table, json, yaml", serverOutputFlag)
+}
+
+func printServerDetails(srv Server) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	row := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "%s:\t%s\n", name, value)
+		}
+	}
+	row("ID", srv.StableID())
+	row("Host", srv.Host)
+	row("IP", srv.IP)
+	row("User", srv.User)
+	row("Port", srv.Port)
+	row("Type", srv.Type)
+	row("Private key", srv.PrivateKey)
+	row("Jump host", srv.Jump)
+	row("Tags", srv.Tags)
+	row("Group", srv.Group)
+	row("Description", srv.Description)
+	row("Notes", strings.Join(srv.Notes, ", "))
+	row("File", srv.SourceRel())
+	if srv.Password != "" {
+		pass := "(set)"
+		if serverShowPassword {
+			pass = srv.DecryptPassword()
+		}
+		row("Password", pass)
+	}
+	if srv.TOTP != "" {
+		row("TOTP", "(set)")
+	}
+	w.Flush()
+}
+
+func init() {
+	for _, c := range []*cobra.Command{serverLsCmd, serverShowCmd} {
+		c.Flags().StringVarP(&serverOutputFlag, "output", "o", "table", "Output format: table, json or yaml")
+		c.Flags().BoolVar(&serverShowPassword, "show-password", false, "Include decrypted passwords")
+	}
+	serverLsCmd.Flags().StringVar(&serverFilterFlag, "filter", "", `Filter: field=value (wildcards), field!=value, field~text or text, e.g. "type=SSH tag=prod"`)
+
+	for _, c := range []*cobra.Command{serverAddCmd, serverEditCmd} {
+		f := c.Flags()
+		f.StringVar(&serverFields.Host, "host", "", "Hostname (name shown in the lists)")
+		f.StringVar(&serverFields.IP, "ip", "", "IP address or DNS name")
+		f.StringVar(&serverFields.User, "user", "", "Username")
+		f.StringVar(&serverFields.Port, "port", "", "Port (default port of the type when empty)")
+		f.StringVar(&serverFields.Type, "type", "", "Type: "+strings.Join(ServerTypes, ", ")+" (default SSH)")
+		f.StringVar(&serverFields.PrivateKey, "key", "", "Private key, name in ~/.ssh or full path")
+		f.StringVar(&serverFields.Description, "description", "", "Description")
+		f.StringVar(&serverFields.Tags, "tags", "", "Comma separated tags")
+		f.StringVar(&serverFields.Group, "group", "", "Group path, levels separated by /, e.g. DC1/Rack4")
+		f.StringVar(&serverFields.Jump, "jump", "", "Jump host, [user@]host[:port]")
+		f.StringSliceVar(&serverFields.Notes, "notes", nil, "Linked notes, comma separated")
+		f.BoolVar(&serverPassStdin, "password-stdin", false, "Read the password from the first line of standard input")
+		f.BoolVar(&serverTOTPStdin, "totp-stdin", false, "Read the TOTP secret from standard input (the line after the password)")
+		f.BoolVar(&serverAskPassword, "ask-password", false, "Ask for the password")
+	}
+	serverRmCmd.Flags().BoolVarP(&serverYesFlag, "yes", "y", false, "Remove without confirmation")
+
+	serverCmd.AddCommand(serverLsCmd, serverShowCmd, serverAddCmd, serverEditCmd, serverRmCmd, serverMvCmd)
+	rootCmd.AddCommand(serverCmd)
+}
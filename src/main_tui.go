@@ -19,6 +19,8 @@ import (
 	"golang.org/x/term"
 
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/rivo/tview"
 	"gopkg.in/ini.v1"
@@ -28,9 +30,30 @@ var appbase = tview.NewApplication()
 var grid = tview.NewFlex()
 var table = tview.NewTable().SetSelectable(true, false)
 var searchBox = tview.NewInputField()
+var statusLine = tview.NewTextView().SetDynamicColors(true)
 var searchMode = false
 var theme map[string]tcell.Color
 
+// tuiRunning is set while tview owns the terminal, writes to it have to go through appbase
+var tuiRunning atomic.Bool
+
+// settingsPasswordEnv is the environment variable with the password of the encrypted settings
+const settingsPasswordEnv = "CONAN_SETTINGS_PASSWORD"
+
+// readTerminalPassword reads the password from the terminal, also when the standard input
+// is redirected (askpass helpers, secrets piped to the command)
+func readTerminalPassword() ([]byte, error) {
+	if term.IsTerminal(int(syscall.Stdin)) {
+		return term.ReadPassword(int(syscall.Stdin))
+	}
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return nil, fmt.Errorf("no terminal to read the password from, set %s", settingsPasswordEnv)
+	}
+	defer tty.Close()
+	return term.ReadPassword(int(tty.Fd()))
+}
+
 func tuiCheckProtection() error {
 	encrypted, err := IsEncryptedINI(env.settingsFile)
 	if err != nil {
@@ -39,10 +62,17 @@ func tuiCheckProtection() error {
 	}
 	if encrypted {
 		log.Printf("Program settings is encrypted!\n\n")
-		for {
-			fmt.Printf("Please enter password: ")
-			bytePwd, err := term.ReadPassword(int(syscall.Stdin))
-			fmt.Println() // move to next line after user hits Enter
+		// scripts and askpass helpers can pass the password in the environment
+		if password := os.Getenv(settingsPasswordEnv); password != "" {
+			if _, err := LoadEncryptedINI(env.settingsFile, password); err != nil {
+				return fmt.Errorf("settings password from %s is incorrect", settingsPasswordEnv)
+			}
+			settings.DecryptPassword = password
+		}
+		for settings.DecryptPassword == "" {
+			fmt.Fprintf(os.Stderr, "Please enter password: ")
+			bytePwd, err := readTerminalPassword()
+			fmt.Fprintln(os.Stderr) // move to next line after user hits Enter
 			if err != nil {
 				log.Fatalf("Failed to read password: %v", err)
 				continue
@@ -53,7 +83,7 @@ func tuiCheckProtection() error {
 				settings.DecryptPassword = password
 				break
 			}
-			fmt.Println("❌ Incorrect—please try again.")
+			fmt.Fprintln(os.Stderr, "❌ Incorrect—please try again.")
 		}
 		log.Printf("Password accepted!\n")
 		loadSettings(settings.DecryptPassword)
@@ -70,6 +100,16 @@ func initTUI() {
 		os.Exit(1)
 	}
 
+	// servers of the stale inventories are fetched in the background
+	if onInventoryFetched(func() {
+		appbase.QueueUpdateDraw(func() {
+			fetchServersFromFiles()
+			fuzzySearch(searchBox.GetText())
+		})
+	}) {
+		fetchServersFromFiles()
+	}
+
 	initSearchBox()
 	updateTable()
 	applyTheme()
@@ -114,6 +154,10 @@ func initTUI() {
 				editServer(row)
 			case 'r':
 				updateTable()
+			case 'n':
+				showNotesBrowser()
+			case 'g':
+				showGroupNavigator()
 			case 'l':
 				row, _ := table.GetSelection()
 				showContextMenu(filteredServers[row-1])
@@ -127,10 +171,13 @@ func initTUI() {
 
 	grid.SetDirection(tview.FlexRow).
 		AddItem(searchBox, 1, 0, false).
-		AddItem(table, 0, 1, true)
+		AddItem(table, 0, 1, true).
+		AddItem(statusLine, 1, 0, false)
 
+	tuiRunning.Store(true)
+	defer tuiRunning.Store(false)
 	if err := appbase.SetRoot(grid, true).Run(); err != nil {
-		fmt.Printf("Got small error: %s\n")
+		fmt.Printf("Got small error: %s\n", err)
 		//panic(err)
 	}
 }
@@ -225,6 +272,9 @@ func showHelp() {
 	helpText += "[yellow]h[::-] - Show this help menu\n"
 	helpText += "[magenta]i[::-] - Insert a new server\n"
 	helpText += "[red]d[::-] - Delete selected server\n"
+	helpText += "[green]n[::-] - Notes browser\n"
+	helpText += "[blue]g[::-] - Group navigator\n"
+	helpText += "[cyan]l[::-] - Context menu (info, copy password/user/IP/URI)\n"
 	helpText += "[blue]Arrow Keys[::-] - Navigate server list\n"
 	helpText += "[white]Enter[::-] - Connect to selected server"
 
@@ -242,11 +292,12 @@ func showHelp() {
 
 func fuzzySearch(query string) {
 	query = strings.ToLower(query)
+	list := tuiGroupServers()
 	if query == "" {
-		filteredServers = servers // Reset to show all servers
+		filteredServers = list // Reset to show all servers of the group
 	} else {
 		filteredServers = nil
-		for _, s := range servers {
+		for _, s := range list {
 			if fuzzy.Match(query, strings.ToLower(s.Host)) || fuzzy.Match(query, strings.ToLower(s.IP)) || fuzzy.Match(query, strings.ToLower(s.Description)) {
 				filteredServers = append(filteredServers, s)
 			}
@@ -278,12 +329,16 @@ func askYesNo(question string) string {
 
 	for {
 		fmt.Print(question + " (yes/no): ")
-		input, _ := reader.ReadString('\n')
+		input, err := reader.ReadString('\n')
 		input = strings.TrimSpace(strings.ToLower(input))
 
 		if input == "yes" || input == "no" {
 			return input
 		}
+		// closed stdin answers no
+		if err != nil {
+			return "no"
+		}
 
 		fmt.Println("Invalid input. Please enter 'yes' or 'no'.")
 	}
@@ -291,10 +346,20 @@ func askYesNo(question string) string {
 
 // Function to display server info
 func showServerInfo(srv Server) {
+	yesNo := func(v string) string {
+		if v != "" {
+			return "yes"
+		}
+		return "no"
+	}
 	info := fmt.Sprintf(
-		"Hostname: %s\nIP: %s\nDescription: %s\nType: %s",
-		srv.Host, srv.IP, srv.Description, srv.Type,
+		"Hostname: %s\nIP: %s\nPort: %s\nUser: %s\nDescription: %s\nType: %s\nTags: %s\nGroup: %s\nSource: %s\nLink ID: %s\nPassword set: %s\nTOTP set: %s\n\n%s",
+		srv.Host, srv.IP, srv.Port, srv.User, srv.Description, srv.Type, srv.Tags, srv.Group, srv.SourceRel(), srv.StableID(),
+		yesNo(srv.Password), yesNo(srv.TOTP), srv.ConnectionString(),
 	)
+	if links := linkedNotes(srv); len(links) > 0 {
+		info += "\n\nRunbooks: " + linkedNoteTitles(links)
+	}
 
 	dialog := tview.NewModal().
 		SetText(info).
@@ -313,18 +378,85 @@ func showServerInfo(srv Server) {
 func showContextMenu(srv Server) {
 	pages := tview.NewPages()
 	pages.AddPage("main", grid, true, true)
-	ContextMenu(appbase, pages, "Context Menu", []string{"Open", "Info"}, func(index int, option string) {
+	options := []string{"Open", "Info", "Copy password", "Copy user", "Copy IP", "Copy URI"}
+	if srv.TOTP != "" {
+		options = append(options, "Copy OTP")
+	}
+	links := linkedNotes(srv)
+	for _, l := range links {
+		options = append(options, "📓 "+l.Title)
+	}
+	ContextMenu(appbase, pages, "Context Menu", options, func(index int, option string) {
 		// Handle menu selection here
 		switch option {
 		case "Open":
 			jumpserver(srv)
 		case "Info":
 			showServerInfo(srv)
+		case "Copy password":
+			tuiCopyServerField(srv, "password")
+		case "Copy user":
+			tuiCopyServerField(srv, "user")
+		case "Copy IP":
+			tuiCopyServerField(srv, "ip")
+		case "Copy URI":
+			tuiCopyServerField(srv, "uri")
+		case "Copy OTP":
+			tuiCopyOTP(srv)
+		default:
+			// linked notes follow the fixed options
+			if i := index - (len(options) - len(links)); i >= 0 && i < len(links) {
+				notesCurrent = &tuiNoteRef{service: newNoteServiceFor(srv.SourceName), rel: links[i].ID}
+				showNotesBrowser()
+			}
 		}
 	})
 	appbase.SetRoot(pages, true)
 }
 
+// tuiCopyServerField copies server details to the terminal clipboard using OSC52
+func tuiCopyServerField(srv Server, field string) {
+	var text string
+	switch field {
+	case "password":
+		text = srv.DecryptPassword()
+	case "user":
+		text = srv.User
+	case "ip":
+		text = srv.Address()
+	case "uri":
+		text = srv.ConnectionString()
+	}
+	if text == "" {
+		ShowMessageBox("Info", "Server "+srv.Host+" has no "+field+" defined")
+		return
+	}
+	if field == "password" {
+		copySecretToClipboard(text)
+		msg := "Password of " + srv.Host + " copied to clipboard"
+		if settings.ClipboardClear > 0 {
+			msg += fmt.Sprintf("\n(clears in %ds)", settings.ClipboardClear)
+		}
+		ShowMessageBox("Copied", msg)
+		return
+	}
+	copyToClipboard(text)
+	ShowMessageBox("Copied", text+"\ncopied to clipboard")
+}
+
+// tuiCopyOTP copies the current OTP code using OSC52 and also displays it,
+// because not every terminal supports clipboard escape sequences
+func tuiCopyOTP(srv Server) {
+	code, err := srv.OTP()
+	if err != nil {
+		ShowMessageBox("Error", "Unable to generate OTP: "+err.Error())
+		return
+	}
+	copySecretToClipboard(code)
+	cfg, _ := parseTOTPSecret(srv.DecryptTOTP())
+	ShowMessageBox("OTP", fmt.Sprintf("OTP for %s: %s\n(valid for %ds, copied to clipboard)", srv.Host, code, totpRemaining(cfg, time.Now())))
+}
+
 // ShowContextMenu displays a list of options in a modal-style context menu
 func ContextMenu(app *tview.Application, pages *tview.Pages, title string, options []string, onSelect func(index int, option string)) {
 	menu := tview.NewList()
@@ -370,6 +502,30 @@ func ShowMessageBox(title, message string) {
 	appbase.SetRoot(modal, false).SetFocus(modal)
 }
 
+// showFormError shows the error and returns to the form, so the entered values are kept
+func showFormError(form *tview.Form, message string) {
+	modal := tview.NewModal().
+		SetText(message).
+		AddButtons([]string{"OK"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			appbase.SetRoot(form, true).SetFocus(form)
+		})
+	modal.SetTitle("Error").SetBorder(true)
+	appbase.SetRoot(modal, false).SetFocus(modal)
+}
+
+// validTOTPSecret checks the TOTP secret of the form the same way the servers window does
+func validTOTPSecret(form *tview.Form, secret string) bool {
+	if secret = strings.TrimSpace(secret); secret == "" {
+		return true
+	}
+	if _, err := parseTOTPSecret(secret); err != nil {
+		showFormError(form, "Invalid TOTP secret: "+err.Error())
+		return false
+	}
+	return true
+}
+
 func insertServer() {
 	form := tview.NewForm()
 	form.AddDropDown("File", baseNames(ymlfiles), 0, nil).
@@ -378,15 +534,22 @@ func insertServer() {
 		AddInputField("Port", "", 15, nil, nil).
 		AddInputField("Username", "", 20, nil, nil).
 		AddInputField("Password", "", 20, nil, nil).
+		AddPasswordField("TOTP secret", "", 20, '*', nil).
 		AddInputField("Description", "", 30, nil, nil).
+		AddInputField("Group", tuiGroupOf(tuiGroup), 30, nil, nil).
 		AddDropDown("Type", ServerTypes, 0, nil).
 		AddButton("Save", func() {
 			hostname := form.GetFormItemByLabel("Hostname").(*tview.InputField).GetText()
 			username := form.GetFormItemByLabel("Username").(*tview.InputField).GetText()
 			passw := form.GetFormItemByLabel("Password").(*tview.InputField).GetText()
+			totp := form.GetFormItemByLabel("TOTP secret").(*tview.InputField).GetText()
+			if !validTOTPSecret(form, totp) {
+				return
+			}
 			ip := form.GetFormItemByLabel("IP Address").(*tview.InputField).GetText()
 			port := form.GetFormItemByLabel("Port").(*tview.InputField).GetText()
 			desc := form.GetFormItemByLabel("Description").(*tview.InputField).GetText()
+			group := cleanGroup(form.GetFormItemByLabel("Group").(*tview.InputField).GetText())
 			typeIndex, _ := form.GetFormItemByLabel("Type").(*tview.DropDown).GetCurrentOption()
 			serverType := ServerTypes[typeIndex]
 			selectFileIndex, _ := form.GetFormItemByLabel("File").(*tview.DropDown).GetCurrentOption()
@@ -400,13 +563,14 @@ func insertServer() {
 			}
 
 			if hostname != "" && ip != "" {
-				srv := Server{ID: uuid.NewString(), SourceName: selectedFileBaseName, SourcePath: selectedFileFullPath, Host: hostname, User: username, Password: "", IP: ip, Port: port, Description: desc, Type: serverType}
+				srv := Server{ID: uuid.NewString(), SourceName: selectedFileBaseName, SourcePath: selectedFileFullPath, Host: hostname, User: username, Password: "", IP: ip, Port: port, Description: desc, Group: group, Type: serverType}
 				srv.Password = srv.EncryptPassword(passw)
+				srv.TOTP = srv.EncryptTOTP(totp)
 				servers = append(servers, srv)
 
 				pushServersToFile()
 				fetchServersFromFiles()
-				updateTable()
+				fuzzySearch(searchBox.GetText())
 			}
 			returnToMainWindow()
 		}).
@@ -421,6 +585,10 @@ func deleteServer(row int) {
 		return
 	}
 	srv := filteredServers[row-1]
+	if srv.ReadOnly() {
+		ShowMessageBox("Error", errReadOnly(srv).Error())
+		return
+	}
 
 	// Create a styled modal confirmation dialog
 	confirmation := tview.NewModal().
@@ -443,7 +611,7 @@ func deleteServer(row int) {
 
 				pushServersToFile()
 				fetchServersFromFiles()
-				updateTable()
+				fuzzySearch(searchBox.GetText())
 			}
 			returnToMainWindow()
 		})
@@ -457,6 +625,10 @@ func editServer(row int) {
 		return
 	}
 	srv := filteredServers[row-1]
+	if srv.ReadOnly() {
+		ShowMessageBox("Error", errReadOnly(srv).Error())
+		return
+	}
 	// decrypt the password
 	pass := srv.DecryptPassword()
 
@@ -482,31 +654,40 @@ func editServer(row int) {
 		AddInputField("IP Address", srv.IP, 15, nil, nil).
 		AddInputField("Username", srv.User, 15, nil, nil).
 		AddInputField("Password", pass, 15, nil, nil).
+		AddPasswordField("TOTP secret", srv.DecryptTOTP(), 15, '*', nil).
 		AddInputField("Port", srv.Port, 15, nil, nil).
 		AddInputField("Description", srv.Description, 60, nil, nil).
+		AddInputField("Group", srv.Group, 30, nil, nil).
 		AddDropDown("Type", ServerTypes, idx, nil). // declared in servers_yml.go
 		AddButton("Save", func() {
 			form.GetFormItemByLabel("File").(*tview.InputField).SetDisabled(true)
 			hostname := form.GetFormItemByLabel("Hostname").(*tview.InputField).GetText()
 			username := form.GetFormItemByLabel("Username").(*tview.InputField).GetText()
 			passw := form.GetFormItemByLabel("Password").(*tview.InputField).GetText()
+			totp := form.GetFormItemByLabel("TOTP secret").(*tview.InputField).GetText()
+			if !validTOTPSecret(form, totp) {
+				return
+			}
 			ip := form.GetFormItemByLabel("IP Address").(*tview.InputField).GetText()
 			port := form.GetFormItemByLabel("Port").(*tview.InputField).GetText()
 			desc := form.GetFormItemByLabel("Description").(*tview.InputField).GetText()
+			group := cleanGroup(form.GetFormItemByLabel("Group").(*tview.InputField).GetText())
 			typeIndex, _ := form.GetFormItemByLabel("Type").(*tview.DropDown).GetCurrentOption()
 			serverType := ServerTypes[typeIndex]
 			srv.Host = hostname
 			srv.User = username
 			srv.Password = srv.EncryptPassword(passw)
+			srv.TOTP = srv.EncryptTOTP(totp)
 			srv.IP = ip
 			srv.Port = port
 			srv.Description = desc
+			srv.Group = group
 			srv.Type = serverType
 			servers[srvIdx] = srv
 
 			pushServersToFile()
 			fetchServersFromFiles()
-			updateTable()
+			fuzzySearch(searchBox.GetText())
 			returnToMainWindow()
 		}).
 		AddButton("Cancel", func() { returnToMainWindow() })
@@ -543,4 +724,18 @@ func updateTable() {
 				SetAlign(tview.AlignLeft))
 		}
 	}
+	updateStatusLine()
+}
+
+// updateStatusLine shows number of the servers and problems of the servers files
+func updateStatusLine() {
+	text := fmt.Sprintf(" %d servers, h for help", len(servers))
+	if tuiGroup != "" {
+		text = fmt.Sprintf(" %d of %d servers in [::b]%s[::-], g for groups", len(filteredServers), len(servers), tuiGroup)
+	}
+	if issues := LintServers(ymlfiles); len(issues) > 0 {
+		errors, warnings := lintCounts(issues)
+		text += fmt.Sprintf(" | [yellow]⚠ %d errors, %d warnings in the servers files, run conan lint[-]", errors, warnings)
+	}
+	statusLine.SetText(text)
 }
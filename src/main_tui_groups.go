@@ -0,0 +1,101 @@
+package main
+
+/* TUI group navigator
+Tree of the servers files and their groups, selecting the group shows only its servers
+(with the servers of the subgroups) in the servers table.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+var tuiGroup string // group path selected in the group navigator, empty shows all servers
+
+// serverTreePath returns the path of the server in the group tree, e.g. servers/DC1/Rack4
+func serverTreePath(srv Server) string {
+	return strings.Join(append([]string{serverFileName(srv)}, srv.GroupPath()...), groupSeparator)
+}
+
+// tuiGroupServers returns servers of the selected group and its subgroups
+func tuiGroupServers() []Server {
+	if tuiGroup == "" {
+		return servers
+	}
+	var list []Server
+	for _, srv := range servers {
+		if p := serverTreePath(srv); p == tuiGroup || strings.HasPrefix(p, tuiGroup+groupSeparator) {
+			list = append(list, srv)
+		}
+	}
+	return list
+}
+
+// showGroupNavigator shows the group tree, Enter selects the group, Esc or q returns back
+func showGroupNavigator() {
+	tree := tview.NewTreeView()
+	tree.SetBorder(true).SetTitle(" [::b]Groups[::-] ")
+
+	root := tview.NewTreeNode(fmt.Sprintf("All servers (%d)", len(servers))).
+		SetReference("").
+		SetColor(theme["hostname_color"])
+	current := root
+	var addGroups func(parent *tview.TreeNode, g *ServerGroup)
+	addGroups = func(parent *tview.TreeNode, g *ServerGroup) {
+		for _, sub := range g.Groups {
+			node := tview.NewTreeNode(fmt.Sprintf("📁 %s (%d)", sub.Name, sub.Count())).
+				SetReference(sub.Path)
+			if sub.Path == tuiGroup {
+				current = node
+			}
+			parent.AddChild(node)
+			addGroups(node, sub)
+		}
+	}
+	addGroups(root, serverGroupTree(servers))
+	tree.SetRoot(root).SetCurrentNode(current)
+
+	tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		tuiGroup, _ = node.GetReference().(string)
+		fuzzySearch(searchBox.GetText())
+		returnToMainWindow()
+	})
+	tree.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			returnToMainWindow()
+			return nil
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'q':
+				returnToMainWindow()
+				return nil
+			case ' ':
+				if node := tree.GetCurrentNode(); node != nil {
+					node.SetExpanded(!node.IsExpanded())
+				}
+				return nil
+			}
+		}
+		return event
+	})
+
+	help := tview.NewTextView().SetDynamicColors(true).
+		SetText(" [yellow]Enter[-] show servers of the group  [yellow]Space[-] collapse/expand  [yellow]Esc/q[-] back")
+	page := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tree, 0, 1, true).
+		AddItem(help, 1, 0, false)
+	appbase.SetRoot(page, true).SetFocus(tree)
+}
+
+// tuiGroupOf returns the group of the group path without the servers file, e.g. DC1/Rack4 of servers/DC1/Rack4
+func tuiGroupOf(path string) string {
+	if i := strings.Index(path, groupSeparator); i >= 0 {
+		return path[i+1:]
+	}
+	return ""
+}
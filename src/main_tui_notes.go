@@ -0,0 +1,412 @@
+package main
+
+/* Notes browser of the terminal UI
+Tree of all notes folders on the left, note rendered from markdown to tview styled text on the right.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// tuiNoteRef is the reference stored in the notes tree nodes
+type tuiNoteRef struct {
+	service *NoteService
+	rel     string // empty for the notes folder root
+	dir     bool
+}
+
+var (
+	notesPage    *tview.Flex
+	notesTree    *tview.TreeView
+	notesViewer  *tview.TextView
+	notesCurrent *tuiNoteRef
+)
+
+// showNotesBrowser replaces the server table with the notes browser, Esc or q returns back
+func showNotesBrowser() {
+	notesTree = tview.NewTreeView()
+	notesViewer = tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(true).
+		SetWordWrap(true)
+	notesViewer.SetBorder(true).SetTitle(" Note ")
+	notesTree.SetBorder(true).SetTitle(" [::b]Notes[::-] ")
+
+	notesTree.SetChangedFunc(func(node *tview.TreeNode) {
+		if ref, ok := node.GetReference().(*tuiNoteRef); ok && !ref.dir {
+			tuiPreviewNote(ref)
+		}
+	})
+	notesTree.SetSelectedFunc(func(node *tview.TreeNode) {
+		ref, ok := node.GetReference().(*tuiNoteRef)
+		if !ok {
+			return
+		}
+		if ref.dir {
+			node.SetExpanded(!node.IsExpanded())
+			return
+		}
+		tuiPreviewNote(ref)
+		appbase.SetFocus(notesViewer)
+	})
+	notesTree.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			returnToMainWindow()
+			return nil
+		case tcell.KeyTab:
+			appbase.SetFocus(notesViewer)
+			return nil
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'q':
+				returnToMainWindow()
+				return nil
+			case 'e':
+				tuiEditSelectedNote()
+				return nil
+			case 'n':
+				tuiNewNote()
+				return nil
+			case 'd':
+				tuiDeleteSelectedNote()
+				return nil
+			case 'r':
+				tuiReloadNotesTree()
+				return nil
+			}
+		}
+		return event
+	})
+	notesViewer.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape, tcell.KeyTab, tcell.KeyBacktab:
+			appbase.SetFocus(notesTree)
+			return nil
+		case tcell.KeyRune:
+			if event.Rune() == 'e' {
+				tuiEditSelectedNote()
+				return nil
+			}
+		}
+		return event
+	})
+
+	help := tview.NewTextView().SetDynamicColors(true).
+		SetText(" [yellow]Enter[-] open  [yellow]Tab[-] switch pane  [yellow]e[-] edit  [yellow]n[-] new  [yellow]d[-] delete  [yellow]r[-] reload  [yellow]Esc/q[-] back")
+
+	body := tview.NewFlex().
+		AddItem(notesTree, 0, 1, true).
+		AddItem(notesViewer, 0, 3, false)
+	notesPage = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(body, 0, 1, true).
+		AddItem(help, 1, 0, false)
+
+	tuiReloadNotesTree()
+	appbase.SetRoot(notesPage, true).SetFocus(notesTree)
+}
+
+// tuiReloadNotesTree rebuilds the tree keeping the current note selected
+func tuiReloadNotesTree() {
+	root := tview.NewTreeNode("Notes")
+	var current *tview.TreeNode
+	for _, item := range ymlfiles {
+		s := newNoteServiceFor(item)
+		if s == nil {
+			continue
+		}
+		folder := tview.NewTreeNode(filepath.Base(s.NotesDir)).
+			SetReference(&tuiNoteRef{service: s, dir: true}).
+			SetColor(tcell.ColorYellow)
+		root.AddChild(folder)
+		treeData, isBranch, err := s.ListTree()
+		if err != nil {
+			continue
+		}
+		if n := tuiAddNoteNodes(folder, s, "", treeData, isBranch); n != nil {
+			current = n
+		}
+	}
+	notesTree.SetRoot(root).SetTopLevel(1)
+	if current != nil {
+		notesTree.SetCurrentNode(current)
+		tuiPreviewNote(current.GetReference().(*tuiNoteRef))
+	} else if children := root.GetChildren(); len(children) > 0 {
+		notesTree.SetCurrentNode(children[0])
+		notesViewer.SetText("")
+	}
+}
+
+// tuiAddNoteNodes adds children of the path, returns the node of the currently shown note if found
+func tuiAddNoteNodes(parent *tview.TreeNode, s *NoteService, path string, treeData map[string][]string, isBranch map[string]bool) *tview.TreeNode {
+	var current *tview.TreeNode
+	children := append([]string{}, treeData[path]...)
+	// folders first, then notes, both alphabetically
+	sort.Slice(children, func(i, j int) bool {
+		if isBranch[children[i]] != isBranch[children[j]] {
+			return isBranch[children[i]]
+		}
+		return children[i] < children[j]
+	})
+	for _, rel := range children {
+		name := filepath.Base(rel)
+		if isBranch[rel] {
+			node := tview.NewTreeNode("📁 " + name).
+				SetReference(&tuiNoteRef{service: s, rel: rel, dir: true}).
+				SetExpanded(false)
+			parent.AddChild(node)
+			if n := tuiAddNoteNodes(node, s, rel, treeData, isBranch); n != nil {
+				node.SetExpanded(true)
+				current = n
+			}
+			continue
+		}
+		if !strings.HasSuffix(name, ".md") {
+			continue
+		}
+		node := tview.NewTreeNode(strings.TrimSuffix(name, ".md")).
+			SetReference(&tuiNoteRef{service: s, rel: rel})
+		parent.AddChild(node)
+		if notesCurrent != nil && notesCurrent.service.NotesDir == s.NotesDir && notesCurrent.rel == rel {
+			current = node
+		}
+	}
+	return current
+}
+
+// tuiSelectedNote returns the reference of the selected tree node
+func tuiSelectedNote() *tuiNoteRef {
+	node := notesTree.GetCurrentNode()
+	if node == nil {
+		return nil
+	}
+	ref, _ := node.GetReference().(*tuiNoteRef)
+	return ref
+}
+
+func tuiPreviewNote(ref *tuiNoteRef) {
+	n, err := ref.service.Load(ref.rel)
+	if err != nil {
+		notesViewer.SetText("[red]Unable to load note: " + tview.Escape(err.Error()))
+		return
+	}
+	notesCurrent = ref
+	title := n.Meta.Title
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(ref.rel), ".md")
+	}
+	notesViewer.SetTitle(" " + tview.Escape(title) + " ")
+	var header strings.Builder
+	if len(n.Meta.Tags) > 0 {
+		header.WriteString("[gray]Tags: " + tview.Escape(strings.Join(n.Meta.Tags, ", ")) + "[-]\n")
+	}
+	if len(n.Meta.Servers) > 0 {
+		header.WriteString("[gray]Servers: " + tview.Escape(strings.Join(n.Meta.Servers, ", ")) + "[-]\n")
+	}
+	if !n.Meta.Updated.IsZero() {
+		header.WriteString("[gray]Updated: " + n.Meta.Updated.Local().Format("2006-01-02 15:04") + "[-]\n")
+	}
+	if header.Len() > 0 {
+		header.WriteString("\n")
+	}
+	notesViewer.SetText(header.String() + markdownToTview(string(n.Body)))
+	notesViewer.ScrollToBeginning()
+}
+
+// tuiEditSelectedNote suspends the TUI and opens the note in $EDITOR
+func tuiEditSelectedNote() {
+	ref := tuiSelectedNote()
+	if ref == nil || ref.dir {
+		return
+	}
+	var err error
+	appbase.Suspend(func() {
+		_, err = editNoteExternal(ref.service, ref.rel)
+	})
+	if err != nil {
+		tuiNotesMessage("Error", err.Error())
+		return
+	}
+	tuiPreviewNote(ref)
+}
+
+// tuiNewNote asks for name and creates the note in the selected folder
+func tuiNewNote() {
+	ref := tuiSelectedNote()
+	if ref == nil {
+		return
+	}
+	parent := ref.rel
+	if !ref.dir {
+		parent = filepath.Dir(ref.rel)
+		if parent == "." {
+			parent = ""
+		}
+	}
+	form := tview.NewForm()
+	form.AddInputField("Name", "", 30, nil, nil).
+		AddButton("Create", func() {
+			name := strings.TrimSuffix(strings.TrimSpace(form.GetFormItemByLabel("Name").(*tview.InputField).GetText()), ".md")
+			if name == "" || strings.ContainsAny(name, `/\`) {
+				tuiNotesMessage("Error", "Invalid note name")
+				return
+			}
+			if err := os.MkdirAll(ref.service.NotesDir, 0755); err != nil {
+				tuiNotesMessage("Error", err.Error())
+				return
+			}
+			if err := ref.service.NewNote(parent, name); err != nil {
+				tuiNotesMessage("Error", err.Error())
+				return
+			}
+			notesCurrent = &tuiNoteRef{service: ref.service, rel: filepath.Join(parent, name+".md")}
+			appbase.SetRoot(notesPage, true).SetFocus(notesTree)
+			tuiReloadNotesTree()
+			tuiEditSelectedNote()
+		}).
+		AddButton("Cancel", func() {
+			appbase.SetRoot(notesPage, true).SetFocus(notesTree)
+		})
+	form.SetBorder(true).SetTitle(" New note in " + tview.Escape(filepath.Join(filepath.Base(ref.service.NotesDir), parent)) + " ").SetTitleAlign(tview.AlignCenter)
+	appbase.SetRoot(form, true)
+}
+
+func tuiDeleteSelectedNote() {
+	ref := tuiSelectedNote()
+	if ref == nil || ref.dir {
+		return
+	}
+	modal := tview.NewModal().
+		SetText("Are you sure you want to delete " + strings.TrimSuffix(ref.rel, ".md") + " note?").
+		AddButtons([]string{"Yes", "No"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			appbase.SetRoot(notesPage, true).SetFocus(notesTree)
+			if buttonLabel != "Yes" {
+				return
+			}
+			if err := ref.service.DeleteNote(ref.rel); err != nil {
+				tuiNotesMessage("Error", err.Error())
+				return
+			}
+			notesCurrent = nil
+			notesViewer.SetText("").SetTitle(" Note ")
+			tuiReloadNotesTree()
+		})
+	appbase.SetRoot(modal, false)
+}
+
+// tuiNotesMessage is ShowMessageBox that returns to the notes browser
+func tuiNotesMessage(title, message string) {
+	modal := tview.NewModal().
+		SetText(message).
+		AddButtons([]string{"OK"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			appbase.SetRoot(notesPage, true).SetFocus(notesTree)
+		})
+	modal.SetTitle(title).SetBorder(true)
+	appbase.SetRoot(modal, false).SetFocus(modal)
+}
+
+var (
+	mdHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdList     = regexp.MustCompile(`^(\s*)([-*+]|\d+\.)\s+(.*)$`)
+	mdCheckbox = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	mdRule     = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	// inline elements: code, bold, italic and links
+	mdInline = regexp.MustCompile("`([^`]+)`|\\*\\*([^*]+)\\*\\*|__([^_]+)__|\\*([^*\\s][^*]*)\\*|\\b_([^_\\s][^_]*)_\\b|\\[([^\\]]+)\\]\\(([^)\\s]+)\\)")
+)
+
+// markdownToTview converts markdown to tview color tags, text is escaped so brackets in notes
+// are never interpreted as tags
+func markdownToTview(md string) string {
+	var out strings.Builder
+	inCode := false
+	for _, line := range strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			out.WriteString("[green]  " + tview.Escape(line) + "[-]\n")
+			continue
+		}
+		if m := mdHeading.FindStringSubmatch(trimmed); m != nil {
+			color := "yellow"
+			if len(m[1]) > 1 {
+				color = "aqua"
+			}
+			out.WriteString(fmt.Sprintf("[%s::b]%s[-::-]\n", color, markdownInline(m[2])))
+			continue
+		}
+		if mdRule.MatchString(line) {
+			out.WriteString("[gray]" + strings.Repeat("─", 40) + "[-]\n")
+			continue
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			out.WriteString("[gray]│ [::i]" + markdownInline(strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))) + "[-::-]\n")
+			continue
+		}
+		if m := mdList.FindStringSubmatch(line); m != nil {
+			bullet := "•"
+			if m[2] != "-" && m[2] != "*" && m[2] != "+" {
+				bullet = m[2]
+			}
+			text := m[3]
+			if c := mdCheckbox.FindStringSubmatch(text); c != nil {
+				if c[1] == " " {
+					bullet, text = "☐", markdownInline(c[2])
+				} else {
+					bullet, text = "[green]☑[-]", "[::s]"+markdownInline(c[2])+"[::-]"
+				}
+			} else {
+				text = markdownInline(text)
+			}
+			out.WriteString(m[1] + " " + bullet + " " + text + "\n")
+			continue
+		}
+		out.WriteString(markdownInline(line) + "\n")
+	}
+	return strings.TrimRight(out.String(), "\n")
+}
+
+// markdownInline renders inline markdown of a single line
+func markdownInline(text string) string {
+	var out strings.Builder
+	last := 0
+	for _, m := range mdInline.FindAllStringSubmatchIndex(text, -1) {
+		out.WriteString(tview.Escape(text[last:m[0]]))
+		group := func(i int) string { return text[m[2*i]:m[2*i+1]] }
+		switch {
+		case m[2] >= 0:
+			out.WriteString("[green]" + tview.Escape(group(1)) + "[-]")
+		case m[4] >= 0:
+			out.WriteString("[::b]" + tview.Escape(group(2)) + "[::-]")
+		case m[6] >= 0:
+			out.WriteString("[::b]" + tview.Escape(group(3)) + "[::-]")
+		case m[8] >= 0:
+			out.WriteString("[::i]" + tview.Escape(group(4)) + "[::-]")
+		case m[10] >= 0:
+			out.WriteString("[::i]" + tview.Escape(group(5)) + "[::-]")
+		case m[12] >= 0 && strings.HasPrefix(group(7), attachmentScheme+":"):
+			// attachments can't be shown in the terminal
+			name := strings.TrimSuffix(out.String(), "!")
+			out.Reset()
+			out.WriteString(name + "[yellow]📎 " + tview.Escape(group(6)) + "[-]")
+		case m[12] >= 0:
+			out.WriteString("[blue::u]" + tview.Escape(group(6)) + "[-::-] [gray](" + tview.Escape(group(7)) + ")[-]")
+		}
+		last = m[1]
+	}
+	out.WriteString(tview.Escape(text[last:]))
+	return out.String()
+}
@@ -0,0 +1,162 @@
+package main
+
+/* Notes attachments
+Files attached to the note (pasted screenshots, dropped configs, diagrams) are kept in
+.attachments/<note>/<name> next to the history and encrypted with the same rules as notes.
+Markdown refers to them as attachment:<name>, exports use attachment:<note>/<name>.
+In the gist they are stored as base64 entries (.attachments__<note>__<name>.b64).
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	attachmentsDir       = ".attachments"
+	attachmentScheme     = "attachment"
+	attachmentGistSuffix = ".b64"
+	// gist is not a file storage, keep attachments reasonably small
+	maxAttachmentSize = 10 * 1024 * 1024
+)
+
+var attachmentNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// attachmentDir returns folder with attachments of the note
+func (s *NoteService) attachmentDir(noteID string) string {
+	return filepath.Join(s.NotesDir, attachmentsDir, noteID)
+}
+
+// cleanAttachmentName makes file name safe for the file system and markdown links
+func cleanAttachmentName(name string) string {
+	name = attachmentNameRe.ReplaceAllString(filepath.Base(name), "_")
+	name = strings.Trim(name, "._")
+	if name == "" {
+		name = "attachment"
+	}
+	return name
+}
+
+// AddAttachment stores data as attachment of the note, returns the stored (unique) name
+func (s *NoteService) AddAttachment(noteID, name string, data []byte) (string, error) {
+	if len(data) > maxAttachmentSize {
+		return "", fmt.Errorf("attachment %s is too big (%d bytes, max %d)", name, len(data), maxAttachmentSize)
+	}
+	dir := s.attachmentDir(noteID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	name = cleanAttachmentName(name)
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) {
+			break
+		}
+		name = stem + "-" + strconv.Itoa(i) + ext
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), s.maybeEncrypt(data), 0644); err != nil {
+		return "", err
+	}
+	notesLog.Infof("Attached %s to %s (%d bytes)", name, noteID, len(data))
+	return name, nil
+}
+
+// ReadAttachment returns decrypted attachment of the note
+func (s *NoteService) ReadAttachment(noteID, name string) ([]byte, error) {
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid attachment name %s", name)
+	}
+	data, err := os.ReadFile(filepath.Join(s.attachmentDir(noteID), name))
+	if err != nil {
+		return nil, err
+	}
+	return s.maybeDecrypt(data), nil
+}
+
+// ListAttachments returns names of the note attachments
+func (s *NoteService) ListAttachments(noteID string) ([]string, error) {
+	entries, err := os.ReadDir(s.attachmentDir(noteID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DeleteAttachments removes all attachments of the note
+func (s *NoteService) DeleteAttachments(noteID string) error {
+	return os.RemoveAll(s.attachmentDir(noteID))
+}
+
+// attachmentLink returns markdown link to the attachment, images are rendered inline
+func attachmentLink(name string) string {
+	target := attachmentScheme + ":" + url.PathEscape(name)
+	if isImageName(name) {
+		return "![" + name + "](" + target + ")"
+	}
+	return "[" + name + "](" + target + ")"
+}
+
+// resolveAttachment returns note and name of the attachment link, links without
+// the note path belong to the current note
+func resolveAttachment(current, target string) (noteID, name string, ok bool) {
+	rest, found := strings.CutPrefix(target, attachmentScheme+":")
+	if !found {
+		return "", "", false
+	}
+	if u, err := url.PathUnescape(rest); err == nil {
+		rest = u
+	}
+	noteID, name = path.Split(rest)
+	noteID = strings.TrimSuffix(noteID, "/")
+	if noteID == "" {
+		noteID = current
+	} else {
+		noteID = filepath.FromSlash(noteID)
+	}
+	if strings.HasPrefix(filepath.Clean(noteID), "..") {
+		return "", "", false
+	}
+	return noteID, name, name != "" && noteID != ""
+}
+
+// qualifiedAttachmentURL returns attachment url including the note path, used when notes are combined
+func qualifiedAttachmentURL(noteID, name string) string {
+	parts := strings.Split(filepath.ToSlash(noteID), "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return attachmentScheme + ":" + strings.Join(parts, "/") + "/" + url.PathEscape(name)
+}
+
+// isImageName returns true if the attachment can be shown inline
+func isImageName(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".svg", ".webp":
+		return true
+	}
+	return false
+}
+
+// isAttachmentPath returns true if the path relative to the notes folder is an attachment
+func isAttachmentPath(rel string) bool {
+	return strings.HasPrefix(rel, attachmentsDir+string(os.PathSeparator))
+}
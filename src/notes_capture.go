@@ -0,0 +1,63 @@
+package main
+
+/* Quick capture
+Short notes captured with the global hotkey (ctrl+shift+space), the tray menu or
+`conan notes capture` are appended to the inbox note as timestamped entries.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const defaultInboxNote = "inbox"
+
+// inboxNoteID returns note id of the inbox note
+func inboxNoteID() string {
+	name := strings.TrimSpace(settings.NotesSettings.Inbox)
+	if name == "" {
+		name = defaultInboxNote
+	}
+	id, err := normalizeNoteID(name)
+	if err != nil {
+		notesLog.Warnf("Inbox note: %s, using %s", err, defaultInboxNote)
+		return defaultInboxNote + ".md"
+	}
+	return id
+}
+
+// Capture appends timestamped entry to the inbox note, the note is created when it does not exist
+func (s *NoteService) Capture(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("nothing to capture")
+	}
+	id := inboxNoteID()
+	if strings.HasPrefix(id, "..") {
+		return "", fmt.Errorf("invalid inbox note %s", id)
+	}
+	if _, err := os.Stat(filepath.Join(s.NotesDir, id)); os.IsNotExist(err) {
+		parent, name := filepath.Split(id)
+		if err := os.MkdirAll(filepath.Join(s.NotesDir, parent), 0755); err != nil {
+			return "", err
+		}
+		if err := s.NewNote(strings.TrimSuffix(parent, string(os.PathSeparator)), strings.TrimSuffix(name, ".md")); err != nil {
+			return "", err
+		}
+	}
+	n, err := s.Load(id)
+	if err != nil {
+		return "", err
+	}
+	entry := fmt.Sprintf("\n## %s\n\n%s\n", time.Now().Format("2006-01-02 15:04"), text)
+	n.Body = append([]byte(strings.TrimRight(string(n.Body), "\n")+"\n"), entry...)
+	if err := s.Save(n); err != nil {
+		return "", err
+	}
+	notesLog.Infof("Captured %d characters to %s", len(text), id)
+	return id, nil
+}
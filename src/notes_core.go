@@ -7,10 +7,11 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"io/fs"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -61,6 +62,21 @@ type NoteMeta struct {
 	Tags      []string  `yaml:"tags"`
 	Sticky    bool      `yaml:"sticky"`
 	Color     string    `yaml:"color"`
+	Servers   []string  `yaml:"servers,omitempty"` // linked servers: host, ip or stable id
+}
+
+// newNoteServiceFor returns notes service of the servers file, notes are kept in <config dir>/<file>-notes
+func newNoteServiceFor(ymlfile string) *NoteService {
+	fname := trimYML(filepath.Base(ymlfile))
+	// servers of the dynamic inventory have no notes folder
+	if fname == "" || strings.HasPrefix(ymlfile, inventorySourcePrefix) {
+		return nil
+	}
+	return &NoteService{
+		NotesDir:   filepath.Join(env.configDir, fname+"-notes"),
+		HistoryDir: ".history",
+		Gist:       findGist(filepath.Base(ymlfile)),
+	}
 }
 
 // Load reads, decrypts (if enabled), parses front-matter & history
@@ -82,7 +98,8 @@ func (s *NoteService) Load(relID string) (*Note, error) {
 			continue
 		}
 		name := fi.Name()
-		if ts, err := time.Parse("20060102-150405", strings.TrimSuffix(name, ".md")); err == nil {
+		// snapshot names are in local time
+		if ts, err := time.ParseInLocation("20060102-150405", strings.TrimSuffix(name, ".md"), time.Local); err == nil {
 			snaps = append(snaps, Snapshot{Timestamp: ts, Path: filepath.Join(hDir, name)})
 		}
 	}
@@ -120,6 +137,8 @@ func (s *NoteService) Save(n *Note) error {
 	if err := ioutil.WriteFile(filepath.Join(snapDir, ts), histout, 0644); err != nil {
 		return err
 	}
+	n.History = append(n.History, Snapshot{Timestamp: time.Now(), Path: filepath.Join(snapDir, ts)})
+	s.PruneHistory(n)
 
 	// update metadata timestamp
 	n.Meta.Updated = time.Now().UTC()
@@ -135,6 +154,7 @@ func (s *NoteService) Save(n *Note) error {
 	}
 	// update raw
 	n.Raw = out
+	s.reindexNote(n)
 	return nil
 }
 
@@ -153,8 +173,8 @@ func (s *NoteService) ListTree() (map[string][]string, map[string]bool, error) {
 		if err != nil || rel == "." {
 			return nil
 		}
-		// skip history
-		if rel == s.HistoryDir || strings.HasPrefix(rel, s.HistoryDir+string(os.PathSeparator)) {
+		// skip history, attachments and other hidden files (sync manifest)
+		if strings.HasPrefix(d.Name(), ".") {
 			if d.IsDir() {
 				return filepath.SkipDir
 			}
@@ -171,23 +191,62 @@ func (s *NoteService) ListTree() (map[string][]string, map[string]bool, error) {
 	return treeData, isBranch, err
 }
 
+// ListNotes returns relative paths of all notes, skipping history and other hidden folders
+func (s *NoteService) ListNotes() ([]string, error) {
+	var ids []string
+	err := filepath.WalkDir(s.NotesDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(s.NotesDir, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(rel, ".md") {
+			ids = append(ids, rel)
+		}
+		return nil
+	})
+	return ids, err
+}
+
 // Create a new note file under parentRel
 func (s *NoteService) NewNote(parentRel, name string) error {
+	now := time.Now().UTC()
+	meta := NoteMeta{Title: name, Created: now, Updated: now}
+	body := []byte(fmt.Sprintf("# %s\n", name))
+	return s.writeNewNote(parentRel, name, meta, body)
+}
+
+// writeNewNote writes new note and adds it to the search index
+func (s *NoteService) writeNewNote(parentRel, name string, meta NoteMeta, body []byte) error {
 	parent := s.NotesDir
 	if parentRel != "" {
 		parent = filepath.Join(s.NotesDir, parentRel)
 	}
 	path := filepath.Join(parent, name+".md")
-	log.Printf("Doing note: %s\n", path)
-	now := time.Now().UTC()
-	meta := NoteMeta{Title: name, Created: now, Updated: now}
-	body := []byte(fmt.Sprintf("# %s\n", name))
+	notesLog.Debugf("Doing note: %s", path)
 	raw, err := renderYAMLFrontMatter(meta, body)
 	if err != nil {
 		return err
 	}
 	raw = s.maybeEncrypt(raw)
-	return ioutil.WriteFile(path, raw, 0644)
+	if err := ioutil.WriteFile(path, raw, 0644); err != nil {
+		return err
+	}
+	if s.cachedIndex() != nil {
+		rel, _ := filepath.Rel(s.NotesDir, path)
+		if note, err := s.Load(rel); err == nil {
+			s.reindexNote(note)
+		}
+	}
+	return nil
 }
 
 // Create a new folder under parentRel
@@ -209,15 +268,104 @@ func (s *NoteService) DeleteNote(relID string) error {
 	// remove history
 	hDir := filepath.Join(s.NotesDir, s.HistoryDir, relID)
 	os.RemoveAll(hDir)
+	s.DeleteAttachments(relID)
+	s.unindexNote(relID)
+	moveStickySections(relID, "")
+
+	return nil
+}
+
+// DeleteFolder removes folder with all notes, returns the removed notes
+func (s *NoteService) DeleteFolder(relDir string) ([]string, error) {
+	if relDir == "" || relDir == "." {
+		return nil, fmt.Errorf("refusing to delete the notes root folder")
+	}
+	if !insideNotesDir(relDir) {
+		return nil, fmt.Errorf("refusing to delete %s outside of the notes folder", relDir)
+	}
+	all, err := s.ListNotes()
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, id := range all {
+		if !strings.HasPrefix(id, relDir+string(os.PathSeparator)) {
+			continue
+		}
+		if err := s.DeleteNote(id); err != nil {
+			return removed, err
+		}
+		removed = append(removed, id)
+	}
+	if err := os.RemoveAll(filepath.Join(s.NotesDir, relDir)); err != nil {
+		return removed, err
+	}
+	os.RemoveAll(filepath.Join(s.NotesDir, s.HistoryDir, relDir))
+	os.RemoveAll(filepath.Join(s.NotesDir, attachmentsDir, relDir))
+	return removed, nil
+}
+
+// insideNotesDir returns true when the path relative to the notes folder does not leave it
+func insideNotesDir(rel string) bool {
+	rel = filepath.Clean(rel)
+	return !filepath.IsAbs(rel) && filepath.VolumeName(rel) == "" && rel != ".." &&
+		!strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
 
+// Move renames or moves note or folder together with history, attachments and sticky window geometry,
+// the next push removes the old files from the gist
+func (s *NoteService) Move(oldRel, newRel string) error {
+	if oldRel == "" || filepath.Clean(oldRel) == "." || !insideNotesDir(oldRel) {
+		return fmt.Errorf("invalid source %s", oldRel)
+	}
+	src := filepath.Join(s.NotesDir, oldRel)
+	fi, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() && !strings.HasSuffix(newRel, ".md") {
+		newRel += ".md"
+	}
+	dst := filepath.Join(s.NotesDir, newRel)
+	if rel, err := filepath.Rel(s.NotesDir, dst); err != nil || rel == "." || strings.HasPrefix(rel, "..") || strings.HasPrefix(rel, ".") {
+		return fmt.Errorf("invalid destination %s", newRel)
+	}
+	if fi.IsDir() && strings.HasPrefix(newRel+string(os.PathSeparator), oldRel+string(os.PathSeparator)) {
+		return fmt.Errorf("unable to move folder %s into itself", oldRel)
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("%s already exists", newRel)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return err
+	}
+	for _, dir := range []string{s.HistoryDir, attachmentsDir} {
+		from := filepath.Join(s.NotesDir, dir, oldRel)
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		to := filepath.Join(s.NotesDir, dir, newRel)
+		if err := os.MkdirAll(filepath.Dir(to), 0755); err == nil {
+			err = os.Rename(from, to)
+		}
+		if err != nil {
+			notesLog.Warnf("Unable to move %s of %s: %s", dir, oldRel, err)
+		}
+	}
+	moveStickySections(oldRel, newRel)
+	s.dropIndex()
+	notesLog.Infof("Moved %s to %s", oldRel, newRel)
 	return nil
 }
 
-// DeleteFromGist removes a single file (note) from the specified Gist.
-// - relID: relative path to note.
+// DeleteFromGist removes notes (and their attachments) from the specified Gist.
+// - relIDs: relative paths to notes.
 // Returns an error if anything goes wrong.
-func (s *NoteService) DeleteFromGist(relID string) error {
-	log.Printf("delete from gist: %s\n", relID)
+func (s *NoteService) DeleteFromGist(relIDs ...string) error {
+	notesLog.Infof("delete from gist: %s", strings.Join(relIDs, ", "))
 	ctx := context.Background()
 
 	// 1) Create an authenticated GitHub client
@@ -225,44 +373,44 @@ func (s *NoteService) DeleteFromGist(relID string) error {
 	tc := oauth2.NewClient(ctx, ts)
 	client := github.NewClient(tc)
 
-	safe := strings.ReplaceAll(relID, string(os.PathSeparator), "__")
-
-	// 2) Build a raw body that tells GitHub to delete our target file.
-	//    Notice filename → nil, which becomes JSON "filename": null
-	body := map[string]interface{}{
-		"files": map[string]interface{}{
-			safe: nil,
-		},
+	// 2) filename → nil, which becomes JSON "filename": null and tells GitHub to delete the file,
+	//    attachments synced with the note are known from the sync manifest
+	manifest := s.loadSyncManifest()
+	files := make(map[string]interface{})
+	for _, relID := range relIDs {
+		safe := gistName(relID)
+		files[safe] = nil
+		prefix := gistName(filepath.Join(attachmentsDir, relID)) + "__"
+		for name := range manifest.Files {
+			if strings.HasPrefix(name, prefix) {
+				files[name] = nil
+			}
+		}
 	}
 
 	// 3) Create and send the PATCH request ourselves
-	path := fmt.Sprintf("gists/%s", s.Gist.GistID)
-	req, err := client.NewRequest("PATCH", path, body)
-	if err != nil {
-		return fmt.Errorf("creating PATCH request: %s", err)
-	}
-	resp, err := client.Do(ctx, req, nil)
-	if err != nil {
-		return fmt.Errorf("sending PATCH request: %w", err)
+	if err := patchGist(ctx, client, s.Gist.GistID, "", files); err != nil {
+		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("GitHub API returned %s", resp.Status)
+	for name := range files {
+		delete(manifest.Files, name)
 	}
-	return nil
+	return s.saveSyncManifest(manifest)
 }
 
-// PushSync pushes all markdown notes under path to a Gist, preserving folder structure as encoded filenames.
-// Since Gists only support a flat file list, directory separators are encoded as "__".
+// PushSync pushes markdown notes and attachments changed since the last sync to a Gist, preserving folder structure
+// as encoded filenames. Since Gists only support a flat file list, directory separators are encoded as "__".
+// Files synced before and removed (or renamed) locally since then are removed from the Gist.
 func (s *NoteService) PushSync() error {
 	ctx := context.Background()
 	// OAuth2 token source
 	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: s.Gist.GistSec})
 	tc := oauth2.NewClient(ctx, ts)
 	client := github.NewClient(tc)
+	manifest := s.loadSyncManifest()
 	// collect files
-	filesMap := make(map[github.GistFilename]github.GistFile)
+	local := make(map[string]string)
+	filesMap := make(map[string]interface{})
 	err := filepath.Walk(s.NotesDir, func(full string, info os.FileInfo, err error) error {
 		if err != nil || info.IsDir() {
 			return err
@@ -272,73 +420,95 @@ func (s *NoteService) PushSync() error {
 			return err
 		}
 
-		// FIXME skip .history this time.. just for testing...
 		// If any part of the path contains .history, skip it
 		if strings.Contains(rel, string(os.PathSeparator)+s.HistoryDir+string(os.PathSeparator)) ||
 			strings.HasPrefix(rel, ".history"+string(os.PathSeparator)) ||
 			rel == ".history" {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
 			return nil
 		}
 
-		// only .md files
-		if !strings.HasSuffix(rel, ".md") {
+		// only .md files and attachments
+		attachment := isAttachmentPath(rel)
+		if !attachment && !strings.HasSuffix(rel, ".md") {
 			return err
 		}
 		data, err := ioutil.ReadFile(full)
 		if err != nil {
 			return err
 		}
+		safe := gistName(rel)
+		local[safe] = contentHash(data)
+		// unchanged since the last sync
+		if manifest.Files[safe] == local[safe] {
+			return nil
+		}
 		contentStr := string(data)
+		// gist files are text only
+		if attachment {
+			contentStr = base64.StdEncoding.EncodeToString(data)
+		}
 		// encrypt if key provided
 		if s.Gist.EncKey != "" {
 			enc, err := encryptAES(contentStr, s.Gist.EncKey)
 			if err != nil {
-				log.Printf("Unable to encrypt note: %s err: %s\n", full, err)
+				notesLog.Errorf("Unable to encrypt note: %s err: %s", full, err)
 			}
 			if err == nil {
 				contentStr = enc
 			}
 		}
-		// encode path separators so filenames remain unique
-		safe := strings.ReplaceAll(rel, string(os.PathSeparator), "__")
-		filesMap[github.GistFilename(safe)] = github.GistFile{
-			Content: github.String(string(contentStr)),
-		}
+		filesMap[safe] = map[string]string{"content": contentStr}
 		return nil
 	})
 	if err != nil {
 		return err
 	}
 
-	opt := &github.Gist{
-		Files:       filesMap,
-		Public:      github.Bool(false),
-		Description: github.String("Notes sync for " + s.NotesDir),
-	}
-
-	var gist *github.Gist
-	if s.Gist.GistID == "" {
+	gistID := s.Gist.GistID
+	if gistID == "" {
+		opt := &github.Gist{
+			Files:       make(map[github.GistFilename]github.GistFile),
+			Public:      github.Bool(false),
+			Description: github.String("Notes sync for " + s.NotesDir),
+		}
+		for name, f := range filesMap {
+			opt.Files[github.GistFilename(name)] = github.GistFile{Content: github.String(f.(map[string]string)["content"])}
+		}
 		created, _, err := client.Gists.Create(ctx, opt)
 		if err != nil {
 			return err
 		}
-		gist = created
+		gistID = created.GetID()
 	} else {
-		updated, _, err := client.Gists.Edit(ctx, s.Gist.GistID, opt)
-		if err != nil {
+		// deleted, renamed and moved notes
+		deleted := 0
+		for name := range manifest.Files {
+			if _, ok := local[name]; !ok {
+				filesMap[name] = nil
+				deleted++
+			}
+		}
+		if len(filesMap) == 0 {
+			notesLog.Infof("Nothing to push to gist %s", gistID)
+			return nil
+		}
+		if err := patchGist(ctx, client, gistID, "Notes sync for "+s.NotesDir, filesMap); err != nil {
 			return err
 		}
-		gist = updated
+		notesLog.Infof("Pushed %d changed and %d deleted files", len(filesMap)-deleted, deleted)
 	}
-	// optionally store new ID back into cfg
-	log.Println("Push Synced Gist ID:", gist.GetID())
+	manifest.GistID = gistID
+	manifest.Files = local
+	if err := s.saveSyncManifest(manifest); err != nil {
+		notesLog.Warnf("Unable to save sync manifest: %s", err)
+	}
+	auditEvent(AuditNotesPush, "", filepath.Base(s.NotesDir), gistID)
+	notesLog.Infof("Push Synced Gist ID: %s", gistID)
 	return nil
 }
 
-// PullSync fetches all markdown files from the given Gist and writes them into path, decoding folder structure.
+// PullSync fetches all markdown files and attachments from the given Gist and writes them into path, decoding folder structure.
+// Files synced before and removed from the Gist since then are removed locally, unless they were changed locally.
 func (s *NoteService) PullSync() error {
 	fmt.Printf("Syncing pull notes: %s using %s\n", s.NotesDir, s.Gist)
 	ctx := context.Background()
@@ -351,38 +521,95 @@ func (s *NoteService) PullSync() error {
 	if err != nil {
 		return err
 	}
+	manifest := s.loadSyncManifest()
+	synced := make(map[string]string)
+	// unreadable files keep their last synced state, they are never treated as deleted in the gist
+	keep := func(safe string) {
+		if base, ok := manifest.Files[safe]; ok {
+			synced[safe] = base
+		}
+	}
 	// iterate through files
 	for name, gf := range gist.Files {
 		safe := string(name)
-		// skip non-.md files
-		if !strings.HasSuffix(safe, ".md") {
-			log.Printf("skipping invalid file in gist notes: %s\n", safe)
+		rel, attachment, ok := gistRel(safe)
+		if !ok {
+			notesLog.Warnf("skipping invalid file in gist notes: %s", safe)
+			keep(safe)
 			continue
 		}
-		rel := strings.ReplaceAll(safe, "__", string(os.PathSeparator))
 		fullPath := filepath.Join(s.NotesDir, rel)
-		dir := filepath.Dir(fullPath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return err
-		}
 		content := gf.GetContent()
+		// api returns only first megabyte of big files
+		if gf.GetSize() > len(content) && gf.GetRawURL() != "" {
+			if content, err = fetchGistRaw(tc, gf.GetRawURL()); err != nil {
+				return err
+			}
+		}
 		if s.Gist.EncKey != "" {
 			decr, err := decryptAES(content, s.Gist.EncKey)
 			if err != nil {
-				log.Printf("Error decrypting note: %s err: %s\n", safe, err)
+				notesLog.Errorf("Error decrypting note: %s err: %s", safe, err)
+				keep(safe)
 				continue
 			}
 			content = decr
 		}
 
-		if err := ioutil.WriteFile(fullPath, []byte(content), 0644); err != nil {
+		data := []byte(content)
+		if attachment {
+			if data, err = base64.StdEncoding.DecodeString(content); err != nil {
+				notesLog.Errorf("Error decoding attachment: %s err: %s", safe, err)
+				keep(safe)
+				continue
+			}
+		}
+		// changed, deleted or moved only locally since the last sync, it is pushed next time
+		if s.deletedLocally(rel, manifest.Files[safe], data) || s.keepLocalChanges(rel, attachment, manifest.Files[safe], data) {
+			synced[safe] = manifest.Files[safe]
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(fullPath, data, 0644); err != nil {
 			return err
 		}
+		synced[safe] = contentHash(data)
+	}
+	// files removed from the gist (deleted, renamed or moved on another computer)
+	for safe, hash := range manifest.Files {
+		if _, ok := synced[safe]; !ok {
+			s.removeDeletedRemotely(safe, hash)
+		}
+	}
+	manifest.GistID = gist.GetID()
+	manifest.Files = synced
+	if err := s.saveSyncManifest(manifest); err != nil {
+		notesLog.Warnf("Unable to save sync manifest: %s", err)
 	}
-	log.Println("Pull Synced Gist ID:", gist.GetID())
+	// pulled notes keep the encryption state of the computer that pushed them
+	s.verifyNotesEncryption()
+	s.dropIndex()
+	auditEvent(AuditNotesPull, "", filepath.Base(s.NotesDir), gist.GetID())
+	notesLog.Infof("Pull Synced Gist ID: %s", gist.GetID())
 	return nil
 }
 
+// fetchGistRaw downloads full content of the gist file
+func fetchGistRaw(client *http.Client, rawURL string) (string, error) {
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to download %s: %s", rawURL, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	return string(data), err
+}
+
 func (s *NoteService) maybeDecrypt(data []byte) []byte {
 	if s.Gist.EncKey != "" && isEncrypted(string(data)) {
 		if dec, err := decryptWithMagic(string(data), s.Gist.EncKey); err == nil {
@@ -0,0 +1,394 @@
+package main
+
+/* Notes export
+A single note or a whole folder can be exported to HTML (front-matter rendered as a table),
+PDF (printed from the same HTML by Qt) or zip of plain (decrypted) markdown files together
+with the local files and attachments they link to. Markdown is rendered by Qt the same way as in the notes window.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"archive/zip"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/printsupport"
+)
+
+// export formats
+const (
+	ExportHTML = "html"
+	ExportPDF  = "pdf"
+	ExportZip  = "zip"
+)
+
+var (
+	// markdown links and images: [text](target "title") or ![alt](target)
+	mdLinkRe = regexp.MustCompile(`(!?\[[^\]]*\]\()([^)\s]+)((?:\s+"[^"]*")?\))`)
+	// images with local files or attachments in the rendered html
+	htmlImgRe = regexp.MustCompile(`(<img[^>]*\ssrc=")((?:file://|attachment:)[^"]+)(")`)
+)
+
+const exportCSS = `
+body { font-family: sans-serif; font-size: 11pt; color: #222; max-width: 900px; margin: 2em auto; }
+table.meta { border-collapse: collapse; margin-bottom: 1.5em; font-size: 9pt; }
+table.meta td { border: 1px solid #ccc; padding: 3px 8px; }
+table.meta td.key { background: #f2f2f2; font-weight: bold; }
+pre, code { font-family: monospace; background: #f6f6f6; }
+.note-title { border-bottom: 1px solid #ccc; }
+`
+
+// ensureQtApp creates Qt application for rendering when running from the command line
+func ensureQtApp() {
+	if qtapp != nil {
+		return
+	}
+	// no display, render offscreen (e.g. over ssh)
+	if runtime.GOOS == "linux" && os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" && os.Getenv("QT_QPA_PLATFORM") == "" {
+		os.Setenv("QT_QPA_PLATFORM", "offscreen")
+	}
+	qtapp = qt.NewQApplication(os.Args)
+}
+
+// exportIDs returns notes to export, rel can be a note, a folder or empty for all notes
+func (s *NoteService) exportIDs(rel string) ([]string, error) {
+	full := filepath.Join(s.NotesDir, rel)
+	fi, err := os.Stat(full)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{rel}, nil
+	}
+	all, err := s.ListNotes()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, id := range all {
+		if rel == "" || strings.HasPrefix(id, rel+string(os.PathSeparator)) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("folder %s has no notes", filepath.ToSlash(rel))
+	}
+	return ids, nil
+}
+
+// Export writes note or folder (rel) to the out file in the given format
+func (s *NoteService) Export(rel, format, out string) error {
+	ids, err := s.exportIDs(rel)
+	if err != nil {
+		return err
+	}
+	title := strings.TrimSuffix(filepath.Base(rel), ".md")
+	if rel == "" {
+		title = filepath.Base(s.NotesDir)
+	}
+	switch format {
+	case ExportHTML:
+		ensureQtApp()
+		page, err := s.exportHTML(ids, title)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(out, []byte(s.inlineImages(page)), 0600)
+		if err != nil {
+			return err
+		}
+	case ExportPDF:
+		ensureQtApp()
+		page, err := s.exportHTML(ids, title)
+		if err != nil {
+			return err
+		}
+		printer := printsupport.NewQPrinter3(printsupport.QPrinter__HighResolution)
+		printer.SetOutputFormat(printsupport.QPrinter__PdfFormat)
+		printer.SetOutputFileName(out)
+		doc := qt.NewQTextDocument()
+		s.addImageResources(doc, page)
+		doc.SetHtml(page)
+		doc.Print(printer.QPagedPaintDevice)
+		if _, err := os.Stat(out); err != nil {
+			return fmt.Errorf("unable to write pdf %s: %s", out, err)
+		}
+	case ExportZip:
+		if err := s.exportZip(ids, out); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown export format %q, use html, pdf or zip", format)
+	}
+	notesLog.Infof("Exported %d notes from %s to %s", len(ids), s.NotesDir, out)
+	return nil
+}
+
+// exportHTML renders notes into one html page, folder exports get a table of contents
+// and every note starts on a new page when printed
+func (s *NoteService) exportHTML(ids []string, title string) (string, error) {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\">")
+	b.WriteString("<title>" + html.EscapeString(title) + "</title>")
+	b.WriteString("<style>" + exportCSS + "</style></head><body>\n")
+
+	notes := make([]*Note, 0, len(ids))
+	for _, id := range ids {
+		n, err := s.Load(id)
+		if err != nil {
+			return "", err
+		}
+		notes = append(notes, n)
+	}
+	if len(notes) > 1 {
+		b.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n<ul>\n")
+		for i, n := range notes {
+			fmt.Fprintf(&b, "<li><a href=\"#note-%d\">%s</a> <small>%s</small></li>\n", i+1, html.EscapeString(noteTitle(n)), html.EscapeString(filepath.ToSlash(n.ID)))
+		}
+		b.WriteString("</ul>\n")
+	}
+	for i, n := range notes {
+		style := ""
+		if len(notes) > 1 {
+			style = ` style="page-break-before: always"`
+		}
+		fmt.Fprintf(&b, "<div class=\"note\" id=\"note-%d\"%s>\n", i+1, style)
+		b.WriteString("<h1 class=\"note-title\">" + html.EscapeString(noteTitle(n)) + "</h1>\n")
+		b.WriteString(frontMatterTable(n.Meta))
+		b.WriteString(markdownToHTML(s.absoluteLinks(n)))
+		b.WriteString("\n</div>\n")
+	}
+	b.WriteString("</body></html>\n")
+	return b.String(), nil
+}
+
+// noteTitle returns title of the note or its file name
+func noteTitle(n *Note) string {
+	if n.Meta.Title != "" {
+		return n.Meta.Title
+	}
+	return strings.TrimSuffix(filepath.Base(n.ID), ".md")
+}
+
+// frontMatterTable renders note metadata as html table, empty values are skipped
+func frontMatterTable(m NoteMeta) string {
+	var rows [][2]string
+	add := func(k, v string) {
+		if v != "" {
+			rows = append(rows, [2]string{k, v})
+		}
+	}
+	date := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return ToLocalTime(t).Format("2006-01-02 15:04")
+	}
+	add("Created", date(m.Created))
+	add("Updated", date(m.Updated))
+	add("Author", m.Author)
+	add("Source", m.Source)
+	add("Tags", strings.Join(m.Tags, ", "))
+	add("Servers", strings.Join(m.Servers, ", "))
+	add("Due", date(m.Due))
+	if m.Completed {
+		add("Completed", "yes")
+	}
+	if m.Latitude != 0 || m.Longitude != 0 {
+		add("Location", fmt.Sprintf("%.6f, %.6f", m.Latitude, m.Longitude))
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<table class=\"meta\">\n")
+	for _, r := range rows {
+		b.WriteString("<tr><td class=\"key\">" + html.EscapeString(r[0]) + "</td><td>" + html.EscapeString(r[1]) + "</td></tr>\n")
+	}
+	b.WriteString("</table>\n")
+	return b.String()
+}
+
+// markdownToHTML renders markdown with Qt and returns contents of the html body
+func markdownToHTML(md string) string {
+	doc := qt.NewQTextDocument()
+	doc.SetMarkdown(md)
+	out := doc.ToHtml()
+	start := strings.Index(out, "<body")
+	end := strings.LastIndex(out, "</body>")
+	if start < 0 || end < 0 {
+		return out
+	}
+	if gt := strings.Index(out[start:], ">"); gt >= 0 && start+gt < end {
+		return out[start+gt+1 : end]
+	}
+	return out
+}
+
+// localTarget returns the file a markdown link points to, or empty string for urls and anchors
+func (s *NoteService) localTarget(n *Note, target string) string {
+	if target == "" || strings.HasPrefix(target, "#") || strings.Contains(target, ":") {
+		return ""
+	}
+	if t, err := url.PathUnescape(target); err == nil {
+		target = t
+	}
+	path := filepath.Join(filepath.Dir(n.Path), filepath.FromSlash(target))
+	if fi, err := os.Stat(path); err != nil || fi.IsDir() {
+		return ""
+	}
+	return path
+}
+
+// absoluteLinks returns note body with local links converted to file:// urls, so Qt can load images,
+// attachment links get the note path, so they stay unique when notes are combined
+func (s *NoteService) absoluteLinks(n *Note) string {
+	return mdLinkRe.ReplaceAllStringFunc(string(n.Body), func(m string) string {
+		parts := mdLinkRe.FindStringSubmatch(m)
+		if id, name, ok := resolveAttachment(n.ID, parts[2]); ok {
+			return parts[1] + qualifiedAttachmentURL(id, name) + parts[3]
+		}
+		path := s.localTarget(n, parts[2])
+		if path == "" {
+			return m
+		}
+		return parts[1] + qt.QUrl_FromLocalFile(path).ToString() + parts[3]
+	})
+}
+
+// imageData returns content of the image referenced by file:// or attachment: url
+func (s *NoteService) imageData(src string) (string, []byte, error) {
+	if id, name, ok := resolveAttachment("", src); ok {
+		data, err := s.ReadAttachment(id, name)
+		return name, data, err
+	}
+	u, err := url.Parse(src)
+	if err != nil {
+		return "", nil, err
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	data, err := os.ReadFile(filepath.FromSlash(path))
+	return path, data, err
+}
+
+// addImageResources gives Qt decrypted attachment images of the page, it can load only local files itself
+func (s *NoteService) addImageResources(doc *qt.QTextDocument, page string) {
+	for _, m := range htmlImgRe.FindAllStringSubmatch(page, -1) {
+		src := html.UnescapeString(m[2])
+		if !strings.HasPrefix(src, attachmentScheme+":") {
+			continue
+		}
+		if _, data, err := s.imageData(src); err == nil {
+			doc.AddResource(int(qt.QTextDocument__ImageResource), qt.NewQUrl3(src), qt.NewQVariant15(data))
+		} else {
+			notesLog.Warnf("Unable to load image %s: %s", src, err)
+		}
+	}
+}
+
+// inlineImages embeds local images and attachments into the html, so the exported file is standalone
+func (s *NoteService) inlineImages(page string) string {
+	return htmlImgRe.ReplaceAllStringFunc(page, func(m string) string {
+		parts := htmlImgRe.FindStringSubmatch(m)
+		path, data, err := s.imageData(html.UnescapeString(parts[2]))
+		if err != nil {
+			notesLog.Warnf("Unable to embed image %s: %s", parts[2], err)
+			return m
+		}
+		mt := mime.TypeByExtension(filepath.Ext(path))
+		if mt == "" {
+			mt = "application/octet-stream"
+		}
+		return parts[1] + "data:" + mt + ";base64," + base64.StdEncoding.EncodeToString(data) + parts[3]
+	})
+}
+
+// exportZip writes decrypted notes and the local files they link to into zip archive,
+// attachments are stored in _attachments/<note>/ and the links point there
+func (s *NoteService) exportZip(ids []string, out string) error {
+	f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	added := make(map[string]bool)
+	addFile := func(name string, data []byte) error {
+		if added[name] {
+			return nil
+		}
+		added[name] = true
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	for _, id := range ids {
+		n, err := s.Load(id)
+		if err != nil {
+			return err
+		}
+		var attachErr error
+		raw := mdLinkRe.ReplaceAllStringFunc(string(n.Raw), func(m string) string {
+			parts := mdLinkRe.FindStringSubmatch(m)
+			aid, name, ok := resolveAttachment(n.ID, parts[2])
+			if !ok {
+				return m
+			}
+			data, err := s.ReadAttachment(aid, name)
+			if err != nil {
+				notesLog.Warnf("Unable to export attachment %s of %s: %s", name, aid, err)
+				return m
+			}
+			zipName := path.Join("_attachments", filepath.ToSlash(aid), name)
+			if err := addFile(zipName, data); err != nil {
+				attachErr = err
+			}
+			// link relative to the note folder inside the archive
+			target := strings.Repeat("../", strings.Count(filepath.ToSlash(id), "/")) +
+				"_attachments/" + strings.TrimPrefix(qualifiedAttachmentURL(aid, name), attachmentScheme+":")
+			return parts[1] + target + parts[3]
+		})
+		if attachErr != nil {
+			return attachErr
+		}
+		if err := addFile(filepath.ToSlash(id), []byte(raw)); err != nil {
+			return err
+		}
+		for _, m := range mdLinkRe.FindAllStringSubmatch(string(n.Body), -1) {
+			path := s.localTarget(n, m[2])
+			if path == "" {
+				continue
+			}
+			rel, err := filepath.Rel(s.NotesDir, path)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				notesLog.Warnf("Skipping %s linked from %s, it is outside of the notes folder", path, id)
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if err := addFile(filepath.ToSlash(rel), data); err != nil {
+				return err
+			}
+		}
+	}
+	return zw.Close()
+}
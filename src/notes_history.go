@@ -0,0 +1,271 @@
+package main
+
+/* Notes history
+Every save of the note writes a snapshot to .history/<note>/<timestamp>.md,
+here are the helpers to read, compare and restore them and the retention policy
+that keeps the history from growing forever.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// diff operation kinds
+const (
+	diffEqual = iota
+	diffDelete
+	diffInsert
+)
+
+// diffLine is a single line of the line based diff
+type diffLine struct {
+	Kind int
+	Text string
+	Old  int // line number in the old text, 0 for inserted lines
+	New  int // line number in the new text, 0 for deleted lines
+}
+
+// maxDiffCells limits memory used by the LCS table, bigger changes are shown as full replace
+const maxDiffCells = 4 * 1024 * 1024
+
+// ReadSnapshot returns decrypted content of the snapshot
+func (s *NoteService) ReadSnapshot(snap Snapshot) ([]byte, error) {
+	data, err := os.ReadFile(snap.Path)
+	if err != nil {
+		return nil, err
+	}
+	return s.maybeDecrypt(data), nil
+}
+
+// Restore replaces the note content with the snapshot, the restored version is saved as a new revision
+func (s *NoteService) Restore(n *Note, snap Snapshot) error {
+	data, err := s.ReadSnapshot(snap)
+	if err != nil {
+		return err
+	}
+	meta, body := stripYAMLFrontMatter(data)
+	// keep creation time of the note, older snapshots could miss it
+	if meta.Created.IsZero() {
+		meta.Created = n.Meta.Created
+	}
+	n.Meta = meta
+	n.Body = body
+	if err := s.Save(n); err != nil {
+		return err
+	}
+	auditEvent(AuditNoteRestore, "", n.ID, snap.Timestamp.Format("20060102-150405"))
+	notesLog.Infof("Note %s restored to revision %s", n.ID, snap.Timestamp.Format(time.RFC3339))
+	return nil
+}
+
+// PruneHistory removes snapshots of the note according to the retention settings,
+// the newest snapshot is always kept. Returns number of removed snapshots.
+func (s *NoteService) PruneHistory(n *Note) int {
+	keep := settings.NotesSettings.HistoryKeep
+	days := settings.NotesSettings.HistoryDays
+	if keep <= 0 && days <= 0 {
+		return 0
+	}
+	removed := 0
+	kept := n.History[:0]
+	cutoff := time.Now().AddDate(0, 0, -days)
+	for i, snap := range n.History {
+		newest := i == len(n.History)-1
+		tooMany := keep > 0 && len(n.History)-i > keep
+		tooOld := days > 0 && snap.Timestamp.Before(cutoff)
+		if !newest && (tooMany || tooOld) {
+			if err := os.Remove(snap.Path); err != nil {
+				notesLog.Warnf("Unable to remove snapshot %s: %s", snap.Path, err)
+				kept = append(kept, snap)
+				continue
+			}
+			removed++
+			continue
+		}
+		kept = append(kept, snap)
+	}
+	n.History = kept
+	if removed > 0 {
+		notesLog.Debugf("Removed %d old revisions of %s", removed, n.ID)
+	}
+	return removed
+}
+
+// PruneAllHistory applies the retention policy to all notes
+func (s *NoteService) PruneAllHistory() (int, error) {
+	ids, err := s.ListNotes()
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, id := range ids {
+		n, err := s.Load(id)
+		if err != nil {
+			continue
+		}
+		total += s.PruneHistory(n)
+	}
+	return total, nil
+}
+
+// splitLines splits text into lines without the trailing empty line
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines returns line based diff of the two texts (longest common subsequence)
+func diffLines(oldText, newText string) []diffLine {
+	a, b := splitLines(oldText), splitLines(newText)
+
+	// common prefix and suffix do not need the LCS table
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	var out []diffLine
+	for i := 0; i < pre; i++ {
+		out = append(out, diffLine{Kind: diffEqual, Text: a[i], Old: i + 1, New: i + 1})
+	}
+
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	n, m := len(ma), len(mb)
+	if n*m > maxDiffCells {
+		for i, l := range ma {
+			out = append(out, diffLine{Kind: diffDelete, Text: l, Old: pre + i + 1})
+		}
+		for j, l := range mb {
+			out = append(out, diffLine{Kind: diffInsert, Text: l, New: pre + j + 1})
+		}
+	} else {
+		// lcs[i][j] is LCS length of ma[i:] and mb[j:]
+		lcs := make([][]int32, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && ma[i] == mb[j]:
+				out = append(out, diffLine{Kind: diffEqual, Text: ma[i], Old: pre + i + 1, New: pre + j + 1})
+				i++
+				j++
+			case j < m && (i == n || lcs[i][j+1] > lcs[i+1][j]):
+				out = append(out, diffLine{Kind: diffInsert, Text: mb[j], New: pre + j + 1})
+				j++
+			default:
+				out = append(out, diffLine{Kind: diffDelete, Text: ma[i], Old: pre + i + 1})
+				i++
+			}
+		}
+	}
+
+	for k := 0; k < suf; k++ {
+		out = append(out, diffLine{Kind: diffEqual, Text: a[len(a)-suf+k], Old: len(a) - suf + k + 1, New: len(b) - suf + k + 1})
+	}
+	return out
+}
+
+// diffChanged returns true if the diff contains any change
+func diffChanged(lines []diffLine) bool {
+	for _, l := range lines {
+		if l.Kind != diffEqual {
+			return true
+		}
+	}
+	return false
+}
+
+// unifiedDiff formats the diff in unified format with the given number of context lines
+func unifiedDiff(oldName, newName string, lines []diffLine, context int) string {
+	if !diffChanged(lines) {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for start := 0; start < len(lines); {
+		// find next change
+		first := start
+		for first < len(lines) && lines[first].Kind == diffEqual {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		// extend the hunk while changes are closer than 2*context lines
+		from := first - context
+		if from < 0 {
+			from = 0
+		}
+		to := first
+		for to < len(lines) {
+			if lines[to].Kind != diffEqual {
+				to++
+				continue
+			}
+			next := to
+			for next < len(lines) && lines[next].Kind == diffEqual {
+				next++
+			}
+			if next == len(lines) || next-to > 2*context {
+				break
+			}
+			to = next
+		}
+		end := to + context
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		oldStart, newStart, oldCount, newCount := 0, 0, 0, 0
+		for _, l := range lines[from:end] {
+			if l.Kind != diffInsert {
+				if oldStart == 0 {
+					oldStart = l.Old
+				}
+				oldCount++
+			}
+			if l.Kind != diffDelete {
+				if newStart == 0 {
+					newStart = l.New
+				}
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, l := range lines[from:end] {
+			switch l.Kind {
+			case diffEqual:
+				b.WriteString(" " + l.Text + "\n")
+			case diffDelete:
+				b.WriteString("-" + l.Text + "\n")
+			case diffInsert:
+				b.WriteString("+" + l.Text + "\n")
+			}
+		}
+		start = end
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package main
+
+/* Notes linked to servers (runbooks)
+A note is linked to a server either from the note front-matter (servers: host, ip or stable id)
+or from the servers file (notes: list of note paths). Links are resolved through the search index,
+so notes are not decrypted again on every table refresh.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// NoteLink is a note linked to the server
+type NoteLink struct {
+	NotesDir string
+	ID       string // relative path of the note
+	Title    string
+}
+
+// serverMatches returns true if the note servers: entry refers to the server
+func serverMatches(ref string, srv Server) bool {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return false
+	}
+	return strings.EqualFold(ref, srv.Host) ||
+		(srv.IP != "" && ref == srv.IP) ||
+		strings.EqualFold(ref, srv.StableID())
+}
+
+// linkedNotes returns notes linked to the server, ordered by title
+func linkedNotes(srv Server) []NoteLink {
+	s := newNoteServiceFor(srv.SourceName)
+	if s == nil {
+		return nil
+	}
+	if _, err := os.Stat(s.NotesDir); err != nil {
+		return nil
+	}
+	idx := s.Index()
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var links []NoteLink
+	add := func(id string, doc *indexedNote) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		links = append(links, NoteLink{NotesDir: s.NotesDir, ID: id, Title: doc.title})
+	}
+	for _, ref := range srv.Notes {
+		id, err := normalizeNoteID(ref)
+		if err != nil {
+			continue
+		}
+		if doc, ok := idx.notes[id]; ok {
+			add(id, doc)
+		}
+	}
+	for id, doc := range idx.notes {
+		for _, ref := range doc.servers {
+			if serverMatches(ref, srv) {
+				add(id, doc)
+				break
+			}
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Title != links[j].Title {
+			return links[i].Title < links[j].Title
+		}
+		return links[i].ID < links[j].ID
+	})
+	return links
+}
+
+// linkedNoteTitles returns comma separated titles of the linked notes
+func linkedNoteTitles(links []NoteLink) string {
+	titles := make([]string, 0, len(links))
+	for _, l := range links {
+		titles = append(titles, l.Title)
+	}
+	return strings.Join(titles, ", ")
+}
+
+// popupLinkedNotes shows notes linked to the server as stickies, must run on Qt main thread
+func popupLinkedNotes(srv Server) {
+	for _, l := range linkedNotes(srv) {
+		sm, ok := Stickies[filepath.Base(l.NotesDir)]
+		if !ok {
+			continue
+		}
+		sm.Popup(l.ID)
+	}
+}
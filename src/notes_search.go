@@ -0,0 +1,327 @@
+package main
+
+/* Notes full-text search
+In-memory inverted index over decrypted note bodies and metadata (title, tags, author).
+Index is built lazily on the first search of the notes directory and kept up to date by
+NoteService Save/NewNote/DeleteNote, it never touches the disk so encrypted notes stay encrypted.
+(c) e1z0 2025
+*/
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// field weights, title and tag matches are more relevant than body ones
+const (
+	weightBody   = 1
+	weightAuthor = 2
+	weightTag    = 3
+	weightTitle  = 4
+)
+
+// NoteSearchResult is a single search hit
+type NoteSearchResult struct {
+	NotesDir string   `json:"notes_dir"`
+	ID       string   `json:"id"` // relative path of the note
+	Title    string   `json:"title"`
+	Tags     []string `json:"tags,omitempty"`
+	Score    int      `json:"score"`
+	Snippet  string   `json:"snippet"`
+	service  *NoteService
+}
+
+type indexedNote struct {
+	title   string
+	tags    []string
+	body    string
+	servers []string
+	terms   map[string]int // term -> weighted count
+}
+
+// NoteIndex is inverted index of one notes directory
+type NoteIndex struct {
+	mu       sync.RWMutex
+	postings map[string]map[string]int // term -> note id -> score
+	notes    map[string]*indexedNote
+}
+
+var (
+	noteIndexesMu sync.Mutex
+	noteIndexes   = make(map[string]*NoteIndex) // notes dir -> index
+)
+
+func newNoteIndex() *NoteIndex {
+	return &NoteIndex{
+		postings: make(map[string]map[string]int),
+		notes:    make(map[string]*indexedNote),
+	}
+}
+
+// tokenize splits text into lower case words, ignoring one letter words
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := words[:0]
+	for _, w := range words {
+		if len([]rune(w)) > 1 {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// Index returns the search index of this notes directory, building it on first use
+func (s *NoteService) Index() *NoteIndex {
+	noteIndexesMu.Lock()
+	idx, ok := noteIndexes[s.NotesDir]
+	if !ok {
+		idx = newNoteIndex()
+		noteIndexes[s.NotesDir] = idx
+	}
+	noteIndexesMu.Unlock()
+	if !ok {
+		s.buildIndex(idx)
+	}
+	return idx
+}
+
+// cachedIndex returns the index only if it was already built
+func (s *NoteService) cachedIndex() *NoteIndex {
+	noteIndexesMu.Lock()
+	defer noteIndexesMu.Unlock()
+	return noteIndexes[s.NotesDir]
+}
+
+func (s *NoteService) buildIndex(idx *NoteIndex) {
+	ids, _ := s.ListNotes()
+	for _, rel := range ids {
+		note, err := s.Load(rel)
+		if err != nil {
+			notesLog.Warnf("Unable to index note %s: %s", rel, err)
+			continue
+		}
+		idx.update(note)
+	}
+	notesLog.Debugf("Indexed %d notes in %s", len(ids), s.NotesDir)
+}
+
+// reindexNote updates the note in the index if the index was already built
+func (s *NoteService) reindexNote(n *Note) {
+	if idx := s.cachedIndex(); idx != nil {
+		idx.update(n)
+	}
+}
+
+// unindexNote removes note (or all notes under the folder) from the index
+func (s *NoteService) unindexNote(relID string) {
+	if idx := s.cachedIndex(); idx != nil {
+		idx.remove(relID)
+	}
+}
+
+func (idx *NoteIndex) update(n *Note) {
+	title := n.Meta.Title
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(n.ID), ".md")
+	}
+	doc := &indexedNote{title: title, tags: n.Meta.Tags, body: string(n.Body), servers: n.Meta.Servers, terms: make(map[string]int)}
+	add := func(text string, weight int) {
+		for _, t := range tokenize(text) {
+			doc.terms[t] += weight
+		}
+	}
+	add(title, weightTitle)
+	add(strings.Join(n.Meta.Tags, " "), weightTag)
+	add(n.Meta.Author, weightAuthor)
+	add(string(n.Body), weightBody)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(n.ID)
+	idx.notes[n.ID] = doc
+	for t, w := range doc.terms {
+		p, ok := idx.postings[t]
+		if !ok {
+			p = make(map[string]int)
+			idx.postings[t] = p
+		}
+		p[n.ID] = w
+	}
+}
+
+func (idx *NoteIndex) remove(relID string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	prefix := relID + string(os.PathSeparator)
+	for id := range idx.notes {
+		if id == relID || strings.HasPrefix(id, prefix) {
+			idx.removeLocked(id)
+		}
+	}
+}
+
+func (idx *NoteIndex) removeLocked(id string) {
+	doc, ok := idx.notes[id]
+	if !ok {
+		return
+	}
+	for t := range doc.terms {
+		if p, ok := idx.postings[t]; ok {
+			delete(p, id)
+			if len(p) == 0 {
+				delete(idx.postings, t)
+			}
+		}
+	}
+	delete(idx.notes, id)
+}
+
+// Search returns notes that contain all query words, the last word is matched as a prefix
+// so results show up while typing. Results are ordered by score.
+func (idx *NoteIndex) Search(query string) []NoteSearchResult {
+	words := tokenize(query)
+	if len(words) == 0 {
+		return nil
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var scores map[string]int
+	for i, w := range words {
+		matched := make(map[string]int)
+		if i == len(words)-1 {
+			for t, p := range idx.postings {
+				if strings.HasPrefix(t, w) {
+					for id, sc := range p {
+						matched[id] += sc
+					}
+				}
+			}
+		} else {
+			for id, sc := range idx.postings[w] {
+				matched[id] += sc
+			}
+		}
+		if scores == nil {
+			scores = matched
+			continue
+		}
+		for id := range scores {
+			if sc, ok := matched[id]; ok {
+				scores[id] += sc
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	results := make([]NoteSearchResult, 0, len(scores))
+	for id, sc := range scores {
+		doc := idx.notes[id]
+		results = append(results, NoteSearchResult{
+			ID:      id,
+			Title:   doc.title,
+			Tags:    doc.tags,
+			Score:   sc,
+			Snippet: makeSnippet(doc.body, words),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results
+}
+
+// Search searches notes of this service
+func (s *NoteService) Search(query string) []NoteSearchResult {
+	results := s.Index().Search(query)
+	for i := range results {
+		results[i].NotesDir = s.NotesDir
+		results[i].service = s
+	}
+	return results
+}
+
+// makeSnippet returns a single line around the first matched word
+func makeSnippet(body string, words []string) string {
+	const around = 40
+	lower := strings.ToLower(body)
+	pos := -1
+	for _, w := range words {
+		if p := strings.Index(lower, w); p >= 0 && (pos < 0 || p < pos) {
+			pos = p
+		}
+	}
+	runes := []rune(body)
+	start := 0
+	if pos > 0 {
+		// lower case runes can have other byte length (İ), but every rune is lowered to one rune,
+		// so the rune offset in lower is the rune offset in body
+		start = utf8.RuneCountInString(lower[:pos]) - around
+		if start < 0 {
+			start = 0
+		}
+	}
+	end := start + 2*around
+	if end > len(runes) {
+		end = len(runes)
+	}
+	snippet := strings.Join(strings.Fields(string(runes[start:end])), " ")
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// allNoteServices returns note services of all loaded server files that have notes
+func allNoteServices() []*NoteService {
+	var list []*NoteService
+	for _, item := range ymlfiles {
+		s := newNoteServiceFor(item)
+		if s == nil {
+			continue
+		}
+		if _, err := os.Stat(s.NotesDir); err != nil {
+			continue
+		}
+		list = append(list, s)
+	}
+	return list
+}
+
+// searchAllNotes searches notes of all server files
+func searchAllNotes(query string) []NoteSearchResult {
+	var results []NoteSearchResult
+	for _, s := range allNoteServices() {
+		results = append(results, s.Search(query)...)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results
+}
+
+// dropIndex forgets the index, it will be rebuilt on next search (used after sync pull)
+func (s *NoteService) dropIndex() {
+	noteIndexesMu.Lock()
+	delete(noteIndexes, s.NotesDir)
+	noteIndexesMu.Unlock()
+}
+
+// resetNoteIndexes forgets all indexes with decrypted note contents (used on lock)
+func resetNoteIndexes() {
+	noteIndexesMu.Lock()
+	noteIndexes = make(map[string]*NoteIndex)
+	noteIndexesMu.Unlock()
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"log"
 	"strings"
 	"time"
 
@@ -18,7 +17,8 @@ type StickyWindowQt struct {
 type StickyManagerQt struct {
 	service *NoteService
 	windows map[string]*StickyWindowQt
-	parent  *qt.QWidget // For parenting new dialogs (can be nil or main window)
+	popups  map[string]bool // notes shown on connect, they are shown as sticky without changing the note
+	parent  *qt.QWidget     // For parenting new dialogs (can be nil or main window)
 }
 
 // NewStickyManagerQt constructs a manager with its own service
@@ -27,6 +27,7 @@ func NewStickyManagerQt(service *NoteService, parent *qt.QWidget) *StickyManager
 		service: service,
 		parent:  parent,
 		windows: make(map[string]*StickyWindowQt),
+		popups:  make(map[string]bool),
 	}
 }
 
@@ -34,7 +35,7 @@ func NewStickyManagerQt(service *NoteService, parent *qt.QWidget) *StickyManager
 func (sm *StickyManagerQt) Refresh() {
 	treeData, _, err := sm.service.ListTree()
 	if err != nil {
-		log.Printf("StickyManagerQt.Refresh: %v", err)
+		notesLog.Debugf("StickyManagerQt.Refresh: %v", err)
 		return
 	}
 	seen := make(map[string]bool)
@@ -45,30 +46,27 @@ func (sm *StickyManagerQt) Refresh() {
 				continue
 			}
 			note, err := sm.service.Load(rel)
-			if err != nil || !note.Meta.Sticky {
+			if err != nil || (!note.Meta.Sticky && !sm.popups[rel]) {
 				continue
 			}
 			seen[rel] = true
 
 			// render markdown (use QTextBrowser for markdown)
 			label := qt.NewQTextBrowser(nil)
+			setupNoteBrowserQt(label, sm.service, func() string { return rel })
 			label.SetMarkdown(string(note.Body))
 			label.SetReadOnly(true)
 			label.SetLineWrapMode(qt.QTextEdit__WidgetWidth)
 			label.SetOpenLinks(false)
 			label.SetOpenExternalLinks(false)
 
-			label.OnAnchorClicked(func(url *qt.QUrl) {
-				qt.QDesktopServices_OpenUrl(url)
-			})
-
 			if sw, ok := sm.windows[rel]; ok {
-				log.Printf("refreshing existing %s", rel)
+				notesLog.Debugf("refreshing existing %s", rel)
 				sw.Label.SetMarkdown(string(note.Body))
 				sw.Win.SetWindowTitle("📌 " + note.Meta.Title)
 				sw.Scroll.SetWidget(sw.Label.QWidget)
 			} else {
-				log.Printf("creating new sticky window for %s", rel)
+				notesLog.Debugf("creating new sticky window for %s", rel)
 
 				win := qt.NewQDialog(sm.parent)
 				win.SetWindowTitle("📌 " + note.Meta.Title)
@@ -110,7 +108,7 @@ func (sm *StickyManagerQt) Refresh() {
 
 				// save settings
 				saveStickyWindowGeometry := func() {
-					secName := "Sticky " + rel
+					secName := stickySection(rel)
 
 					Store.SetMany(secName, map[string]interface{}{
 						"x":      win.Pos().X(),
@@ -122,7 +120,7 @@ func (sm *StickyManagerQt) Refresh() {
 
 				// load settings
 				load := func() {
-					secName := "Sticky " + rel
+					secName := stickySection(rel)
 					if Store.HasSection(secName) {
 						x, _ := Store.GetInt(secName, "x")
 						y, _ := Store.GetInt(secName, "y")
@@ -168,14 +166,14 @@ func (sm *StickyManagerQt) Refresh() {
 				})
 
 				if settings.NotesSettings.AlwaysOnTop {
-					log.Printf("Note stickies always on top\n")
+					notesLog.Debugf("Note stickies always on top")
 					win.SetWindowFlags(
 						qt.FramelessWindowHint |
 							qt.Window |
 							qt.WindowStaysOnTopHint, // stay on top of other windows
 					)
 				} else {
-					log.Printf("Note stickies normal mode\n")
+					notesLog.Debugf("Note stickies normal mode")
 					win.SetWindowFlags(
 						qt.FramelessWindowHint |
 							qt.Window, // <-- makes it resizable!
@@ -234,6 +232,14 @@ func (sm *StickyManagerQt) Refresh() {
 				closeBtn.SetCursor(qt.NewQCursor2(qt.PointingHandCursor))
 				closeBtn.OnClicked(func() {
 					win.Close()
+					// closing the note shown on connect does not change the note
+					if sm.popups[rel] {
+						delete(sm.popups, rel)
+						if !note.Meta.Sticky {
+							delete(sm.windows, rel)
+							return
+						}
+					}
 					sm.service.DisableSticky(note)
 					//note.Meta.Sticky = false
 					//sm.service.Save(note)
@@ -246,16 +252,13 @@ func (sm *StickyManagerQt) Refresh() {
 
 				// Label (QTextBrowser)
 				label := qt.NewQTextBrowser(nil)
+				setupNoteBrowserQt(label, sm.service, func() string { return rel })
 				label.SetMarkdown(string(note.Body))
 				label.SetReadOnly(true)
 
 				label.SetOpenLinks(false)
 				label.SetOpenExternalLinks(false)
 
-				label.OnAnchorClicked(func(url *qt.QUrl) {
-					qt.QDesktopServices_OpenUrl(url)
-				})
-
 				label.SetLineWrapMode(qt.QTextEdit__WidgetWidth)
 				label.SetStyleSheet(`
 	QTextBrowser {
@@ -367,9 +370,49 @@ func (sm *StickyManagerQt) Refresh() {
 	// close non-sticky windows
 	for rel, sw := range sm.windows {
 		if !seen[rel] {
-			log.Printf("closing sticky window for %s", rel)
+			notesLog.Debugf("closing sticky window for %s", rel)
 			sw.Win.Close()
 			delete(sm.windows, rel)
 		}
 	}
 }
+
+// HideAll hides sticky windows and drops their content, used when the app gets locked,
+// notes shown on connect are closed
+func (sm *StickyManagerQt) HideAll() {
+	for rel, sw := range sm.windows {
+		sw.Label.SetMarkdown("")
+		sw.Win.Hide()
+		if sm.popups[rel] {
+			sw.Win.Close()
+			delete(sm.windows, rel)
+		}
+	}
+	sm.popups = make(map[string]bool)
+}
+
+// ShowAll shows the sticky windows again after unlock
+func (sm *StickyManagerQt) ShowAll() {
+	for _, sw := range sm.windows {
+		sw.Win.Show()
+	}
+}
+
+// Popup brings the sticky window of the note to front, the note that is not sticky is shown
+// in the sticky window until it is closed, the note itself is not changed
+func (sm *StickyManagerQt) Popup(rel string) {
+	if _, ok := sm.windows[rel]; !ok {
+		sm.popups[rel] = true
+		sm.Refresh()
+		if _, ok := sm.windows[rel]; !ok {
+			delete(sm.popups, rel)
+			notesLog.Warnf("Unable to show linked note %s", rel)
+			return
+		}
+	}
+	if sw, ok := sm.windows[rel]; ok {
+		sw.Win.Show()
+		sw.Win.Raise()
+		sw.Win.ActivateWindow()
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+/* Notes sync reconciliation
+The sync manifest (.sync.json in the notes folder) remembers the files and their hashes
+after the last push or pull. Comparing it with the local folder and the gist tells what
+was deleted, renamed or moved on either side:
+- push uploads only changed files and removes files deleted locally from the gist
+- pull removes local files deleted from the gist, unless they were changed locally,
+  and keeps local changes of files that were not changed in the gist
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+const syncManifestFile = ".sync.json"
+
+// syncManifest is the state of the notes folder after the last sync
+type syncManifest struct {
+	GistID string            `json:"gist_id"`
+	Synced time.Time         `json:"synced"`
+	Files  map[string]string `json:"files"` // gist file name -> sha256 of the local file
+}
+
+// loadSyncManifest returns manifest of the last sync, empty when the folder was never synced with the gist
+func (s *NoteService) loadSyncManifest() *syncManifest {
+	m := &syncManifest{Files: make(map[string]string)}
+	data, err := os.ReadFile(filepath.Join(s.NotesDir, syncManifestFile))
+	if err != nil {
+		return m
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		notesLog.Warnf("Invalid sync manifest of %s: %s", s.NotesDir, err)
+		return &syncManifest{Files: make(map[string]string)}
+	}
+	// synced with another gist
+	if m.GistID != s.Gist.GistID || m.Files == nil {
+		m.Files = make(map[string]string)
+	}
+	return m
+}
+
+func (s *NoteService) saveSyncManifest(m *syncManifest) error {
+	m.Synced = time.Now().UTC()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.NotesDir, syncManifestFile), data, 0600)
+}
+
+// resetSyncState marks all the synced files as changed, the next push uploads them again,
+// files are still known as synced so they are not removed when they are missing in the gist
+func (s *NoteService) resetSyncState() {
+	manifest := s.loadSyncManifest()
+	if len(manifest.Files) == 0 {
+		return
+	}
+	for safe := range manifest.Files {
+		manifest.Files[safe] = ""
+	}
+	if err := s.saveSyncManifest(manifest); err != nil {
+		notesLog.Warnf("Unable to save sync manifest: %s", err)
+	}
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// gistName returns gist file name of the note or attachment
+func gistName(rel string) string {
+	safe := strings.ReplaceAll(rel, string(os.PathSeparator), "__")
+	if isAttachmentPath(rel) {
+		safe += attachmentGistSuffix
+	}
+	return safe
+}
+
+// gistRel returns relative path of the gist file, ok is false for files that are not notes or attachments
+func gistRel(name string) (rel string, attachment bool, ok bool) {
+	attachment = strings.HasPrefix(name, attachmentsDir+"__") && strings.HasSuffix(name, attachmentGistSuffix)
+	if !attachment && !strings.HasSuffix(name, ".md") {
+		return "", false, false
+	}
+	rel = strings.ReplaceAll(strings.TrimSuffix(name, attachmentGistSuffix), "__", string(os.PathSeparator))
+	if strings.Contains(rel, "..") {
+		return "", false, false
+	}
+	return rel, attachment, true
+}
+
+// patchGist changes files of the gist, nil file removes it from the gist
+func patchGist(ctx context.Context, client *github.Client, gistID, description string, files map[string]interface{}) error {
+	body := map[string]interface{}{"files": files}
+	if description != "" {
+		body["description"] = description
+	}
+	req, err := client.NewRequest("PATCH", fmt.Sprintf("gists/%s", gistID), body)
+	if err != nil {
+		return fmt.Errorf("creating PATCH request: %s", err)
+	}
+	resp, err := client.Do(ctx, req, nil)
+	if err != nil {
+		return fmt.Errorf("sending PATCH request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GitHub API returned %s", resp.Status)
+	}
+	return nil
+}
+
+// deletedLocally returns true when the file synced before was deleted or moved locally and not changed
+// in the gist since then, it is not written back and the next push deletes it from the gist
+func (s *NoteService) deletedLocally(rel, base string, pulled []byte) bool {
+	if base == "" {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(s.NotesDir, rel)); !os.IsNotExist(err) {
+		return false
+	}
+	if contentHash(pulled) != base {
+		notesLog.Warnf("%s was deleted locally, but changed in the gist, restoring it", rel)
+		return false
+	}
+	notesLog.Infof("%s was deleted locally, it is deleted from the gist by the next push", rel)
+	return true
+}
+
+// keepLocalChanges decides what to do with the local file before it is overwritten by the pulled one,
+// returns true when only the local file was changed since the last sync. When both were changed
+// the gist version wins and the local one is kept in the note history.
+func (s *NoteService) keepLocalChanges(rel string, attachment bool, base string, pulled []byte) bool {
+	current, err := os.ReadFile(filepath.Join(s.NotesDir, rel))
+	if err != nil || base == "" {
+		return false
+	}
+	local := contentHash(current)
+	remote := contentHash(pulled)
+	if local == base || local == remote {
+		return false
+	}
+	if remote == base {
+		notesLog.Infof("Keeping local changes of %s", rel)
+		return true
+	}
+	notesLog.Warnf("%s was changed locally and in the gist, local version is kept in the history", rel)
+	if !attachment {
+		snapDir := filepath.Join(s.NotesDir, s.HistoryDir, rel)
+		if err := os.MkdirAll(snapDir, 0755); err == nil {
+			os.WriteFile(filepath.Join(snapDir, time.Now().Format("20060102-150405")+".md"), current, 0644)
+		}
+	}
+	return false
+}
+
+// removeDeletedRemotely removes local file that was deleted from the gist, local changes are kept
+// and the file is uploaded again by the next push
+func (s *NoteService) removeDeletedRemotely(safe, base string) {
+	rel, attachment, ok := gistRel(safe)
+	if !ok {
+		return
+	}
+	full := filepath.Join(s.NotesDir, rel)
+	current, err := os.ReadFile(full)
+	if err != nil {
+		return
+	}
+	if contentHash(current) != base {
+		notesLog.Warnf("%s was deleted from the gist, but changed locally, keeping it", rel)
+		return
+	}
+	if attachment {
+		err = os.Remove(full)
+	} else {
+		err = s.DeleteNote(rel)
+	}
+	if err != nil {
+		notesLog.Warnf("Unable to remove %s deleted from the gist: %s", rel, err)
+		return
+	}
+	notesLog.Infof("Removed %s, it was deleted from the gist", rel)
+	removeEmptyDirs(filepath.Dir(full), s.NotesDir)
+}
+
+// removeEmptyDirs removes dir and its parents up to root while they are empty
+func removeEmptyDirs(dir, root string) {
+	for dir != root && strings.HasPrefix(dir, root+string(os.PathSeparator)) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// stickySection returns config section with the sticky window geometry
+func stickySection(rel string) string {
+	return "Sticky " + rel
+}
+
+// moveStickySections moves geometry of the sticky windows when the note or folder is renamed,
+// empty newRel removes them
+func moveStickySections(oldRel, newRel string) {
+	if Store == nil {
+		return
+	}
+	for _, name := range Store.Sections() {
+		rel, ok := strings.CutPrefix(name, stickySection(""))
+		if !ok || (rel != oldRel && !strings.HasPrefix(rel, oldRel+string(os.PathSeparator))) {
+			continue
+		}
+		if newRel != "" {
+			Store.RenameSection(name, stickySection(newRel+strings.TrimPrefix(rel, oldRel)))
+		} else {
+			Store.DeleteSection(name)
+		}
+	}
+}
+
+// cleanStaleStickySections removes geometry of the sticky windows whose notes no longer exist
+func cleanStaleStickySections() {
+	if Store == nil {
+		return
+	}
+	services := allNoteServices()
+	for _, name := range Store.Sections() {
+		rel, ok := strings.CutPrefix(name, stickySection(""))
+		if !ok {
+			continue
+		}
+		exists := false
+		for _, s := range services {
+			if _, err := os.Stat(filepath.Join(s.NotesDir, rel)); err == nil {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			notesLog.Debugf("Removing stale %s", name)
+			Store.DeleteSection(name)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+/* Notes tasks
+A note with due: in the front-matter is a task (completed: marks it done), checkbox items
+"- [ ] text" in the note body are tasks too, they can have their own due date (due:2025-01-31
+or due:2025-01-31T14:00). Tasks are listed by the tasks window and `conan notes tasks`,
+the GUI shows tray notifications when the due date arrives.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// task groups
+const (
+	TaskOverdue  = "overdue"
+	TaskToday    = "today"
+	TaskUpcoming = "upcoming"
+	TaskNoDate   = "no date"
+	TaskDone     = "done"
+)
+
+var (
+	// - [ ] text, * [x] text, 1. [ ] text
+	taskItemRe = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)])\s+)\[([ xX])\]\s+(.*)$`)
+	// due:2025-01-31, due:2025-01-31T14:00 or 📅 2025-01-31
+	taskDueRe = regexp.MustCompile(`(?:\bdue:|📅\s*)(\d{4}-\d{2}-\d{2}(?:T\d{2}:\d{2})?)`)
+)
+
+// NoteTask is a note with due date or a checkbox item of the note
+type NoteTask struct {
+	NotesDir string    `json:"notes_dir"`
+	NoteID   string    `json:"note"`
+	Title    string    `json:"title"`          // note title
+	Line     int       `json:"line,omitempty"` // line of the checkbox in the note body, 0 for the note itself
+	Text     string    `json:"text"`
+	Due      time.Time `json:"due"`
+	Done     bool      `json:"done"`
+}
+
+// Ref returns reference of the task used by the command line, note[:line]
+func (t NoteTask) Ref() string {
+	ref := strings.TrimSuffix(filepath.ToSlash(t.NoteID), ".md")
+	if t.Line > 0 {
+		ref += ":" + strconv.Itoa(t.Line)
+	}
+	return ref
+}
+
+// Key identifies the task for reminders
+func (t NoteTask) Key() string {
+	return t.NotesDir + "|" + t.Ref() + "|" + t.Text
+}
+
+// Group returns overdue, today, upcoming, no date or done
+func (t NoteTask) Group(now time.Time) string {
+	switch {
+	case t.Done:
+		return TaskDone
+	case t.Due.IsZero():
+		return TaskNoDate
+	case t.Due.Before(now) && !sameDay(t.Due, now):
+		return TaskOverdue
+	case sameDay(t.Due, now):
+		return TaskToday
+	}
+	return TaskUpcoming
+}
+
+func sameDay(a, b time.Time) bool {
+	a, b = a.Local(), b.Local()
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+// parseTaskDue returns due date of the checkbox item text, item without date returns zero time
+func parseTaskDue(text string) time.Time {
+	m := taskDueRe.FindStringSubmatch(text)
+	if m == nil {
+		return time.Time{}
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, m[1], time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// taskDueText formats due date, date only tasks are shown without time
+func taskDueText(t NoteTask) string {
+	if t.Due.IsZero() {
+		return ""
+	}
+	due := t.Due.Local()
+	if due.Hour() == 0 && due.Minute() == 0 {
+		return due.Format("2006-01-02")
+	}
+	return due.Format("2006-01-02 15:04")
+}
+
+// noteTasks returns tasks of the note
+func noteTasks(notesDir string, n *Note) []NoteTask {
+	var tasks []NoteTask
+	title := noteTitle(n)
+	if !n.Meta.Due.IsZero() {
+		tasks = append(tasks, NoteTask{NotesDir: notesDir, NoteID: n.ID, Title: title, Text: title, Due: n.Meta.Due, Done: n.Meta.Completed})
+	}
+	for i, line := range strings.Split(string(n.Body), "\n") {
+		m := taskItemRe.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		tasks = append(tasks, NoteTask{
+			NotesDir: notesDir,
+			NoteID:   n.ID,
+			Title:    title,
+			Line:     i + 1,
+			Text:     strings.TrimSpace(m[3]),
+			Due:      parseTaskDue(m[3]),
+			Done:     m[2] != " ",
+		})
+	}
+	return tasks
+}
+
+// tasks of unchanged notes are reused, reminders check them every minute
+type taskCacheEntry struct {
+	modTime time.Time
+	encKey  string // notes loaded before the settings were unlocked are not decrypted
+	tasks   []NoteTask
+}
+
+var (
+	taskCacheMu sync.Mutex
+	taskCache   = make(map[string]taskCacheEntry)
+)
+
+// Tasks returns tasks of all notes sorted by due date, tasks without date are last
+func (s *NoteService) Tasks() ([]NoteTask, error) {
+	ids, err := s.ListNotes()
+	if err != nil {
+		return nil, err
+	}
+	var tasks []NoteTask
+	for _, id := range ids {
+		full := filepath.Join(s.NotesDir, id)
+		fi, err := os.Stat(full)
+		if err != nil {
+			continue
+		}
+		taskCacheMu.Lock()
+		entry, ok := taskCache[full]
+		taskCacheMu.Unlock()
+		if !ok || !entry.modTime.Equal(fi.ModTime()) || entry.encKey != s.Gist.EncKey {
+			n, err := s.Load(id)
+			if err != nil {
+				notesLog.Warnf("Unable to load note %s: %s", id, err)
+				continue
+			}
+			entry = taskCacheEntry{modTime: fi.ModTime(), encKey: s.Gist.EncKey, tasks: noteTasks(s.NotesDir, n)}
+			taskCacheMu.Lock()
+			taskCache[full] = entry
+			taskCacheMu.Unlock()
+		}
+		tasks = append(tasks, entry.tasks...)
+	}
+	sortTasks(tasks)
+	return tasks, nil
+}
+
+// resetTaskCache drops the cached tasks, they contain decrypted text of the notes
+func resetTaskCache() {
+	taskCacheMu.Lock()
+	taskCache = make(map[string]taskCacheEntry)
+	taskCacheMu.Unlock()
+}
+
+// taskRemindersKeep is how long the sent reminders are remembered
+const taskRemindersKeep = 90 * 24 * time.Hour
+
+// taskReminderID identifies the reminder of the task, a new due date reminds again.
+// The text of the task is hashed, so the reminders file does not leak encrypted notes.
+func taskReminderID(t NoteTask) string {
+	sum := sha256.Sum256([]byte(t.Key() + "|" + t.Due.UTC().Format(time.RFC3339)))
+	return hex.EncodeToString(sum[:16])
+}
+
+func taskRemindersFile() string {
+	return filepath.Join(env.configDir, "cache", "reminders.json")
+}
+
+// loadTaskReminders returns the sent reminders with the time they were sent
+func loadTaskReminders() map[string]time.Time {
+	sent := make(map[string]time.Time)
+	data, err := os.ReadFile(taskRemindersFile())
+	if err != nil {
+		return sent
+	}
+	if err := json.Unmarshal(data, &sent); err != nil {
+		notesLog.Warnf("Ignoring broken reminders file: %s", err)
+		return make(map[string]time.Time)
+	}
+	return sent
+}
+
+// saveTaskReminders writes the sent reminders, the old ones are dropped
+func saveTaskReminders(sent map[string]time.Time) {
+	for id, when := range sent {
+		if time.Since(when) > taskRemindersKeep {
+			delete(sent, id)
+		}
+	}
+	data, err := json.Marshal(sent)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(taskRemindersFile()), 0700); err != nil {
+		notesLog.Warnf("Unable to create the cache folder: %s", err)
+		return
+	}
+	if err := os.WriteFile(taskRemindersFile(), data, 0600); err != nil {
+		notesLog.Warnf("Unable to save reminders: %s", err)
+	}
+}
+
+// allTasks returns tasks of all notes folders
+func allTasks() []NoteTask {
+	var tasks []NoteTask
+	for _, s := range allNoteServices() {
+		t, err := s.Tasks()
+		if err != nil {
+			notesLog.Warnf("Unable to list tasks of %s: %s", s.NotesDir, err)
+			continue
+		}
+		tasks = append(tasks, t...)
+	}
+	sortTasks(tasks)
+	return tasks
+}
+
+func sortTasks(tasks []NoteTask) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if a.Due.IsZero() != b.Due.IsZero() {
+			return !a.Due.IsZero()
+		}
+		if !a.Due.Equal(b.Due) {
+			return a.Due.Before(b.Due)
+		}
+		if a.NoteID != b.NoteID {
+			return a.NoteID < b.NoteID
+		}
+		return a.Line < b.Line
+	})
+}
+
+// CompleteTask marks the task done, the note is checked again so stale tasks don't change other lines
+func (s *NoteService) CompleteTask(t NoteTask) error {
+	n, err := s.Load(t.NoteID)
+	if err != nil {
+		return err
+	}
+	if t.Line == 0 {
+		if n.Meta.Due.IsZero() {
+			return fmt.Errorf("note %s has no due date", t.Ref())
+		}
+		n.Meta.Completed = true
+	} else {
+		lines := strings.Split(string(n.Body), "\n")
+		if t.Line > len(lines) {
+			return fmt.Errorf("task %s not found, the note was changed", t.Ref())
+		}
+		m := taskItemRe.FindStringSubmatchIndex(lines[t.Line-1])
+		if m == nil || strings.TrimSpace(lines[t.Line-1][m[6]:m[7]]) != t.Text {
+			return fmt.Errorf("task %s not found, the note was changed", t.Ref())
+		}
+		// replace the checkbox mark
+		lines[t.Line-1] = lines[t.Line-1][:m[4]] + "x" + lines[t.Line-1][m[5]:]
+		n.Body = []byte(strings.Join(lines, "\n"))
+	}
+	if err := s.Save(n); err != nil {
+		return err
+	}
+	notesLog.Infof("Task %s marked done", t.Ref())
+	return nil
+}
+
+// findTask returns task by the note[:line] reference
+func findTask(tasks []NoteTask, ref string) (NoteTask, error) {
+	ref = filepath.ToSlash(strings.TrimSpace(ref))
+	if i := strings.LastIndex(ref, ":"); i > 0 {
+		if _, err := strconv.Atoi(ref[i+1:]); err == nil {
+			ref = strings.TrimSuffix(ref[:i], ".md") + ref[i:]
+		}
+	}
+	ref = strings.TrimSuffix(ref, ".md")
+	var found []NoteTask
+	for _, t := range tasks {
+		if t.Ref() == ref {
+			found = append(found, t)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return NoteTask{}, fmt.Errorf("task %s not found", ref)
+	case 1:
+		return found[0], nil
+	}
+	return NoteTask{}, fmt.Errorf("task %s exists in several notes folders, select one with --file", ref)
+}
@@ -0,0 +1,219 @@
+package main
+
+/* Note templates
+New notes can be created from templates (incident report, change request, server runbook...).
+Templates are markdown files in .templates of the notes folder, optionally with front-matter,
+filled in with text/template. The built-in ones are used until a file with the same name exists.
+Available fields: .Name .Date .Time .Now .Host .IP .User .Type .Description .Tags .Source
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const templatesDir = ".templates"
+
+var builtinTemplates = map[string]string{
+	"incident": `---
+title: "Incident {{.Date}}{{if .Host}} {{.Host}}{{end}}"
+tags: [incident]
+---
+# Incident {{.Date}}{{if .Host}} - {{.Host}}{{end}}
+
+{{if .Host}}- **Server:** {{.Host}} ({{.IP}})
+{{end}}- **Started:** {{.Date}} {{.Time}}
+- **Severity:**
+
+## Impact
+
+## Timeline
+- {{.Time}} incident reported
+
+## Root cause
+
+## Action items
+- [ ] write postmortem
+`,
+	"change-request": `---
+title: "Change {{.Date}}{{if .Host}} {{.Host}}{{end}}"
+tags: [change]
+---
+# Change request{{if .Host}} - {{.Host}}{{end}}
+
+- **Planned:** {{.Date}} {{.Time}}
+{{if .Host}}- **Server:** {{.Host}} ({{.IP}})
+{{end}}
+## Description
+
+## Risk and impact
+
+## Implementation plan
+1.
+
+## Rollback plan
+
+## Verification
+- [ ] services checked after the change
+`,
+	"runbook": `---
+title: "{{if .Host}}{{.Host}} runbook{{else}}{{.Name}}{{end}}"
+tags: [runbook]
+---
+# {{if .Host}}{{.Host}}{{else}}{{.Name}}{{end}}
+
+| | |
+|---|---|
+| Host | {{.Host}} |
+| IP | {{.IP}} |
+| Type | {{.Type}} |
+| User | {{.User}} |
+| Tags | {{join .Tags ", "}} |
+
+## Purpose
+{{.Description}}
+
+## Access
+
+## Common tasks
+
+## Troubleshooting
+`,
+}
+
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// TemplateData is passed to the note templates
+type TemplateData struct {
+	Name        string // note name
+	Date        string // 2006-01-02
+	Time        string // 15:04
+	Now         time.Time
+	Host        string
+	IP          string
+	User        string
+	Type        string
+	Description string
+	Tags        []string
+	Source      string // servers file
+}
+
+// newTemplateData returns template data of the note, srv can be nil
+func newTemplateData(name string, srv *Server) TemplateData {
+	now := time.Now()
+	data := TemplateData{Name: name, Date: now.Format("2006-01-02"), Time: now.Format("15:04"), Now: now}
+	if srv != nil {
+		data.Host = srv.Host
+		data.IP = srv.IP
+		data.User = srv.User
+		data.Type = srv.Type
+		data.Description = srv.Description
+		data.Source = srv.SourceName
+		for _, t := range strings.Split(srv.Tags, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				data.Tags = append(data.Tags, t)
+			}
+		}
+	}
+	return data
+}
+
+// NoteTemplate is a built-in or user defined template
+type NoteTemplate struct {
+	Name    string `json:"name"`
+	Builtin bool   `json:"builtin"`
+}
+
+// Templates returns available templates, user defined override the built-in ones with the same name
+func (s *NoteService) Templates() []NoteTemplate {
+	seen := make(map[string]bool)
+	var list []NoteTemplate
+	entries, _ := os.ReadDir(filepath.Join(s.NotesDir, templatesDir))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), ".md")
+		seen[name] = true
+		list = append(list, NoteTemplate{Name: name})
+	}
+	for name := range builtinTemplates {
+		if !seen[name] {
+			list = append(list, NoteTemplate{Name: name, Builtin: true})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// templateText returns source of the template
+func (s *NoteService) templateText(name string) (string, error) {
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid template name %s", name)
+	}
+	data, err := os.ReadFile(filepath.Join(s.NotesDir, templatesDir, name+".md"))
+	if err == nil {
+		return string(s.maybeDecrypt(data)), nil
+	}
+	if text, ok := builtinTemplates[name]; ok {
+		return text, nil
+	}
+	return "", fmt.Errorf("template %s not found", name)
+}
+
+// InstallTemplates copies built-in templates to the templates folder, so they can be changed,
+// existing templates are kept
+func (s *NoteService) InstallTemplates() (string, error) {
+	dir := filepath.Join(s.NotesDir, templatesDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	for name, text := range builtinTemplates {
+		path := filepath.Join(dir, name+".md")
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		if err := os.WriteFile(path, s.maybeEncrypt([]byte(text)), 0644); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// NewNoteFromTemplate creates note from the template, the note is linked to srv when given
+func (s *NoteService) NewNoteFromTemplate(parentRel, name, tmplName string, srv *Server) error {
+	text, err := s.templateText(tmplName)
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New(tmplName).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("template %s: %w", tmplName, err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, newTemplateData(name, srv)); err != nil {
+		return fmt.Errorf("template %s: %w", tmplName, err)
+	}
+	meta, body := stripYAMLFrontMatter(out.Bytes())
+	if meta.Title == "" {
+		meta.Title = name
+	}
+	now := time.Now().UTC()
+	meta.Created, meta.Updated = now, now
+	if srv != nil && len(meta.Servers) == 0 {
+		meta.Servers = []string{srv.Host}
+	}
+	notesLog.Debugf("Creating note %s from template %s", name, tmplName)
+	return s.writeNewNote(parentRel, name, meta, body)
+}
@@ -0,0 +1,151 @@
+package main
+
+/* Notes encryption consistency
+Notes, history snapshots and attachments are encrypted (HMACENCv1: prefix) only when the
+notes encryption of the gist is enabled. Notes pulled from the gist keep the state of the
+computer that pushed them and toggling the setting does not touch existing files, so
+VerifyEncryption finds the files in the wrong state and converts them. RekeyNotes converts
+the files encrypted with the previous key of the gist to the current key.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EncryptionReport is the result of the encryption consistency pass
+type EncryptionReport struct {
+	NotesDir   string   `json:"notes_dir"`
+	Encrypt    bool     `json:"encrypt"`    // configured state
+	Checked    int      `json:"checked"`    // notes, snapshots and attachments checked
+	Mismatched []string `json:"mismatched"` // files in the wrong state
+	Converted  int      `json:"converted"`
+	Unreadable []string `json:"unreadable"` // encrypted with another key or without the key set
+}
+
+// OK returns true when all files are in the configured state
+func (r *EncryptionReport) OK() bool {
+	return len(r.Unreadable) == 0 && len(r.Mismatched) == r.Converted
+}
+
+// wantEncrypted returns true when the notes should be stored encrypted
+func (s *NoteService) wantEncrypted() bool {
+	return s.Gist.EncryptNotes && s.Gist.EncKey != ""
+}
+
+// VerifyEncryption checks notes, history snapshots and attachments against the encryption setting,
+// files in the wrong state are converted unless dryRun is set
+func (s *NoteService) VerifyEncryption(dryRun bool) (*EncryptionReport, error) {
+	return s.convertEncryption("", dryRun)
+}
+
+// RekeyNotes decrypts notes, history snapshots and attachments encrypted with the old key
+// and stores them in the configured state with the current key, the gist is encrypted with
+// the key too, so all the files are pushed again
+func (s *NoteService) RekeyNotes(oldKey string) (*EncryptionReport, error) {
+	report, err := s.convertEncryption(oldKey, false)
+	if err != nil {
+		return report, err
+	}
+	s.resetSyncState()
+	return report, nil
+}
+
+// convertEncryption converts the files in the wrong state, files encrypted with oldKey
+// (when set) are always converted to the current key
+func (s *NoteService) convertEncryption(oldKey string, dryRun bool) (*EncryptionReport, error) {
+	report := &EncryptionReport{NotesDir: s.NotesDir, Encrypt: s.wantEncrypted()}
+	if _, err := os.Stat(s.NotesDir); os.IsNotExist(err) {
+		return report, nil
+	}
+	manifest := s.loadSyncManifest()
+	manifestChanged := false
+	err := filepath.Walk(s.NotesDir, func(full string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(s.NotesDir, full)
+		if err != nil {
+			return err
+		}
+		if !isAttachmentPath(rel) && !strings.HasSuffix(rel, ".md") {
+			return nil
+		}
+		data, err := os.ReadFile(full)
+		if err != nil {
+			return err
+		}
+		report.Checked++
+		encrypted := isEncrypted(string(data))
+		plain := data
+		oldKeyUsed := false
+		if encrypted {
+			dec, err := "", errors.New("no key")
+			if s.Gist.EncKey != "" {
+				dec, err = decryptWithMagic(string(data), s.Gist.EncKey)
+			}
+			if err != nil && oldKey != "" {
+				dec, err = decryptWithMagic(string(data), oldKey)
+				oldKeyUsed = err == nil
+			}
+			if err != nil {
+				report.Unreadable = append(report.Unreadable, rel)
+				return nil
+			}
+			plain = []byte(dec)
+		}
+		if encrypted == report.Encrypt && !oldKeyUsed {
+			return nil
+		}
+		report.Mismatched = append(report.Mismatched, rel)
+		if dryRun {
+			return nil
+		}
+		out := s.maybeEncrypt(plain)
+		if report.Encrypt && !isEncrypted(string(out)) {
+			notesLog.Errorf("Unable to encrypt %s", rel)
+			return nil
+		}
+		if err := os.WriteFile(full, out, info.Mode().Perm()); err != nil {
+			return err
+		}
+		report.Converted++
+		// the content did not change, don't push it again (files of the old key are pushed with the new one)
+		if safe := gistName(rel); oldKey == "" && manifest.Files[safe] == contentHash(data) {
+			manifest.Files[safe] = contentHash(out)
+			manifestChanged = true
+		}
+		return nil
+	})
+	if manifestChanged {
+		if err := s.saveSyncManifest(manifest); err != nil {
+			notesLog.Warnf("Unable to save sync manifest: %s", err)
+		}
+	}
+	for _, rel := range report.Unreadable {
+		notesLog.Warnf("Unable to decrypt %s in %s, wrong encryption key?", rel, s.NotesDir)
+	}
+	if report.Converted > 0 {
+		state := "plain text"
+		if report.Encrypt {
+			state = "encrypted"
+		}
+		notesLog.Infof("Converted %d files of %s to %s", report.Converted, s.NotesDir, state)
+	}
+	return report, err
+}
+
+// verifyNotesEncryption runs the consistency pass and logs the result
+func (s *NoteService) verifyNotesEncryption() {
+	report, err := s.VerifyEncryption(false)
+	if err != nil {
+		notesLog.Errorf("Notes encryption check of %s failed: %s", s.NotesDir, err)
+		return
+	}
+	if !report.OK() {
+		notesLog.Warnf("Notes of %s are not consistently encrypted, run `conan notes verify`", s.NotesDir)
+	}
+}
@@ -0,0 +1,437 @@
+package main
+
+/* Servers export
+Writes loaded servers as ssh config, Ansible inventory (ini or yaml), CSV or JSON, so the other
+tools can be generated from the servers files instead of being maintained separately.
+In the Ansible inventory tags become groups and each servers file is the parent group of its tag groups.
+Passwords are omitted by default, they can be written in plain text or (yaml inventory) as ansible-vault.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"gopkg.in/yaml.v3"
+)
+
+// export formats
+const (
+	ExportSSHConfig   = "sshconfig"
+	ExportAnsibleINI  = "ansible-ini"
+	ExportAnsibleYAML = "ansible-yaml"
+	ExportCSV         = "csv"
+	ExportJSON        = "json"
+)
+
+var ExportFormats = []string{ExportSSHConfig, ExportAnsibleINI, ExportAnsibleYAML, ExportCSV, ExportJSON}
+
+// password modes of the export
+const (
+	PasswordsNone  = "none"
+	PasswordsPlain = "plain"
+	PasswordsVault = "vault"
+)
+
+// ExportOptions controls how the servers are exported
+type ExportOptions struct {
+	Format        string
+	Passwords     string // none, plain or vault
+	VaultPassword string // ansible-vault password when Passwords is vault
+	Separator     string // csv column separator
+}
+
+var ansibleGroupRe = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// ansibleGroup returns valid Ansible group name of the tag or servers file
+func ansibleGroup(name string) string {
+	name = strings.Trim(ansibleGroupRe.ReplaceAllString(strings.TrimSpace(name), "_"), "_")
+	if name == "" {
+		return "ungrouped_"
+	}
+	// group names can't start with a digit
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// exportHostName returns host name usable in inventories and ssh config
+func exportHostName(srv Server) string {
+	return strings.Join(strings.Fields(srv.Host), "_")
+}
+
+// exportKeyPath returns path of the private key as the connection resolves it
+func exportKeyPath(key string) string {
+	if key == "" {
+		return ""
+	}
+	key = CmdParseTemplate(key)
+	if !strings.ContainsAny(key, `/\`) {
+		return "~/.ssh/" + key
+	}
+	return key
+}
+
+// ExportServers writes servers in the selected format
+func ExportServers(w io.Writer, list []Server, opts ExportOptions) error {
+	switch opts.Passwords {
+	case "", PasswordsNone, PasswordsPlain:
+	case PasswordsVault:
+		if opts.Format != ExportAnsibleYAML {
+			return fmt.Errorf("ansible-vault passwords are supported only by %s", ExportAnsibleYAML)
+		}
+		if opts.VaultPassword == "" {
+			return fmt.Errorf("vault password is required")
+		}
+	default:
+		return fmt.Errorf("unknown passwords mode %s, supported: none, plain, vault", opts.Passwords)
+	}
+	switch opts.Format {
+	case ExportSSHConfig:
+		return exportSSHConfig(w, sshServers(list))
+	case ExportAnsibleINI:
+		return exportAnsibleINI(w, sshServers(list), opts)
+	case ExportAnsibleYAML:
+		return exportAnsibleYAML(w, sshServers(list), opts)
+	case ExportCSV:
+		return exportCSV(w, list, opts)
+	case ExportJSON:
+		return exportJSON(w, list, opts)
+	}
+	return fmt.Errorf("unknown export format %s, supported: %s", opts.Format, strings.Join(ExportFormats, ", "))
+}
+
+// sshServers returns only servers reachable by ssh, other types can't be used by ssh or Ansible
+func sshServers(list []Server) []Server {
+	var out []Server
+	for _, srv := range list {
+		if srv.Type == "SSH" || srv.Type == "" {
+			out = append(out, srv)
+		}
+	}
+	if skipped := len(list) - len(out); skipped > 0 {
+		importLog.Infof("Skipping %d servers that are not ssh servers", skipped)
+	}
+	return out
+}
+
+// exportPassword returns password of the server according to the export options
+func exportPassword(srv Server, opts ExportOptions) string {
+	if opts.Passwords != PasswordsPlain && opts.Passwords != PasswordsVault {
+		return ""
+	}
+	return srv.DecryptPassword()
+}
+
+func exportSSHConfig(w io.Writer, list []Server) error {
+	source := ""
+	for _, srv := range list {
+		if srv.SourceName != source {
+			source = srv.SourceName
+			fmt.Fprintf(w, "# %s\n", source)
+		}
+		fmt.Fprintf(w, "Host %s\n", exportHostName(srv))
+		fmt.Fprintf(w, "    HostName %s\n", srv.Address())
+		if srv.User != "" {
+			fmt.Fprintf(w, "    User %s\n", srv.User)
+		}
+		if srv.Port != "" {
+			fmt.Fprintf(w, "    Port %s\n", srv.Port)
+		}
+		if key := exportKeyPath(srv.PrivateKey); key != "" {
+			fmt.Fprintf(w, "    IdentityFile %s\n", key)
+		}
+		if srv.Jump != "" {
+			fmt.Fprintf(w, "    ProxyJump %s\n", srv.Jump)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// ansibleHostVars returns inventory variables of the server
+func ansibleHostVars(srv Server, opts ExportOptions) ([][2]string, error) {
+	vars := [][2]string{{"ansible_host", srv.Address()}}
+	if srv.User != "" {
+		vars = append(vars, [2]string{"ansible_user", srv.User})
+	}
+	if srv.Port != "" {
+		vars = append(vars, [2]string{"ansible_port", srv.Port})
+	}
+	if key := exportKeyPath(srv.PrivateKey); key != "" {
+		vars = append(vars, [2]string{"ansible_ssh_private_key_file", key})
+	}
+	if srv.Jump != "" {
+		vars = append(vars, [2]string{"ansible_ssh_common_args", "-o ProxyJump=" + srv.Jump})
+	}
+	if pass := exportPassword(srv, opts); pass != "" {
+		if opts.Passwords == PasswordsVault {
+			vault, err := ansibleVaultEncrypt([]byte(pass), opts.VaultPassword)
+			if err != nil {
+				return nil, err
+			}
+			pass = vault
+		}
+		vars = append(vars, [2]string{"ansible_password", pass})
+	}
+	return vars, nil
+}
+
+// ansibleGroups returns servers by the servers file group and the tag groups of each file group
+func ansibleGroups(list []Server) (files []string, hosts map[string][]Server, tags map[string]map[string][]string) {
+	hosts = make(map[string][]Server)
+	tags = make(map[string]map[string][]string)
+	for _, srv := range list {
+		file := ansibleGroup(trimYML(srv.SourceName))
+		if _, ok := hosts[file]; !ok {
+			files = append(files, file)
+			tags[file] = make(map[string][]string)
+		}
+		hosts[file] = append(hosts[file], srv)
+		for _, t := range srv.TagsList() {
+			if t == "" {
+				continue
+			}
+			g := ansibleGroup(t)
+			tags[file][g] = append(tags[file][g], exportHostName(srv))
+		}
+	}
+	return files, hosts, tags
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func exportAnsibleINI(w io.Writer, list []Server, opts ExportOptions) error {
+	files, hosts, tags := ansibleGroups(list)
+	// tag groups are shared between the servers files
+	members := make(map[string][]string)
+	for _, file := range files {
+		fmt.Fprintf(w, "[%s]\n", file)
+		for _, srv := range hosts[file] {
+			vars, err := ansibleHostVars(srv, opts)
+			if err != nil {
+				return err
+			}
+			line := exportHostName(srv)
+			for _, v := range vars {
+				line += " " + v[0] + "=" + iniInventoryValue(v[1])
+			}
+			fmt.Fprintln(w, line)
+		}
+		fmt.Fprintln(w)
+		if len(tags[file]) > 0 {
+			fmt.Fprintf(w, "[%s:children]\n", file)
+			for _, g := range sortedKeys(tags[file]) {
+				fmt.Fprintln(w, g)
+				members[g] = append(members[g], tags[file][g]...)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	for _, g := range sortedKeys(members) {
+		fmt.Fprintf(w, "[%s]\n", g)
+		for _, h := range members[g] {
+			fmt.Fprintln(w, h)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// iniInventoryValue quotes values with spaces
+func iniInventoryValue(v string) string {
+	if strings.ContainsAny(v, " \t#'\"") {
+		return "'" + strings.ReplaceAll(v, "'", `\'`) + "'"
+	}
+	return v
+}
+
+func exportAnsibleYAML(w io.Writer, list []Server, opts ExportOptions) error {
+	files, hosts, tags := ansibleGroups(list)
+	children := &yaml.Node{Kind: yaml.MappingNode}
+	for _, file := range files {
+		fileHosts := &yaml.Node{Kind: yaml.MappingNode}
+		for _, srv := range hosts[file] {
+			vars, err := ansibleHostVars(srv, opts)
+			if err != nil {
+				return err
+			}
+			hv := &yaml.Node{Kind: yaml.MappingNode}
+			for _, v := range vars {
+				value := &yaml.Node{Kind: yaml.ScalarNode, Value: v[1]}
+				if strings.HasPrefix(v[1], "$ANSIBLE_VAULT;") {
+					value.Tag = "!vault"
+					value.Style = yaml.LiteralStyle
+				}
+				hv.Content = append(hv.Content, yamlKey(v[0]), value)
+			}
+			fileHosts.Content = append(fileHosts.Content, yamlKey(exportHostName(srv)), hv)
+		}
+		group := &yaml.Node{Kind: yaml.MappingNode}
+		group.Content = append(group.Content, yamlKey("hosts"), fileHosts)
+		if len(tags[file]) > 0 {
+			tagGroups := &yaml.Node{Kind: yaml.MappingNode}
+			for _, g := range sortedKeys(tags[file]) {
+				members := &yaml.Node{Kind: yaml.MappingNode}
+				for _, h := range tags[file][g] {
+					members.Content = append(members.Content, yamlKey(h), &yaml.Node{Kind: yaml.MappingNode})
+				}
+				tg := &yaml.Node{Kind: yaml.MappingNode}
+				tg.Content = append(tg.Content, yamlKey("hosts"), members)
+				tagGroups.Content = append(tagGroups.Content, yamlKey(g), tg)
+			}
+			group.Content = append(group.Content, yamlKey("children"), tagGroups)
+		}
+		children.Content = append(children.Content, yamlKey(file), group)
+	}
+	all := &yaml.Node{Kind: yaml.MappingNode}
+	all.Content = append(all.Content, yamlKey("children"), children)
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	root.Content = append(root.Content, yamlKey("all"), all)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func yamlKey(k string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: k}
+}
+
+// exportRecord is a server in csv and json exports and the output of the server command
+type exportRecord struct {
+	ID          string   `json:"id" yaml:"id"`
+	Host        string   `json:"host" yaml:"host"`
+	IP          string   `json:"ip,omitempty" yaml:"ip,omitempty"`
+	Port        string   `json:"port,omitempty" yaml:"port,omitempty"`
+	User        string   `json:"username,omitempty" yaml:"username,omitempty"`
+	Password    string   `json:"password,omitempty" yaml:"password,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Type        string   `json:"type" yaml:"type"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Group       string   `json:"group,omitempty" yaml:"group,omitempty"`
+	PrivateKey  string   `json:"privatekey,omitempty" yaml:"privatekey,omitempty"`
+	Jump        string   `json:"jump,omitempty" yaml:"jump,omitempty"`
+	Notes       []string `json:"notes,omitempty" yaml:"notes,omitempty"`
+	Source      string   `json:"source" yaml:"source"`
+}
+
+func newExportRecord(srv Server, opts ExportOptions) exportRecord {
+	return exportRecord{
+		ID:          srv.StableID(),
+		Host:        srv.Host,
+		IP:          srv.IP,
+		Port:        srv.Port,
+		User:        srv.User,
+		Password:    exportPassword(srv, opts),
+		Description: srv.Description,
+		Type:        srv.Type,
+		Tags:        srv.TagsList(),
+		Group:       srv.Group,
+		PrivateKey:  srv.PrivateKey,
+		Jump:        srv.Jump,
+		Notes:       srv.Notes,
+		Source:      srv.SourceRel(),
+	}
+}
+
+// exportCSV uses the column names of the csv import, so the file can be imported again,
+// passwords only when they are exported as plain text, the source column is not imported
+func exportCSV(w io.Writer, list []Server, opts ExportOptions) error {
+	cw := csv.NewWriter(w)
+	if opts.Separator != "" {
+		cw.Comma = []rune(opts.Separator)[0]
+	}
+	cw.Write([]string{"hostname", "ip", "port", "username", "password", "description", "type", "tags", "group", "privatekey", "jump", "source"})
+	for _, srv := range list {
+		r := newExportRecord(srv, opts)
+		cw.Write([]string{r.Host, r.IP, r.Port, r.User, r.Password, r.Description, r.Type, strings.Join(r.Tags, ","), r.Group, r.PrivateKey, r.Jump, r.Source})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func exportJSON(w io.Writer, list []Server, opts ExportOptions) error {
+	records := make([]exportRecord, 0, len(list))
+	for _, srv := range list {
+		records = append(records, newExportRecord(srv, opts))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// ansibleVaultEncrypt encrypts the value in the ansible-vault 1.1 format (AES256),
+// the result can be used as !vault tagged variable
+func ansibleVaultEncrypt(plain []byte, password string) (string, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	derived := pbkdf2.Key([]byte(password), salt, 10000, 80, sha256.New)
+	key, hmacKey, iv := derived[:32], derived[32:64], derived[64:80]
+
+	// pkcs7 padding
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	padded := append(append([]byte{}, plain...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCTR(block, iv).XORKeyStream(ciphertext, padded)
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write(ciphertext)
+
+	inner := hex.EncodeToString(salt) + "\n" + hex.EncodeToString(mac.Sum(nil)) + "\n" + hex.EncodeToString(ciphertext)
+	outer := hex.EncodeToString([]byte(inner))
+	var b strings.Builder
+	b.WriteString("$ANSIBLE_VAULT;1.1;AES256")
+	for len(outer) > 0 {
+		n := min(80, len(outer))
+		b.WriteString("\n" + outer[:n])
+		outer = outer[n:]
+	}
+	b.WriteString("\n")
+	return b.String(), nil
+}
+
+// exportServerList returns servers of the servers file, or all servers when source is empty
+func exportServerList(source string) ([]Server, error) {
+	if source == "" {
+		return servers, nil
+	}
+	var list []Server
+	for _, srv := range servers {
+		if srv.SourceName == source || trimYML(srv.SourceName) == source || srv.SourceRel() == source || trimYML(srv.SourceRel()) == source || srv.SourcePath == source {
+			list = append(list, srv)
+		}
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("no servers found in %s", source)
+	}
+	return list, nil
+}
@@ -0,0 +1,172 @@
+package main
+
+/* Server groups
+Servers are organized in the servers file by the group path, e.g. group: "DC1/Rack4/Routers",
+the tray menu, the servers tree and the TUI group navigator are built from the group tree.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// groupSeparator separates the levels of the group path
+const groupSeparator = "/"
+
+// ServerGroup is a node of the group tree
+type ServerGroup struct {
+	Name    string
+	Path    string // full path from the root, e.g. servers/DC1/Rack4
+	Groups  []*ServerGroup
+	Servers []Server
+}
+
+// splitGroup splits the group path to its levels, empty levels are dropped
+func splitGroup(group string) []string {
+	var levels []string
+	for _, l := range strings.Split(group, groupSeparator) {
+		if l = strings.TrimSpace(l); l != "" {
+			levels = append(levels, l)
+		}
+	}
+	return levels
+}
+
+// cleanGroup normalizes the group path, e.g. " DC1 / Rack4/" is DC1/Rack4
+func cleanGroup(group string) string {
+	return strings.Join(splitGroup(group), groupSeparator)
+}
+
+// GroupPath returns levels of the server group, the subfolder of the servers file is the first level
+func (s Server) GroupPath() []string {
+	return append(splitGroup(s.folderGroup()), splitGroup(s.Group)...)
+}
+
+// groupAncestors returns the group and its parents, e.g. DC1, DC1/Rack4, DC1/Rack4/Routers
+func groupAncestors(group string) []string {
+	var list []string
+	levels := splitGroup(group)
+	for i := range levels {
+		list = append(list, strings.Join(levels[:i+1], groupSeparator))
+	}
+	return list
+}
+
+// child returns the subgroup, creates it when it does not exist
+func (g *ServerGroup) child(name string) *ServerGroup {
+	for _, c := range g.Groups {
+		if c.Name == name {
+			return c
+		}
+	}
+	path := name
+	if g.Path != "" {
+		path = g.Path + groupSeparator + name
+	}
+	c := &ServerGroup{Name: name, Path: path}
+	g.Groups = append(g.Groups, c)
+	return c
+}
+
+// Count returns number of the servers in the group and its subgroups
+func (g *ServerGroup) Count() int {
+	n := len(g.Servers)
+	for _, c := range g.Groups {
+		n += c.Count()
+	}
+	return n
+}
+
+// All returns servers of the group and its subgroups
+func (g *ServerGroup) All() []Server {
+	list := append([]Server{}, g.Servers...)
+	for _, c := range g.Groups {
+		list = append(list, c.All()...)
+	}
+	return list
+}
+
+// Find returns the group by its path, nil when it does not exist
+func (g *ServerGroup) Find(path string) *ServerGroup {
+	if path == g.Path {
+		return g
+	}
+	for _, c := range g.Groups {
+		if path == c.Path || strings.HasPrefix(path, c.Path+groupSeparator) {
+			return c.Find(path)
+		}
+	}
+	return nil
+}
+
+func (g *ServerGroup) sort() {
+	sort.SliceStable(g.Groups, func(i, j int) bool { return strings.ToLower(g.Groups[i].Name) < strings.ToLower(g.Groups[j].Name) })
+	for _, c := range g.Groups {
+		c.sort()
+	}
+}
+
+// groupServersBy builds the group tree, paths returns the group paths of the server
+// (more paths put the server to more groups, e.g. tags), servers keep their order
+func groupServersBy(list []Server, paths func(Server) [][]string) *ServerGroup {
+	root := &ServerGroup{}
+	for _, srv := range list {
+		for _, p := range paths(srv) {
+			g := root
+			for _, name := range p {
+				g = g.child(name)
+			}
+			g.Servers = append(g.Servers, srv)
+		}
+	}
+	root.sort()
+	return root
+}
+
+// serverFileName returns name of the servers file without extension
+func serverFileName(srv Server) string {
+	if srv.ReadOnly() {
+		return srv.SourceName
+	}
+	return strings.TrimSuffix(filepath.Base(srv.SourceName), filepath.Ext(srv.SourceName))
+}
+
+// serverGroupTree returns the group tree of the servers: servers files with their groups
+func serverGroupTree(list []Server) *ServerGroup {
+	return groupServersBy(list, func(srv Server) [][]string {
+		return [][]string{append([]string{serverFileName(srv)}, srv.GroupPath()...)}
+	})
+}
+
+// serverGroups returns sorted unique group paths of the servers
+func serverGroups(list []Server) []string {
+	seen := make(map[string]bool)
+	var groups []string
+	for _, srv := range list {
+		for _, g := range groupAncestors(srv.FullGroup()) {
+			if !seen[g] {
+				seen[g] = true
+				groups = append(groups, g)
+			}
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// folderGroups returns the groups inside the subfolder of the servers file without the subfolder,
+// these can be set as the group of the servers of that file
+func folderGroups(groups []string, folder string) []string {
+	if folder == "" {
+		return groups
+	}
+	var list []string
+	for _, g := range groups {
+		if rest, ok := strings.CutPrefix(g, folder+groupSeparator); ok {
+			list = append(list, rest)
+		}
+	}
+	return list
+}
@@ -0,0 +1,747 @@
+package main
+
+/* Servers import
+Converts servers from other tools into Server entries:
+- sshconfig: ~/.ssh/config with Include, Host patterns (defaults from Host * blocks), ProxyJump
+- remmina: .remmina connection files (folder or single file), groups become server groups
+- mremoteng: confCons.xml, folders become server groups (passwords are not imported, they are encrypted)
+- putty-reg: registry export of PuTTY sessions (reg export HKCU\Software\SimonTatham\PuTTY\Sessions)
+- csv, xlsx: columns selected by name or detected from the header row
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v3"
+)
+
+// import formats
+const (
+	ImportSSHConfig = "sshconfig"
+	ImportRemmina   = "remmina"
+	ImportMRemoteNG = "mremoteng"
+	ImportPuttyReg  = "putty-reg"
+	ImportCSV       = "csv"
+	ImportXLSX      = "xlsx"
+)
+
+var ImportFormats = []string{ImportSSHConfig, ImportRemmina, ImportMRemoteNG, ImportPuttyReg, ImportCSV, ImportXLSX}
+
+// CSVColumns are the column names used by the csv and xlsx import,
+// columns not found in the header are detected by the usual names
+type CSVColumns struct {
+	Host, IP, User, Password, Port, Description, Type string
+	Separator                                         string
+	Sheet                                             string // xlsx sheet, the first one when empty
+}
+
+// defaultImportPath returns the usual location of the format, empty when there is none
+func defaultImportPath(format string) string {
+	switch format {
+	case ImportSSHConfig:
+		return filepath.Join(env.homeDir, ".ssh", "config")
+	case ImportRemmina:
+		return filepath.Join(env.homeDir, ".local", "share", "remmina")
+	case ImportMRemoteNG:
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, "mRemoteNG", "confCons.xml")
+		}
+	}
+	return ""
+}
+
+// ImportServers reads servers in the format from the path
+func ImportServers(format, path string, cols CSVColumns) ([]Server, error) {
+	if path == "" {
+		path = defaultImportPath(format)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("file to import is required for %s", format)
+	}
+	var list []Server
+	var err error
+	switch format {
+	case ImportSSHConfig:
+		list, err = importSSHConfig(path)
+	case ImportRemmina:
+		list, err = importRemmina(path)
+	case ImportMRemoteNG:
+		list, err = importMRemoteNG(path)
+	case ImportPuttyReg:
+		list, err = importPuttyReg(path)
+	case ImportCSV:
+		list, err = importCSV(path, cols)
+	case ImportXLSX:
+		list, err = importXLSX(path, cols)
+	default:
+		return nil, fmt.Errorf("unknown import format %s, supported: %s", format, strings.Join(ImportFormats, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	importLog.Infof("Read %d servers from %s (%s)", len(list), path, format)
+	return list, nil
+}
+
+// importType maps protocol name of other tools to the server type, empty for unsupported ones
+func importType(protocol string) string {
+	switch strings.ToLower(strings.TrimSpace(protocol)) {
+	case "ssh", "ssh1", "ssh2", "sftp", "":
+		return "SSH"
+	case "rdp":
+		return "RDP"
+	case "vnc":
+		return "VNC"
+	case "telnet":
+		return "Telnet"
+	case "serial":
+		return "Serial"
+	case "winbox":
+		return "WINBOX"
+	}
+	return ""
+}
+
+// defaultPorts are not stored, the connection command uses them anyway
+var defaultPorts = map[string]string{"SSH": "22", "RDP": "3389", "VNC": "5900", "Telnet": "23"}
+
+func importPort(typ, port string) string {
+	port = strings.TrimSpace(port)
+	if port == "0" || port == defaultPorts[typ] {
+		return ""
+	}
+	return port
+}
+
+// importKey returns key name when it is in ~/.ssh (where the connection looks for it), full path otherwise
+func importKey(key string) string {
+	key = strings.Trim(strings.TrimSpace(key), `"`)
+	if key == "" {
+		return ""
+	}
+	if strings.HasPrefix(key, "~/") {
+		key = filepath.Join(env.homeDir, key[2:])
+	}
+	if filepath.Dir(key) == filepath.Join(env.homeDir, ".ssh") {
+		return filepath.Base(key)
+	}
+	return key
+}
+
+// puttyKey returns the file name of the PuTTY session key, the key is looked up in ~/.ssh
+// like the keys of the other servers
+func puttyKey(key string) string {
+	key = strings.TrimSpace(strings.ReplaceAll(key, `\`, "/"))
+	if key == "" {
+		return ""
+	}
+	return path.Base(key)
+}
+
+// splitHostPort splits host[:port], IPv6 addresses can be in brackets
+func splitHostPort(addr string) (string, string) {
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		return host, port
+	}
+	return strings.Trim(addr, "[]"), ""
+}
+
+// joinTags joins unique non empty tags
+func joinTags(tags ...string) string {
+	var list []string
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		list = append(list, t)
+	}
+	return strings.Join(list, ",")
+}
+
+// sshConfigBlock is a Host block of the ssh config, options keep the first value of each key
+type sshConfigBlock struct {
+	patterns []string
+	options  map[string]string
+	source   string
+}
+
+func (b *sshConfigBlock) matches(host string) bool {
+	host = strings.ToLower(host)
+	matched := false
+	for _, p := range b.patterns {
+		negated := strings.HasPrefix(p, "!")
+		ok, _ := path.Match(strings.ToLower(strings.TrimPrefix(p, "!")), host)
+		if ok && negated {
+			return false
+		}
+		matched = matched || (ok && !negated)
+	}
+	return matched
+}
+
+type sshConfigParser struct {
+	blocks []*sshConfigBlock
+	main   string
+}
+
+func importSSHConfig(file string) ([]Server, error) {
+	p := &sshConfigParser{main: file}
+	// options before the first Host apply to all hosts
+	global := &sshConfigBlock{patterns: []string{"*"}, options: make(map[string]string), source: file}
+	p.blocks = append(p.blocks, global)
+	if err := p.parse(file, global, 0); err != nil {
+		return nil, err
+	}
+	var list []Server
+	seen := make(map[string]bool)
+	for _, b := range p.blocks {
+		for _, alias := range b.patterns {
+			if strings.ContainsAny(alias, "*?!") || seen[alias] {
+				continue
+			}
+			seen[alias] = true
+			list = append(list, p.server(alias, b.source))
+		}
+	}
+	return list, nil
+}
+
+// server resolves options of the host the same way ssh does, the first obtained value wins
+func (p *sshConfigParser) server(alias, source string) Server {
+	opts := make(map[string]string)
+	for _, b := range p.blocks {
+		if !b.matches(alias) {
+			continue
+		}
+		for k, v := range b.options {
+			if _, ok := opts[k]; !ok {
+				opts[k] = v
+			}
+		}
+	}
+	srv := Server{Host: alias, Type: "SSH", User: opts["user"], PrivateKey: importKey(opts["identityfile"])}
+	// ssh connects to the alias itself when HostName is not set
+	srv.IP = alias
+	if hostname := opts["hostname"]; hostname != "" {
+		srv.IP = strings.ReplaceAll(hostname, "%h", alias)
+	}
+	srv.Port = importPort("SSH", opts["port"])
+	if jump := opts["proxyjump"]; jump != "" && !strings.EqualFold(jump, "none") {
+		srv.Jump = jump
+	} else if strings.Contains(opts["proxycommand"], "-W") {
+		// ssh -W %h:%p jumphost or ssh jumphost -W %h:%p
+		for _, f := range strings.Fields(opts["proxycommand"])[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "%") {
+				srv.Jump = f
+			}
+		}
+	}
+	// hosts from included files are tagged with the file name, e.g. config.d/production
+	if source != p.main {
+		srv.Tags = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	}
+	return srv
+}
+
+func (p *sshConfigParser) parse(file string, current *sshConfigBlock, depth int) error {
+	if depth > 16 {
+		return fmt.Errorf("too many nested includes in %s", file)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value := line, ""
+		if i := strings.IndexAny(line, " \t="); i > 0 {
+			key = line[:i]
+			value = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line[i:]), "="))
+		}
+		key = strings.ToLower(key)
+		switch key {
+		case "host":
+			current = &sshConfigBlock{patterns: strings.Fields(value), options: make(map[string]string), source: file}
+			p.blocks = append(p.blocks, current)
+		case "match":
+			// conditional blocks can't be evaluated without connecting, never match
+			current = &sshConfigBlock{options: make(map[string]string), source: file}
+			p.blocks = append(p.blocks, current)
+		case "include":
+			for _, pattern := range strings.Fields(value) {
+				if strings.HasPrefix(pattern, "~/") {
+					pattern = filepath.Join(env.homeDir, pattern[2:])
+				} else if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(env.homeDir, ".ssh", pattern)
+				}
+				matches, _ := filepath.Glob(pattern)
+				sort.Strings(matches)
+				for _, m := range matches {
+					if err := p.parse(m, current, depth+1); err != nil {
+						importLog.Warnf("Unable to read included %s: %s", m, err)
+					}
+				}
+			}
+		default:
+			if _, ok := current.options[key]; !ok {
+				current.options[key] = strings.Trim(value, `"`)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+func importRemmina(source string) ([]Server, error) {
+	files := []string{source}
+	if fi, err := os.Stat(source); err != nil {
+		return nil, err
+	} else if fi.IsDir() {
+		files, _ = filepath.Glob(filepath.Join(source, "*.remmina"))
+		sort.Strings(files)
+	}
+	var list []Server
+	for _, f := range files {
+		cfg, err := ini.Load(f)
+		if err != nil {
+			importLog.Warnf("Unable to read %s: %s", f, err)
+			continue
+		}
+		sec := cfg.Section("remmina")
+		typ := importType(sec.Key("protocol").String())
+		if typ == "" {
+			importLog.Warnf("Skipping %s, protocol %s is not supported", f, sec.Key("protocol").String())
+			continue
+		}
+		host, port := splitHostPort(sec.Key("server").String())
+		srv := Server{
+			Host: sec.Key("name").String(),
+			IP:   host,
+			Type: typ,
+			Port: importPort(typ, port),
+			User: sec.Key("username").String(),
+			// nested groups are separated by /
+			Group:      cleanGroup(sec.Key("group").String()),
+			PrivateKey: importKey(sec.Key("ssh_privatekey").String()),
+		}
+		if srv.User == "" {
+			srv.User = sec.Key("ssh_username").String()
+		}
+		if srv.Host == "" {
+			srv.Host = host
+		}
+		if sec.Key("ssh_tunnel_enabled").MustBool() && sec.Key("ssh_tunnel_server").String() != "" {
+			srv.Jump = sec.Key("ssh_tunnel_server").String()
+			if user := sec.Key("ssh_tunnel_username").String(); user != "" {
+				srv.Jump = user + "@" + srv.Jump
+			}
+		}
+		list = append(list, srv)
+	}
+	return list, nil
+}
+
+type mremotengNode struct {
+	Name     string          `xml:"Name,attr"`
+	Type     string          `xml:"Type,attr"`
+	Descr    string          `xml:"Descr,attr"`
+	Hostname string          `xml:"Hostname,attr"`
+	Port     string          `xml:"Port,attr"`
+	Username string          `xml:"Username,attr"`
+	Protocol string          `xml:"Protocol,attr"`
+	Nodes    []mremotengNode `xml:"Node"`
+}
+
+type mremotengFile struct {
+	FullFileEncryption bool            `xml:"FullFileEncryption,attr"`
+	Nodes              []mremotengNode `xml:"Node"`
+}
+
+func importMRemoteNG(file string) ([]Server, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var doc mremotengFile
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid mRemoteNG file %s: %w", file, err)
+	}
+	if doc.FullFileEncryption {
+		return nil, fmt.Errorf("%s is fully encrypted, disable the full file encryption in mRemoteNG and export it again", file)
+	}
+	var list []Server
+	var walk func(nodes []mremotengNode, folders []string)
+	walk = func(nodes []mremotengNode, folders []string) {
+		for _, n := range nodes {
+			if n.Type == "Container" {
+				walk(n.Nodes, append(folders, n.Name))
+				continue
+			}
+			typ := importType(n.Protocol)
+			if typ == "" || n.Hostname == "" {
+				importLog.Warnf("Skipping %s, protocol %s is not supported", n.Name, n.Protocol)
+				continue
+			}
+			list = append(list, Server{
+				Host:        n.Name,
+				IP:          n.Hostname,
+				Type:        typ,
+				Port:        importPort(typ, n.Port),
+				User:        n.Username,
+				Description: n.Descr,
+				Group:       strings.Join(folders, groupSeparator),
+			})
+		}
+	}
+	walk(doc.Nodes, nil)
+	return list, nil
+}
+
+var (
+	puttySessionRe = regexp.MustCompile(`(?i)^\[HKEY_CURRENT_USER\\Software\\SimonTatham\\PuTTY\\Sessions\\(.+)\]$`)
+	regValueRe     = regexp.MustCompile(`^"((?:[^"\\]|\\.)*)"=(.*)$`)
+)
+
+// readRegFile returns lines of the .reg file, regedit exports are UTF-16
+func readRegFile(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE {
+		u := make([]uint16, 0, len(data)/2)
+		for i := 2; i+1 < len(data); i += 2 {
+			u = append(u, uint16(data[i])|uint16(data[i+1])<<8)
+		}
+		data = []byte(string(utf16.Decode(u)))
+	}
+	return strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), nil
+}
+
+// regValue decodes "string" and dword:0000001 values, other types are returned as they are
+func regValue(raw string) string {
+	if strings.HasPrefix(raw, `"`) && strings.HasSuffix(raw, `"`) && len(raw) >= 2 {
+		raw = raw[1 : len(raw)-1]
+		return strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(raw)
+	}
+	if hex, ok := strings.CutPrefix(raw, "dword:"); ok {
+		if v, err := strconv.ParseUint(hex, 16, 32); err == nil {
+			return strconv.FormatUint(v, 10)
+		}
+	}
+	return raw
+}
+
+func importPuttyReg(file string) ([]Server, error) {
+	lines, err := readRegFile(file)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make(map[string]map[string]string)
+	var order []string
+	var current map[string]string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			current = nil
+			if m := puttySessionRe.FindStringSubmatch(line); m != nil && !strings.Contains(m[1], `\`) {
+				name := m[1]
+				// session names are url encoded by putty
+				if u, err := url.PathUnescape(name); err == nil {
+					name = u
+				}
+				current = make(map[string]string)
+				sessions[name] = current
+				order = append(order, name)
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if m := regValueRe.FindStringSubmatch(line); m != nil {
+			current[m[1]] = regValue(m[2])
+		}
+	}
+	var list []Server
+	for _, name := range order {
+		v := sessions[name]
+		if name == "Default Settings" {
+			continue
+		}
+		typ := importType(v["Protocol"])
+		if typ == "" {
+			importLog.Warnf("Skipping session %s, protocol %s is not supported", name, v["Protocol"])
+			continue
+		}
+		host := v["HostName"]
+		if typ == "Serial" {
+			host = v["SerialLine"]
+		}
+		if host == "" {
+			continue
+		}
+		srv := Server{Host: name, Type: typ, User: v["UserName"], PrivateKey: puttyKey(v["PublicKeyFile"])}
+		if user, h, ok := strings.Cut(host, "@"); ok {
+			srv.User, host = user, h
+		}
+		srv.IP = host
+		if typ != "Serial" {
+			srv.Port = importPort(typ, v["PortNumber"])
+		}
+		// 6-8 are ssh proxy types (putty 0.77+)
+		switch v["ProxyMethod"] {
+		case "6", "7", "8":
+			srv.Jump = v["ProxyHost"]
+			if v["ProxyUsername"] != "" {
+				srv.Jump = v["ProxyUsername"] + "@" + srv.Jump
+			}
+			if p := v["ProxyPort"]; p != "" && p != "22" {
+				srv.Jump += ":" + p
+			}
+		}
+		list = append(list, srv)
+	}
+	return list, nil
+}
+
+func importCSV(file string, cols CSVColumns) ([]Server, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	if cols.Separator != "" {
+		r.Comma = []rune(cols.Separator)[0]
+	}
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return importTable(file, records, cols)
+}
+
+func importXLSX(file string, cols CSVColumns) ([]Server, error) {
+	sheets, err := ReadXLSX(file)
+	if err != nil {
+		return nil, err
+	}
+	for _, sheet := range sheets {
+		if cols.Sheet == "" || strings.EqualFold(sheet.Name, cols.Sheet) {
+			return importTable(file, sheet.Rows, cols)
+		}
+	}
+	return nil, fmt.Errorf("sheet %s not found in %s", cols.Sheet, file)
+}
+
+// importTable converts rows with the header row to servers
+func importTable(file string, records [][]string, cols CSVColumns) ([]Server, error) {
+	if len(records) < 2 {
+		return nil, nil
+	}
+	mapping := DetectColumns(records[0])
+	named := map[string]string{
+		FieldHost: cols.Host, FieldIP: cols.IP, FieldUser: cols.User, FieldPassword: cols.Password,
+		FieldPort: cols.Port, FieldDescription: cols.Description, FieldType: cols.Type,
+	}
+	for i, name := range records[0] {
+		for field, col := range named {
+			if col != "" && strings.EqualFold(strings.TrimSpace(name), col) {
+				mapping[field] = i
+			}
+		}
+	}
+	_, hasHost := mapping[FieldHost]
+	_, hasIP := mapping[FieldIP]
+	if !hasHost && !hasIP {
+		return nil, fmt.Errorf("neither %s nor %s column found in %s", cols.Host, cols.IP, file)
+	}
+	return ServersFromRows(records[1:], mapping), nil
+}
+
+// server fields the table columns can be mapped to
+const (
+	FieldHost        = "host"
+	FieldIP          = "ip"
+	FieldUser        = "user"
+	FieldPassword    = "password"
+	FieldPort        = "port"
+	FieldType        = "type"
+	FieldDescription = "description"
+	FieldTags        = "tags"
+	FieldGroup       = "group"
+	FieldJump        = "jump"
+	FieldPrivateKey  = "privatekey"
+)
+
+var ImportFields = []string{FieldHost, FieldIP, FieldUser, FieldPassword, FieldPort, FieldType, FieldDescription, FieldTags, FieldGroup, FieldJump, FieldPrivateKey}
+
+// importFieldAliases are the header names of the fields, compared in lower case without spaces, - and _
+var importFieldAliases = map[string][]string{
+	FieldHost:        {"host", "hostname", "name", "server", "servername", "alias", "label"},
+	FieldIP:          {"ip", "ipaddress", "address", "addr", "ipv4", "fqdn"},
+	FieldUser:        {"user", "username", "login", "account"},
+	FieldPassword:    {"password", "pass", "passwd", "pwd"},
+	FieldPort:        {"port"},
+	FieldType:        {"type", "protocol", "proto", "connection"},
+	FieldDescription: {"description", "desc", "comment", "comments", "note", "notes"},
+	FieldTags:        {"tags", "tag", "environment", "env"},
+	FieldGroup:       {"group", "groups", "folder", "path", "location"},
+	FieldJump:        {"jump", "jumphost", "bastion", "proxyjump", "gateway"},
+	FieldPrivateKey:  {"privatekey", "key", "identityfile", "sshkey"},
+}
+
+func columnKey(name string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "", ".", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+// DetectColumns maps server fields to the columns of the header row by their names
+func DetectColumns(header []string) map[string]int {
+	mapping := make(map[string]int)
+	for i, name := range header {
+		key := columnKey(name)
+	fields:
+		for _, field := range ImportFields {
+			if _, ok := mapping[field]; ok {
+				continue
+			}
+			for _, alias := range importFieldAliases[field] {
+				if key == alias {
+					mapping[field] = i
+					break fields
+				}
+			}
+		}
+	}
+	return mapping
+}
+
+// DetectAddressColumn returns column which values are mostly IP addresses, -1 when there is none,
+// used when the header has no known address column name
+func DetectAddressColumn(rows [][]string) int {
+	counts := make(map[int]int)
+	total := 0
+	for _, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		total++
+		for i, v := range row {
+			if net.ParseIP(strings.TrimSpace(v)) != nil {
+				counts[i]++
+			}
+		}
+	}
+	best := -1
+	for i, n := range counts {
+		if n*2 > total && (best < 0 || n > counts[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// ServersFromRows converts table rows to servers by the mapping, rows without host and address are skipped
+func ServersFromRows(rows [][]string, mapping map[string]int) []Server {
+	var list []Server
+	for _, rec := range rows {
+		get := func(field string) string {
+			if i, ok := mapping[field]; ok && i >= 0 && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		typ := importType(get(FieldType))
+		if typ == "" {
+			typ = "SSH"
+		}
+		srv := Server{
+			Host:        get(FieldHost),
+			IP:          get(FieldIP),
+			User:        get(FieldUser),
+			Type:        typ,
+			Port:        importPort(typ, get(FieldPort)),
+			Description: get(FieldDescription),
+			Tags:        joinTags(strings.FieldsFunc(get(FieldTags), func(r rune) bool { return r == ',' || r == ';' })...),
+			Group:       cleanGroup(strings.ReplaceAll(get(FieldGroup), "\\", groupSeparator)),
+			Jump:        get(FieldJump),
+			PrivateKey:  importKey(get(FieldPrivateKey)),
+		}
+		if srv.Host == "" {
+			srv.Host = srv.IP
+		}
+		if srv.Host == "" {
+			continue
+		}
+		// plain text, encrypted with the key of the target file when written
+		srv.Password = get(FieldPassword)
+		list = append(list, srv)
+	}
+	return list
+}
+
+// isDuplicateServer returns true when the server already exists in the list
+func isDuplicateServer(list []Server, srv Server) bool {
+	for _, s := range list {
+		if strings.EqualFold(s.Host, srv.Host) ||
+			(srv.IP != "" && s.IP == srv.IP && s.Port == srv.Port && s.User == srv.User) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendServersToFile adds servers to the servers file, the file is created when it does not exist
+func appendServersToFile(file string, add []Server) error {
+	var list []Server
+	if data, err := os.ReadFile(file); err == nil {
+		if err := yaml.Unmarshal(data, &list); err != nil {
+			return fmt.Errorf("unable to parse %s: %w", file, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	for _, srv := range add {
+		srv.SourcePath = file
+		srv.SourceName = filepath.Base(file)
+		// imported passwords are plain text, encrypt them with the key of the file
+		srv.Password = srv.EncryptPassword(srv.Password)
+		list = append(list, srv)
+	}
+	data, err := yaml.Marshal(list)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
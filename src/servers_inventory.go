@@ -0,0 +1,540 @@
+package main
+
+/* Dynamic inventory
+Servers from inventory providers configured in settings.ini, cached for the TTL in ~/.config/conan/cache:
+  [inventory cloud]
+  type   = exec            ; exec, http, ansible or dns
+  source = /usr/local/bin/cloud-hosts --json
+  ttl    = 300             ; seconds
+Inventory servers are read-only, every inventory is its own source (inventory:cloud) and servers
+of the yml files with the same host take precedence, so credentials can be added to dynamic hosts.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gopkg.in/ini.v1"
+)
+
+// inventory provider types
+const (
+	InventoryExec    = "exec"
+	InventoryHTTP    = "http"
+	InventoryAnsible = "ansible"
+	InventoryDNS     = "dns"
+)
+
+var InventoryTypes = []string{InventoryExec, InventoryHTTP, InventoryAnsible, InventoryDNS}
+
+const (
+	inventorySection      = "inventory " // settings.ini section prefix
+	inventorySourcePrefix = "inventory:" // source name of the inventory servers
+	inventoryDefaultTTL   = 300          // seconds
+	inventoryTimeout      = 60 * time.Second
+)
+
+// InventoryConfig is the inventory provider from settings.ini
+type InventoryConfig struct {
+	Name       string
+	Type       string        // exec, http, ansible or dns
+	Source     string        // command, URL or file
+	TTL        time.Duration // servers are fetched again when the cache is older
+	Header     string        // http header, e.g. "Authorization: Bearer xyz"
+	ServerType string        // type of the servers without type, default SSH
+	User       string        // user of the servers without user
+	Tags       string        // tags added to all the servers
+	Origin     string        // origin of the dns zone without $ORIGIN
+}
+
+var inventories []InventoryConfig
+
+// inventoryCache is the cached result of the inventory
+type inventoryCache struct {
+	Fetched time.Time `json:"fetched"`
+	Servers []Server  `json:"servers"`
+}
+
+var (
+	inventoryMu       sync.Mutex
+	inventoryCached   = make(map[string]inventoryCache)
+	inventoryFailed   = make(map[string]time.Time) // failed inventories are not fetched again before the TTL
+	inventoryFetching = make(map[string]bool)      // inventories fetched in the background
+	inventoryFetched  func()                       // called when the inventory was fetched in the background
+	inventoryMissed   bool                         // inventory was fetched before inventoryFetched was set
+	// inventoryWait fetches the stale inventories before returning their servers, used by the commands
+	// that exit before the background fetch finishes
+	inventoryWait bool
+)
+
+// inventoryFromSection reads the inventory from its settings.ini section
+func inventoryFromSection(section *ini.Section) InventoryConfig {
+	inv := InventoryConfig{
+		Name:       strings.TrimSpace(strings.TrimPrefix(section.Name(), inventorySection)),
+		Type:       strings.ToLower(section.Key("type").String()),
+		Source:     section.Key("source").String(),
+		TTL:        time.Duration(section.Key("ttl").MustInt(inventoryDefaultTTL)) * time.Second,
+		Header:     section.Key("header").String(),
+		ServerType: section.Key("server_type").String(),
+		User:       section.Key("user").String(),
+		Tags:       section.Key("tags").String(),
+		Origin:     section.Key("origin").String(),
+	}
+	if strings.HasPrefix(inv.Source, "~/") {
+		inv.Source = filepath.Join(env.homeDir, inv.Source[2:])
+	}
+	return inv
+}
+
+// findInventory returns the inventory by its name
+func findInventory(name string) (InventoryConfig, bool) {
+	name = strings.TrimPrefix(name, inventorySourcePrefix)
+	for _, inv := range inventories {
+		if inv.Name == name {
+			return inv, true
+		}
+	}
+	return InventoryConfig{}, false
+}
+
+// ReadOnly returns true for servers of the dynamic inventory, they are not written to the yml files
+func (s Server) ReadOnly() bool {
+	return s.Inventory != ""
+}
+
+// errReadOnly is returned when the inventory server is changed
+func errReadOnly(srv Server) error {
+	return fmt.Errorf("server %s comes from the inventory %s and is read-only", srv.Host, srv.Inventory)
+}
+
+func (inv InventoryConfig) cacheFile() string {
+	name := regexp.MustCompile(`[^A-Za-z0-9_.-]+`).ReplaceAllString(inv.Name, "_")
+	return filepath.Join(env.configDir, "cache", "inventory-"+name+".json")
+}
+
+func (inv InventoryConfig) loadCache() (inventoryCache, bool) {
+	inventoryMu.Lock()
+	defer inventoryMu.Unlock()
+	if c, ok := inventoryCached[inv.Name]; ok {
+		return c, true
+	}
+	data, err := os.ReadFile(inv.cacheFile())
+	if err != nil {
+		return inventoryCache{}, false
+	}
+	var c inventoryCache
+	if err := json.Unmarshal(data, &c); err != nil {
+		inventoryLog.Warnf("Ignoring broken cache of the inventory %s: %s", inv.Name, err)
+		return inventoryCache{}, false
+	}
+	inventoryCached[inv.Name] = c
+	return c, true
+}
+
+func (inv InventoryConfig) saveCache(c inventoryCache) {
+	inventoryMu.Lock()
+	inventoryCached[inv.Name] = c
+	inventoryMu.Unlock()
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(inv.cacheFile()), 0700); err != nil {
+		inventoryLog.Warnf("Unable to create the cache folder: %s", err)
+		return
+	}
+	if err := os.WriteFile(inv.cacheFile(), data, 0600); err != nil {
+		inventoryLog.Warnf("Unable to write cache of the inventory %s: %s", inv.Name, err)
+	}
+}
+
+// failedRecently returns true when the inventory could not be fetched within the TTL
+func (inv InventoryConfig) failedRecently() bool {
+	inventoryMu.Lock()
+	defer inventoryMu.Unlock()
+	failed, ok := inventoryFailed[inv.Name]
+	return ok && time.Since(failed) < inv.TTL
+}
+
+// Servers returns servers of the inventory from the cache when it is not older than TTL or the inventory
+// failed within the TTL (refresh ignores both), the older cache is returned together with the error
+// when the inventory can't be fetched
+func (inv InventoryConfig) Servers(refresh bool) ([]Server, time.Time, error) {
+	cache, cached := inv.loadCache()
+	if !refresh && (cached && time.Since(cache.Fetched) < inv.TTL || inv.failedRecently()) {
+		if !cached {
+			return nil, time.Time{}, nil
+		}
+		return inv.sourced(cache.Servers), cache.Fetched, nil
+	}
+	list, err := inv.fetch()
+	inventoryMu.Lock()
+	if err != nil {
+		inventoryFailed[inv.Name] = time.Now()
+	} else {
+		delete(inventoryFailed, inv.Name)
+	}
+	inventoryMu.Unlock()
+	if err != nil {
+		err = fmt.Errorf("inventory %s: %w", inv.Name, err)
+		if cached {
+			return inv.sourced(cache.Servers), cache.Fetched, err
+		}
+		return nil, time.Time{}, err
+	}
+	list = inv.normalize(list)
+	cache = inventoryCache{Fetched: time.Now(), Servers: list}
+	inv.saveCache(cache)
+	inventoryLog.Infof("Inventory %s: %d servers fetched", inv.Name, len(list))
+	return inv.sourced(list), cache.Fetched, nil
+}
+
+// cachedServers returns servers of the inventory from the cache without waiting for the inventory,
+// the stale inventory is fetched in the background and inventoryFetched is called when it is done
+func (inv InventoryConfig) cachedServers() []Server {
+	cache, cached := inv.loadCache()
+	if cached && time.Since(cache.Fetched) < inv.TTL || inv.failedRecently() {
+		return inv.sourced(cache.Servers)
+	}
+	inventoryMu.Lock()
+	fetching := inventoryFetching[inv.Name]
+	inventoryFetching[inv.Name] = true
+	inventoryMu.Unlock()
+	if !fetching {
+		go func() {
+			_, _, err := inv.Servers(true)
+			inventoryMu.Lock()
+			delete(inventoryFetching, inv.Name)
+			done := inventoryFetched
+			if err == nil && done == nil {
+				inventoryMissed = true
+			}
+			inventoryMu.Unlock()
+			if err != nil {
+				inventoryLog.Warnf("%s", err)
+				return
+			}
+			if done != nil {
+				done()
+			}
+		}()
+	}
+	return inv.sourced(cache.Servers)
+}
+
+// onInventoryFetched sets the function called when the inventory was fetched in the background,
+// returns true when an inventory was fetched before, the caller has to reload the servers itself
+func onInventoryFetched(fn func()) bool {
+	inventoryMu.Lock()
+	defer inventoryMu.Unlock()
+	inventoryFetched = fn
+	missed := inventoryMissed
+	inventoryMissed = false
+	return missed
+}
+
+func (inv InventoryConfig) fetch() ([]Server, error) {
+	if strings.TrimSpace(inv.Source) == "" {
+		return nil, fmt.Errorf("source is not set")
+	}
+	switch inv.Type {
+	case InventoryExec:
+		data, err := inventoryExec(inv.Source)
+		if err != nil {
+			return nil, err
+		}
+		return parseInventoryJSON(data)
+	case InventoryHTTP:
+		data, err := inventoryHTTP(inv.Source, inv.Header)
+		if err != nil {
+			return nil, err
+		}
+		return parseInventoryJSON(data)
+	case InventoryAnsible:
+		data, err := os.ReadFile(inv.Source)
+		if err != nil {
+			return nil, err
+		}
+		return parseAnsibleInventory(inv.Source, data)
+	case InventoryDNS:
+		data, err := os.ReadFile(inv.Source)
+		if err != nil {
+			return nil, err
+		}
+		return parseZoneFile(data, inv.Origin)
+	}
+	return nil, fmt.Errorf("unknown type %q, supported: %s", inv.Type, strings.Join(InventoryTypes, ", "))
+}
+
+// normalize applies the defaults of the inventory and drops servers without host or with unknown type
+func (inv InventoryConfig) normalize(list []Server) []Server {
+	var out []Server
+	for _, srv := range list {
+		if srv.Host == "" {
+			srv.Host = srv.IP
+		}
+		if srv.Host == "" {
+			continue
+		}
+		if srv.IP == "" {
+			srv.IP = srv.Host
+		}
+		if srv.Type == "" {
+			srv.Type = inv.ServerType
+		}
+		typ := importType(srv.Type)
+		if typ == "" {
+			inventoryLog.Warnf("Inventory %s: skipping %s, type %s is not supported", inv.Name, srv.Host, srv.Type)
+			continue
+		}
+		srv.Type = typ
+		if srv.User == "" {
+			srv.User = inv.User
+		}
+		srv.Tags = joinTags(append(srv.TagsList(), strings.Split(inv.Tags, ",")...)...)
+		srv.Group = cleanGroup(srv.Group)
+		// inventories never carry secrets
+		srv.Password, srv.TOTP = "", ""
+		if !inv.validate(&srv) {
+			continue
+		}
+		srv.Port = importPort(typ, srv.Port)
+		out = append(out, srv)
+	}
+	return out
+}
+
+// unsafeValue returns true for values that could pass options or more arguments to the connect command
+func unsafeValue(v string) bool {
+	if strings.HasPrefix(v, "-") {
+		return true
+	}
+	for _, r := range v {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripControl removes control characters of the free text fields
+func stripControl(v string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, v)
+}
+
+// validate checks fields of the inventory server, fields used in the connect command are dropped
+// when they are not valid (the server is skipped without valid host or address), returns false to skip it
+func (inv InventoryConfig) validate(srv *Server) bool {
+	drop := func(field string, value *string) {
+		inventoryLog.Warnf("Inventory %s: dropping %s %q of %s, the value is not valid", inv.Name, field, *value, srv.Host)
+		*value = ""
+	}
+	if unsafeValue(srv.Host) || !validAddress(srv.Host) {
+		inventoryLog.Warnf("Inventory %s: skipping server, host %q is not valid", inv.Name, srv.Host)
+		return false
+	}
+	switch {
+	case unsafeValue(srv.IP):
+		drop("ip", &srv.IP)
+	case srv.Type == "Serial":
+		// device name, e.g. /dev/ttyUSB0 or COM3
+	case srv.Type == "WINBOX" && func() bool { _, err := net.ParseMAC(srv.IP); return err == nil }():
+		// winbox connects by MAC address too
+	case !validAddress(srv.IP):
+		drop("ip", &srv.IP)
+	}
+	if srv.IP == "" {
+		srv.IP = srv.Host
+	}
+	if srv.Port != "" && (unsafeValue(srv.Port) || !validPort(srv.Port)) {
+		drop("port", &srv.Port)
+	}
+	if srv.Jump != "" && (unsafeValue(srv.Jump) || !validJump(srv.Jump)) {
+		drop("jump", &srv.Jump)
+	}
+	if srv.User != "" && (unsafeValue(srv.User) || strings.ContainsAny(srv.User, "@:")) {
+		drop("username", &srv.User)
+	}
+	if srv.PrivateKey != "" && unsafeValue(srv.PrivateKey) {
+		drop("privatekey", &srv.PrivateKey)
+	}
+	srv.Description = stripControl(srv.Description)
+	srv.Tags = stripControl(srv.Tags)
+	srv.Group = stripControl(srv.Group)
+	return true
+}
+
+// sourced marks the servers as servers of the inventory
+func (inv InventoryConfig) sourced(list []Server) []Server {
+	out := make([]Server, len(list))
+	for i, srv := range list {
+		srv.ID = uuid.NewString()
+		srv.SourcePath = ""
+		srv.SourceName = inventorySourcePrefix + inv.Name
+		srv.Inventory = inv.Name
+		out[i] = srv
+	}
+	return out
+}
+
+// mergeInventoryServers appends servers of the inventories to the servers of the yml files,
+// servers of the yml files with the same host take precedence, the cached servers are used
+// while the stale inventories are fetched in the background (unless inventoryWait is set)
+func mergeInventoryServers(static []Server) []Server {
+	if len(inventories) == 0 {
+		return static
+	}
+	hosts := make(map[string]bool)
+	for _, srv := range static {
+		hosts[strings.ToLower(srv.Host)] = true
+	}
+	merged := static
+	for _, inv := range inventories {
+		var list []Server
+		if inventoryWait {
+			var err error
+			if list, _, err = inv.Servers(false); err != nil {
+				inventoryLog.Warnf("%s", err)
+			}
+		} else {
+			list = inv.cachedServers()
+		}
+		for _, srv := range list {
+			if hosts[strings.ToLower(srv.Host)] {
+				continue
+			}
+			hosts[strings.ToLower(srv.Host)] = true
+			merged = append(merged, srv)
+		}
+	}
+	return merged
+}
+
+// inventoryExec runs the command and returns its output
+func inventoryExec(command string) ([]byte, error) {
+	args := strings.Fields(command)
+	ctx, cancel := context.WithTimeout(context.Background(), inventoryTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// inventoryHTTP downloads the inventory, header is "Name: value"
+func inventoryHTTP(url, header string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if name, value, ok := strings.Cut(header, ":"); ok {
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	client := &http.Client{Timeout: inventoryTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+}
+
+// parseInventoryJSON reads list of servers (the format of conan export --format json)
+// or Ansible dynamic inventory JSON (groups with _meta.hostvars)
+func parseInventoryJSON(data []byte) ([]Server, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		return parseAnsibleJSON(data)
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("expected list of servers or Ansible inventory JSON: %w", err)
+	}
+	var list []Server
+	for _, r := range records {
+		get := func(keys ...string) string {
+			for _, k := range keys {
+				if v, ok := r[k]; ok && v != nil {
+					return strings.TrimSpace(inventoryValue(v))
+				}
+			}
+			return ""
+		}
+		list = append(list, Server{
+			Host:        get("host", "name", "hostname"),
+			IP:          get("ip", "address"),
+			User:        get("username", "user"),
+			Port:        get("port"),
+			Type:        get("type"),
+			Description: get("description"),
+			Tags:        get("tags"),
+			Group:       get("group"),
+			PrivateKey:  get("privatekey"),
+			Jump:        get("jump"),
+		})
+	}
+	return list, nil
+}
+
+// inventoryValue returns the JSON value as string, lists are joined with commas
+func inventoryValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return fmt.Sprintf("%g", t)
+	case []interface{}:
+		var items []string
+		for _, i := range t {
+			items = append(items, inventoryValue(i))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// refreshInventories fetches the inventories again ignoring the TTL, all of them when no names are given
+func refreshInventories(names ...string) []error {
+	var errs []error
+	for _, inv := range inventories {
+		if len(names) > 0 && !FindInArray(names, inv.Name) {
+			continue
+		}
+		if _, _, err := inv.Servers(true); err != nil {
+			inventoryLog.Warnf("%s", err)
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,430 @@
+package main
+
+/* Inventory formats
+Ansible inventories (INI, YAML and dynamic inventory JSON) and DNS zone files read by the inventory providers.
+Ansible groups become tags of the servers, A, AAAA and CNAME records of the zone become servers.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ansibleInventory collects hosts, groups and variables of the Ansible inventory
+type ansibleInventory struct {
+	hosts     []string                     // in the order of the inventory
+	hostVars  map[string]map[string]string // variables of the hosts
+	hostGroup map[string][]string          // groups the host is member of
+	groupVars map[string]map[string]string
+	parents   map[string][]string // parent groups of the group
+}
+
+func newAnsibleInventory() *ansibleInventory {
+	return &ansibleInventory{
+		hostVars:  make(map[string]map[string]string),
+		hostGroup: make(map[string][]string),
+		groupVars: make(map[string]map[string]string),
+		parents:   make(map[string][]string),
+	}
+}
+
+// addHost adds the host to the group, vars are merged
+func (a *ansibleInventory) addHost(host, group string, vars map[string]string) {
+	if _, ok := a.hostVars[host]; !ok {
+		a.hosts = append(a.hosts, host)
+		a.hostVars[host] = make(map[string]string)
+	}
+	for k, v := range vars {
+		a.hostVars[host][k] = v
+	}
+	if group != "" && !FindInArray(a.hostGroup[host], group) {
+		a.hostGroup[host] = append(a.hostGroup[host], group)
+	}
+}
+
+func (a *ansibleInventory) addGroupVars(group string, vars map[string]string) {
+	if a.groupVars[group] == nil {
+		a.groupVars[group] = make(map[string]string)
+	}
+	for k, v := range vars {
+		a.groupVars[group][k] = v
+	}
+}
+
+func (a *ansibleInventory) addChild(parent, child string) {
+	if !FindInArray(a.parents[child], parent) {
+		a.parents[child] = append(a.parents[child], parent)
+	}
+}
+
+// ancestors returns the group with all its parents, the farthest parents first
+func (a *ansibleInventory) ancestors(group string, seen map[string]bool) []string {
+	if seen[group] {
+		return nil
+	}
+	seen[group] = true
+	var list []string
+	for _, p := range a.parents[group] {
+		list = append(list, a.ancestors(p, seen)...)
+	}
+	return append(list, group)
+}
+
+var ansibleJumpRe = regexp.MustCompile(`(?:-J\s*|ProxyJump=)(\S+)`)
+
+// servers converts hosts to servers, variables of all, parent groups, groups and the host
+// are applied in this order
+func (a *ansibleInventory) servers() []Server {
+	var list []Server
+	for _, host := range a.hosts {
+		seen := make(map[string]bool)
+		groups := a.ancestors("all", seen)
+		for _, g := range a.hostGroup[host] {
+			groups = append(groups, a.ancestors(g, seen)...)
+		}
+		vars := make(map[string]string)
+		var tags []string
+		for _, g := range groups {
+			for k, v := range a.groupVars[g] {
+				vars[k] = v
+			}
+			if g != "all" && g != "ungrouped" {
+				tags = append(tags, g)
+			}
+		}
+		for k, v := range a.hostVars[host] {
+			vars[k] = v
+		}
+		get := func(keys ...string) string {
+			for _, k := range keys {
+				if v := strings.Trim(vars[k], `"'`); v != "" {
+					return v
+				}
+			}
+			return ""
+		}
+		srv := Server{
+			Host:       host,
+			IP:         get("ansible_host", "ansible_ssh_host"),
+			User:       get("ansible_user", "ansible_ssh_user"),
+			Port:       get("ansible_port", "ansible_ssh_port"),
+			PrivateKey: importKey(get("ansible_ssh_private_key_file", "ansible_private_key_file")),
+			Tags:       joinTags(tags...),
+		}
+		switch get("ansible_connection") {
+		case "winrm", "psrp":
+			srv.Type = "RDP"
+			srv.Port = ""
+		}
+		if m := ansibleJumpRe.FindStringSubmatch(get("ansible_ssh_common_args", "ansible_ssh_extra_args")); m != nil {
+			srv.Jump = strings.Trim(m[1], `"'`)
+		}
+		list = append(list, srv)
+	}
+	return list
+}
+
+// parseAnsibleInventory reads the inventory file, YAML or JSON by the content, INI otherwise
+func parseAnsibleInventory(name string, data []byte) ([]Server, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) > 0 && trimmed[0] == '{':
+		return parseAnsibleJSON(trimmed)
+	case strings.HasSuffix(name, ".yml"), strings.HasSuffix(name, ".yaml"), bytes.HasPrefix(trimmed, []byte("all:")), bytes.HasPrefix(trimmed, []byte("---")):
+		return parseAnsibleYAML(data)
+	}
+	return parseAnsibleINI(data)
+}
+
+// ansibleHostRangeRe matches host ranges like web[01:10].example.com
+var ansibleHostRangeRe = regexp.MustCompile(`^(.*)\[(\d+):(\d+)\](.*)$`)
+
+// expandAnsibleHost expands numeric host ranges
+func expandAnsibleHost(host string) []string {
+	m := ansibleHostRangeRe.FindStringSubmatch(host)
+	if m == nil {
+		return []string{host}
+	}
+	from, _ := strconv.Atoi(m[2])
+	to, _ := strconv.Atoi(m[3])
+	var hosts []string
+	for i := from; i <= to && len(hosts) < 10000; i++ {
+		n := strconv.Itoa(i)
+		if len(m[2]) > 1 && m[2][0] == '0' {
+			n = fmt.Sprintf("%0*d", len(m[2]), i)
+		}
+		hosts = append(hosts, expandAnsibleHost(m[1]+n+m[4])...)
+	}
+	return hosts
+}
+
+// ansibleINIVars parses key=value pairs, values can be quoted
+func ansibleINIVars(fields []string) map[string]string {
+	vars := make(map[string]string)
+	for _, f := range fields {
+		if k, v, ok := strings.Cut(f, "="); ok {
+			vars[k] = strings.Trim(v, `"'`)
+		}
+	}
+	return vars
+}
+
+// splitAnsibleINILine splits the line by spaces outside of quotes
+func splitAnsibleINILine(line string) []string {
+	var fields []string
+	var cur strings.Builder
+	quote := rune(0)
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+			cur.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			cur.WriteRune(r)
+		case r == ' ' || r == '\t':
+			if cur.Len() > 0 {
+				fields = append(fields, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		fields = append(fields, cur.String())
+	}
+	return fields
+}
+
+func parseAnsibleINI(data []byte) ([]Server, error) {
+	a := newAnsibleInventory()
+	group, kind := "ungrouped", ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			group, kind, _ = strings.Cut(line[1:len(line)-1], ":")
+			continue
+		}
+		fields := splitAnsibleINILine(line)
+		switch kind {
+		case "vars":
+			k, v, _ := strings.Cut(line, "=")
+			a.addGroupVars(group, map[string]string{strings.TrimSpace(k): strings.Trim(strings.TrimSpace(v), `"'`)})
+		case "children":
+			a.addChild(group, fields[0])
+		default:
+			vars := ansibleINIVars(fields[1:])
+			for _, host := range expandAnsibleHost(fields[0]) {
+				a.addHost(host, group, vars)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return a.servers(), nil
+}
+
+// ansibleYAMLVars converts variables of the YAML inventory to strings
+func ansibleYAMLVars(v interface{}) map[string]string {
+	vars := make(map[string]string)
+	if m, ok := v.(map[string]interface{}); ok {
+		for k, val := range m {
+			if val != nil {
+				vars[k] = inventoryValue(val)
+			}
+		}
+	}
+	return vars
+}
+
+func parseAnsibleYAML(data []byte) ([]Server, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	a := newAnsibleInventory()
+	var walk func(name string, group map[string]interface{})
+	walk = func(name string, group map[string]interface{}) {
+		a.addGroupVars(name, ansibleYAMLVars(group["vars"]))
+		if hosts, ok := group["hosts"].(map[string]interface{}); ok {
+			names := make([]string, 0, len(hosts))
+			for h := range hosts {
+				names = append(names, h)
+			}
+			sort.Strings(names)
+			for _, h := range names {
+				for _, host := range expandAnsibleHost(h) {
+					a.addHost(host, name, ansibleYAMLVars(hosts[h]))
+				}
+			}
+		}
+		if children, ok := group["children"].(map[string]interface{}); ok {
+			for child, v := range children {
+				a.addChild(name, child)
+				sub, _ := v.(map[string]interface{})
+				walk(child, sub)
+			}
+		}
+	}
+	for name, v := range doc {
+		group, _ := v.(map[string]interface{})
+		walk(name, group)
+	}
+	return a.servers(), nil
+}
+
+// parseAnsibleJSON reads output of the Ansible dynamic inventory scripts (ansible-inventory --list)
+func parseAnsibleJSON(data []byte) ([]Server, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	a := newAnsibleInventory()
+	var meta struct {
+		HostVars map[string]map[string]interface{} `json:"hostvars"`
+	}
+	if raw, ok := doc["_meta"]; ok {
+		json.Unmarshal(raw, &meta)
+	}
+	names := make([]string, 0, len(doc))
+	for name := range doc {
+		if name != "_meta" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var group struct {
+			Hosts    []string               `json:"hosts"`
+			Vars     map[string]interface{} `json:"vars"`
+			Children []string               `json:"children"`
+		}
+		// the old format is just a list of hosts
+		if err := json.Unmarshal(doc[name], &group); err != nil {
+			if err := json.Unmarshal(doc[name], &group.Hosts); err != nil {
+				return nil, fmt.Errorf("group %s: %w", name, err)
+			}
+		}
+		a.addGroupVars(name, ansibleYAMLVars(group.Vars))
+		for _, child := range group.Children {
+			a.addChild(name, child)
+		}
+		for _, host := range group.Hosts {
+			a.addHost(host, name, nil)
+		}
+	}
+	for host, vars := range meta.HostVars {
+		if _, ok := a.hostVars[host]; ok {
+			a.addHost(host, "", ansibleYAMLVars(vars))
+		}
+	}
+	return a.servers(), nil
+}
+
+// zoneClasses are the classes of the zone file records
+var zoneClasses = map[string]bool{"IN": true, "CH": true, "HS": true, "CS": true}
+
+// zoneTTLRe matches TTL of the record, e.g. 300 or 1h30m
+var zoneTTLRe = regexp.MustCompile(`^(?i)\d+[smhdw]?(\d+[smhdw])*$`)
+
+// parseZoneFile reads A, AAAA and CNAME records of the DNS zone file, origin is used without $ORIGIN
+func parseZoneFile(data []byte, origin string) ([]Server, error) {
+	origin = strings.TrimSuffix(origin, ".")
+	fqdn := func(name string) string {
+		switch {
+		case name == "@":
+			return origin
+		case strings.HasSuffix(name, "."):
+			return strings.TrimSuffix(name, ".")
+		case origin == "":
+			return name
+		}
+		return name + "." + origin
+	}
+
+	// join records split to more lines by parentheses and drop the comments
+	var lines []string
+	var record strings.Builder
+	depth := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, ";"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimRight(line, " \t\r")
+		depth += strings.Count(line, "(") - strings.Count(line, ")")
+		record.WriteString(strings.NewReplacer("(", " ", ")", " ").Replace(line))
+		if depth > 0 {
+			record.WriteString(" ")
+			continue
+		}
+		lines = append(lines, record.String())
+		record.Reset()
+		depth = 0
+	}
+
+	var list []Server
+	seen := make(map[string]bool)
+	owner := ""
+	for n, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch strings.ToUpper(fields[0]) {
+		case "$ORIGIN":
+			if len(fields) > 1 {
+				origin = strings.TrimSuffix(fqdn(fields[1]), ".")
+			}
+			continue
+		case "$TTL":
+			continue
+		case "$INCLUDE", "$GENERATE":
+			inventoryLog.Warnf("Zone file line %d: %s is not supported", n+1, fields[0])
+			continue
+		}
+		// records starting with space belong to the previous owner
+		if line[0] != ' ' && line[0] != '\t' {
+			owner = fields[0]
+			fields = fields[1:]
+		}
+		for len(fields) > 0 && (zoneClasses[strings.ToUpper(fields[0])] || zoneTTLRe.MatchString(fields[0])) {
+			fields = fields[1:]
+		}
+		if len(fields) < 2 || owner == "" || strings.HasPrefix(owner, "*") {
+			continue
+		}
+		host := fqdn(owner)
+		var ip string
+		switch strings.ToUpper(fields[0]) {
+		case "A", "AAAA":
+			ip = fields[1]
+		case "CNAME":
+			ip = fqdn(fields[1])
+		default:
+			continue
+		}
+		if host == "" || seen[strings.ToLower(host)] {
+			continue
+		}
+		seen[strings.ToLower(host)] = true
+		list = append(list, Server{Host: host, IP: ip, Description: strings.ToUpper(fields[0]) + " record of " + origin})
+	}
+	return list, nil
+}
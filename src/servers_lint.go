@@ -0,0 +1,293 @@
+package main
+
+/* Servers files validation
+Checks the servers files and reports problems with their line numbers: yaml syntax, unknown fields,
+server type, port range, address syntax, jump hosts, passwords and TOTP secrets that can't be decrypted
+with the key of the file and duplicate servers in the file (errors) or in other files (warnings).
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// lint issue severities
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintIssue is a problem found in the servers file
+type LintIssue struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Host     string `json:"host,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (i LintIssue) String() string {
+	host := ""
+	if i.Host != "" {
+		host = i.Host + ": "
+	}
+	return fmt.Sprintf("%s:%d: %s: %s%s", i.File, i.Line, i.Severity, host, i.Message)
+}
+
+var (
+	yamlLineRe = regexp.MustCompile(`line (\d+)`)
+	hostnameRe = regexp.MustCompile(`^(?i)[a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9_])?(\.[a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9_])?)*\.?$`)
+)
+
+// serverYAMLFields returns field names of the servers files
+func serverYAMLFields() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(Server{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// validAddress returns true for IP addresses and host names
+func validAddress(addr string) bool {
+	return net.ParseIP(strings.Trim(addr, "[]")) != nil || (len(addr) <= 253 && hostnameRe.MatchString(addr))
+}
+
+// validPort returns true when the port is a number in the port range
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// validJump checks comma separated [user@]host[:port] jump hosts
+func validJump(jump string) bool {
+	for _, hop := range strings.Split(jump, ",") {
+		hop = strings.TrimSpace(hop)
+		if i := strings.LastIndex(hop, "@"); i >= 0 {
+			hop = hop[i+1:]
+		}
+		host, port := splitHostPort(hop)
+		if !validAddress(host) || (port != "" && !validPort(port)) {
+			return false
+		}
+	}
+	return true
+}
+
+// lintedServer is a server with its position in the servers file
+type lintedServer struct {
+	srv  Server
+	line int
+}
+
+// lintCacheEntry is the result of lintFile, it is used until the file or its key is changed
+type lintCacheEntry struct {
+	modTime time.Time
+	size    int64
+	key     [32]byte // hash of the key the file was checked with
+	issues  []LintIssue
+	list    []lintedServer
+}
+
+var (
+	lintCacheMu sync.Mutex
+	lintCache   = make(map[string]lintCacheEntry)
+)
+
+// lintFileCached returns the cached result of lintFile, the file is checked again when it was changed
+func lintFileCached(path string) ([]LintIssue, []lintedServer) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return lintFile(path)
+	}
+	keyOf := Server{SourceName: filepath.Base(path)}
+	key := keyOf.encKey()
+	if key == "" {
+		key = settings.GlobEncryptKey
+	}
+	sum := sha256.Sum256([]byte(key))
+	lintCacheMu.Lock()
+	e, ok := lintCache[path]
+	lintCacheMu.Unlock()
+	if !ok || !e.modTime.Equal(fi.ModTime()) || e.size != fi.Size() || e.key != sum {
+		e = lintCacheEntry{modTime: fi.ModTime(), size: fi.Size(), key: sum}
+		e.issues, e.list = lintFile(path)
+		lintCacheMu.Lock()
+		lintCache[path] = e
+		lintCacheMu.Unlock()
+	}
+	// LintServers appends to the issues
+	return append([]LintIssue(nil), e.issues...), e.list
+}
+
+// lintFile checks the servers file and returns the issues and the servers with their lines
+func lintFile(path string) ([]LintIssue, []lintedServer) {
+	name := filepath.Base(path)
+	issue := func(line int, host, severity, format string, a ...interface{}) LintIssue {
+		return LintIssue{File: name, Line: line, Host: host, Severity: severity, Message: fmt.Sprintf(format, a...)}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []LintIssue{issue(0, "", LintError, "%s", err)}, nil
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		line := 0
+		if m := yamlLineRe.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+		}
+		return []LintIssue{issue(line, "", LintError, "%s", strings.TrimPrefix(err.Error(), "yaml: "))}, nil
+	}
+	if len(root.Content) == 0 {
+		return nil, nil // empty file
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.SequenceNode {
+		return []LintIssue{issue(doc.Line, "", LintError, "servers file has to be a list of servers")}, nil
+	}
+
+	fields := serverYAMLFields()
+	var issues []LintIssue
+	var list []lintedServer
+	keyOf := Server{SourceName: name}
+	key := keyOf.encKey()
+	for _, item := range doc.Content {
+		if item.Kind != yaml.MappingNode {
+			issues = append(issues, issue(item.Line, "", LintError, "server has to be a mapping of fields"))
+			continue
+		}
+		lines := make(map[string]int)
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			k := item.Content[i]
+			lines[k.Value] = k.Line
+			if !fields[k.Value] {
+				issues = append(issues, issue(k.Line, "", LintWarning, "unknown field %s", k.Value))
+			}
+		}
+		line := func(field string) int {
+			if l, ok := lines[field]; ok {
+				return l
+			}
+			return item.Line
+		}
+		var srv Server
+		if err := item.Decode(&srv); err != nil {
+			issues = append(issues, issue(item.Line, "", LintError, "%s", strings.TrimPrefix(err.Error(), "yaml: unmarshal errors:\n  ")))
+			continue
+		}
+		srv.SourceName, srv.SourcePath = name, path
+		host := srv.Host
+		if host == "" {
+			issues = append(issues, issue(item.Line, "", LintError, "host is empty"))
+			host = srv.IP
+		}
+		switch {
+		case srv.Type == "":
+			issues = append(issues, issue(item.Line, host, LintWarning, "type is empty, %s is used", ServerTypes[0]))
+		case !FindInArray(ServerTypes, srv.Type):
+			issues = append(issues, issue(line("type"), host, LintError, "unknown type %s, supported: %s", srv.Type, strings.Join(ServerTypes, ", ")))
+		}
+		switch {
+		case srv.IP == "":
+			issues = append(issues, issue(item.Line, host, LintError, "ip is empty"))
+		case srv.Type == "Serial":
+			// device name, e.g. /dev/ttyUSB0 or COM3
+		case srv.Type == "WINBOX" && func() bool { _, err := net.ParseMAC(srv.IP); return err == nil }():
+			// winbox connects by MAC address too
+		case !validAddress(srv.IP):
+			issues = append(issues, issue(line("ip"), host, LintError, "invalid address %s", srv.IP))
+		}
+		if srv.Port != "" && !validPort(srv.Port) {
+			issues = append(issues, issue(line("port"), host, LintError, "invalid port %s, has to be 1-65535", srv.Port))
+		}
+		if srv.Jump != "" && !validJump(srv.Jump) {
+			issues = append(issues, issue(line("jump"), host, LintError, "invalid jump host %s, expected [user@]host[:port]", srv.Jump))
+		}
+		if _, err := decryptString(srv.Password, key); err != nil {
+			issues = append(issues, issue(line("password"), host, LintError, "password can't be decrypted with the key of %s", name))
+		}
+		if _, err := decryptString(srv.TOTP, key); err != nil {
+			issues = append(issues, issue(line("totp"), host, LintError, "TOTP secret can't be decrypted with the key of %s", name))
+		}
+		list = append(list, lintedServer{srv: srv, line: item.Line})
+	}
+	return issues, list
+}
+
+// LintServers checks the servers files, issues are ordered by file and line
+func LintServers(files []string) []LintIssue {
+	var issues []LintIssue
+	var all []lintedServer
+	for _, path := range files {
+		fileIssues, list := lintFileCached(path)
+		// duplicates in the file are errors, the same server in another file is a warning
+		for i, a := range list {
+			for _, b := range list[:i] {
+				switch {
+				case a.srv.Host != "" && strings.EqualFold(a.srv.Host, b.srv.Host):
+					fileIssues = append(fileIssues, LintIssue{File: a.srv.SourceName, Line: a.line, Host: a.srv.Host, Severity: LintError,
+						Message: fmt.Sprintf("duplicate host, defined on line %d", b.line)})
+				case a.srv.IP != "" && a.srv.IP == b.srv.IP && a.srv.Port == b.srv.Port && a.srv.User == b.srv.User:
+					fileIssues = append(fileIssues, LintIssue{File: a.srv.SourceName, Line: a.line, Host: a.srv.Host, Severity: LintWarning,
+						Message: fmt.Sprintf("same address, port and user as %s on line %d", b.srv.Host, b.line)})
+				}
+			}
+			for _, b := range all {
+				if a.srv.Host != "" && strings.EqualFold(a.srv.Host, b.srv.Host) {
+					fileIssues = append(fileIssues, LintIssue{File: a.srv.SourceName, Line: a.line, Host: a.srv.Host, Severity: LintWarning,
+						Message: fmt.Sprintf("host is also defined in %s:%d", b.srv.SourceName, b.line)})
+					break
+				}
+			}
+		}
+		sort.SliceStable(fileIssues, func(i, j int) bool { return fileIssues[i].Line < fileIssues[j].Line })
+		issues = append(issues, fileIssues...)
+		all = append(all, list...)
+	}
+	return issues
+}
+
+// lintCounts returns number of errors and warnings
+func lintCounts(issues []LintIssue) (errors, warnings int) {
+	for _, i := range issues {
+		if i.Severity == LintError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	return errors, warnings
+}
+
+// lintSummary returns short description of the issues, empty when there are none
+func lintSummary(issues []LintIssue) string {
+	errors, warnings := lintCounts(issues)
+	if errors+warnings == 0 {
+		return ""
+	}
+	first := issues[0]
+	for _, i := range issues {
+		if i.Severity == LintError {
+			first = i
+			break
+		}
+	}
+	return fmt.Sprintf("%d errors, %d warnings in the servers files (conan lint shows all): %s", errors, warnings, first)
+}
@@ -0,0 +1,198 @@
+package main
+
+/* Servers files discovery
+Servers files (.yml and .yaml) are searched in the paths of settings.ini or CONAN_PATH,
+a path ending with /** is searched with its subfolders, the folder becomes the group of its servers:
+  [General]
+  paths   = ~/.config/conan/servers/**, /srv/shared/conan
+  include = prod-*.yml, team/*
+  exclude = old.yml, archive/*
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// serverPathsEnv overrides the search paths of the settings, separated like PATH
+const serverPathsEnv = "CONAN_PATH"
+
+// recursiveSuffix marks the search path searched with its subfolders
+const recursiveSuffix = "/**"
+
+var serverFileExts = []string{".yml", ".yaml"}
+
+var (
+	serverFilesRecursive = make(map[string]bool)   // search paths searched with subfolders
+	serverFilesRel       = make(map[string]string) // servers file path -> path relative to its search path
+)
+
+// defaultServerFilesPaths are used when neither settings.ini nor CONAN_PATH sets the paths
+func defaultServerFilesPaths() []string {
+	return []string{
+		filepath.Join(env.configDir, "servers") + recursiveSuffix,
+		env.configDir,
+		env.appPath,
+	}
+}
+
+// setServerFilesPaths sets the search paths, CONAN_PATH has priority over the paths of settings.ini
+func setServerFilesPaths(configured string) {
+	var list []string
+	if value := os.Getenv(serverPathsEnv); value != "" {
+		list = filepath.SplitList(value)
+	} else if strings.TrimSpace(configured) != "" {
+		list = strings.Split(configured, ",")
+	} else {
+		list = defaultServerFilesPaths()
+	}
+	serverFilesPaths = nil
+	serverFilesRecursive = make(map[string]bool)
+	for _, p := range list {
+		p = strings.TrimSpace(p)
+		recursive := strings.HasSuffix(filepath.ToSlash(p), recursiveSuffix)
+		if recursive {
+			p = p[:len(p)-len(recursiveSuffix)]
+		}
+		if p == "" {
+			continue
+		}
+		if p == "~" || strings.HasPrefix(p, "~/") {
+			p = filepath.Join(env.homeDir, p[1:])
+		}
+		p = filepath.Clean(p)
+		if !FindInArray(serverFilesPaths, p) {
+			serverFilesPaths = append(serverFilesPaths, p)
+		}
+		if recursive {
+			serverFilesRecursive[p] = true
+		}
+	}
+}
+
+// splitPatterns splits comma separated glob patterns
+func splitPatterns(value string) []string {
+	var list []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			list = append(list, filepath.ToSlash(p))
+		}
+	}
+	return list
+}
+
+// matchPatterns returns true when the relative path matches one of the patterns,
+// patterns without / match the file name, the others the path relative to the search path
+func matchPatterns(patterns []string, rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, p := range patterns {
+		name := rel
+		if !strings.Contains(p, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+		// dir/* matches the files in the subfolders of dir too
+		if strings.HasSuffix(p, "/*") && strings.HasPrefix(rel, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// isServersFile returns true for .yml and .yaml files
+func isServersFile(name string) bool {
+	return FindInArray(serverFileExts, strings.ToLower(filepath.Ext(name)))
+}
+
+// serverFileExcluded returns true when the servers file is not included or is excluded in settings.ini
+func serverFileExcluded(rel string) bool {
+	if len(settings.Include) > 0 && !matchPatterns(settings.Include, rel) {
+		return true
+	}
+	return matchPatterns(settings.Exclude, rel)
+}
+
+// scanServerFiles returns servers files of the search path with their paths relative to it,
+// the search path can be a symlink, the files keep the paths under the search path
+func scanServerFiles(dir string) map[string]string {
+	found := make(map[string]string)
+	recursive := serverFilesRecursive[dir]
+	root := dir
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		root = resolved
+	}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			serversLog.Warnf("Unable to read %s: %s", p, err)
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		if d.IsDir() {
+			if p == root {
+				return nil
+			}
+			// hidden folders and the notes folders are never searched
+			if !recursive || strings.HasPrefix(d.Name(), ".") || strings.HasSuffix(d.Name(), "-notes") || matchPatterns(settings.Exclude, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isServersFile(p) {
+			found[filepath.Join(dir, rel)] = filepath.ToSlash(rel)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		serversLog.Warnf("Unable to search %s: %s", dir, err)
+	}
+	return found
+}
+
+// SourceRel returns the servers file path relative to its search path, e.g. prod/web.yml
+func (s Server) SourceRel() string {
+	if rel, ok := serverFilesRel[s.SourcePath]; ok {
+		return rel
+	}
+	return s.SourceName
+}
+
+// folderGroup returns the subfolder of the servers file, its servers are grouped by it
+func (s Server) folderGroup() string {
+	dir := path.Dir(s.SourceRel())
+	if dir == "." || s.ReadOnly() {
+		return ""
+	}
+	return dir
+}
+
+// FullGroup returns the group of the server with the subfolder of its servers file, e.g. prod/DC1
+func (s Server) FullGroup() string {
+	return strings.Join(s.GroupPath(), groupSeparator)
+}
+
+// resolveServersFile returns the servers file by its full path, relative path or name (the extension can be omitted)
+func resolveServersFile(name string) (string, error) {
+	name = filepath.ToSlash(strings.TrimSpace(name))
+	for _, f := range ymlfiles {
+		rel, ok := serverFilesRel[f]
+		if !ok {
+			rel = filepath.Base(f)
+		}
+		for _, candidate := range []string{filepath.ToSlash(f), rel, path.Base(rel)} {
+			if candidate == name || trimYML(candidate) == name {
+				return f, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("servers file %s not found", name)
+}
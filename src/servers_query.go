@@ -0,0 +1,144 @@
+package main
+
+/* Servers filter
+Filter queries used by the command line: terms separated by spaces, all of them have to match.
+  field=value   equal (case insensitive), * and ? wildcards are allowed
+  field!=value  not equal
+  field~text    contains
+  text          host, address, description or tags contain the text
+Fields: host, ip, user, port, type, tag (one of the tags), tags, group (the group or its parent), description, jump, key, file, id
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+type filterTerm struct {
+	field string // empty for plain text
+	op    string // =, != or ~
+	value string
+}
+
+// ServerFilter is a parsed filter query
+type ServerFilter []filterTerm
+
+var filterFields = []string{"host", "ip", "user", "port", "type", "tag", "tags", "group", "description", "jump", "key", "file", "id"}
+
+// ParseServerFilter parses the filter query, empty query matches all servers
+func ParseServerFilter(query string) (ServerFilter, error) {
+	var f ServerFilter
+	for _, term := range strings.Fields(query) {
+		i := strings.IndexAny(term, "=!~")
+		if i <= 0 {
+			f = append(f, filterTerm{value: strings.ToLower(term)})
+			continue
+		}
+		t := filterTerm{field: strings.ToLower(term[:i])}
+		switch {
+		case strings.HasPrefix(term[i:], "!="):
+			t.op, t.value = "!=", term[i+2:]
+		case term[i] == '!':
+			return nil, fmt.Errorf("invalid filter term %s", term)
+		default:
+			t.op, t.value = term[i:i+1], term[i+1:]
+		}
+		if !FindInArray(filterFields, t.field) {
+			return nil, fmt.Errorf("unknown filter field %s, supported: %s", t.field, strings.Join(filterFields, ", "))
+		}
+		t.value = strings.ToLower(t.value)
+		f = append(f, t)
+	}
+	return f, nil
+}
+
+// filterValues returns values of the server field, tag returns all tags
+func filterValues(srv Server, field string) []string {
+	switch field {
+	case "host":
+		return []string{srv.Host}
+	case "ip":
+		return []string{srv.IP}
+	case "user":
+		return []string{srv.User}
+	case "port":
+		return []string{srv.Port}
+	case "type":
+		return []string{srv.Type}
+	case "tag":
+		if tags := srv.TagsList(); len(tags) > 0 {
+			return tags
+		}
+		return []string{""}
+	case "tags":
+		return []string{srv.Tags}
+	case "group":
+		if groups := groupAncestors(srv.FullGroup()); len(groups) > 0 {
+			return groups
+		}
+		return []string{""}
+	case "description":
+		return []string{srv.Description}
+	case "jump":
+		return []string{srv.Jump}
+	case "key":
+		return []string{srv.PrivateKey}
+	case "file":
+		return []string{srv.SourceName, trimYML(srv.SourceName), srv.SourceRel(), trimYML(srv.SourceRel())}
+	case "id":
+		return []string{srv.StableID()}
+	}
+	return nil
+}
+
+func (t filterTerm) match(srv Server) bool {
+	if t.field == "" {
+		for _, v := range []string{srv.Host, srv.IP, srv.Description, srv.Tags} {
+			if strings.Contains(strings.ToLower(v), t.value) {
+				return true
+			}
+		}
+		return false
+	}
+	matched := false
+	for _, v := range filterValues(srv, t.field) {
+		v = strings.ToLower(strings.TrimSpace(v))
+		switch t.op {
+		case "~":
+			matched = strings.Contains(v, t.value)
+		default:
+			ok, err := path.Match(t.value, v)
+			matched = err == nil && ok
+		}
+		if matched {
+			break
+		}
+	}
+	if t.op == "!=" {
+		return !matched
+	}
+	return matched
+}
+
+// Match returns true when all the terms match the server
+func (f ServerFilter) Match(srv Server) bool {
+	for _, t := range f {
+		if !t.match(srv) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns servers matching the filter
+func (f ServerFilter) Filter(list []Server) []Server {
+	var out []Server
+	for _, srv := range list {
+		if f.Match(srv) {
+			out = append(out, srv)
+		}
+	}
+	return out
+}
@@ -0,0 +1,224 @@
+package main
+
+/* Excel workbook reader
+Minimal .xlsx reader for the servers import, reads cell values of all sheets
+(shared and inline strings, numbers, booleans), formatting and formulas are ignored.
+(c) 2025 e1z0, Conan project
+*/
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// XLSXSheet is a sheet of the workbook with rows of cell values
+type XLSXSheet struct {
+	Name string
+	Rows [][]string
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"id,attr"` // r:id
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is a plain or rich text string (runs are concatenated)
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	s := t.T
+	for _, r := range t.Runs {
+		s += r.T
+	}
+	return s
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSX returns sheets of the workbook, empty rows are left out
+func ReadXLSX(file string) ([]XLSXSheet, error) {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not an xlsx workbook: %w", file, err)
+	}
+	defer zr.Close()
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	readXML := func(name string, v interface{}) error {
+		f, ok := files[name]
+		if !ok {
+			return fmt.Errorf("%s not found in %s", name, file)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		return xml.NewDecoder(rc).Decode(v)
+	}
+
+	var wb xlsxWorkbook
+	if err := readXML("xl/workbook.xml", &wb); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := readXML("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string)
+	for _, r := range rels.Relationships {
+		if strings.HasPrefix(r.Target, "/") {
+			targets[r.ID] = strings.TrimPrefix(r.Target, "/")
+		} else {
+			targets[r.ID] = path.Join("xl", r.Target)
+		}
+	}
+	var shared xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := readXML("xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	var sheets []XLSXSheet
+	for _, s := range wb.Sheets {
+		var ws xlsxWorksheet
+		if err := readXML(targets[s.RID], &ws); err != nil {
+			return nil, fmt.Errorf("sheet %s: %w", s.Name, err)
+		}
+		sheet := XLSXSheet{Name: s.Name}
+		for _, row := range ws.Rows {
+			var values []string
+			empty := true
+			for i, c := range row.Cells {
+				col := i
+				if c.Ref != "" {
+					col = xlsxColumn(c.Ref)
+				}
+				// broken references and columns after XFD are skipped
+				if col < 0 || col >= xlsxMaxColumns {
+					continue
+				}
+				for len(values) <= col {
+					values = append(values, "")
+				}
+				v := c.Value
+				switch c.Type {
+				case "s":
+					var idx int
+					if _, err := fmt.Sscan(c.Value, &idx); err == nil && idx >= 0 && idx < len(shared.Items) {
+						v = shared.Items[idx].String()
+					}
+				case "inlineStr":
+					v = c.Inline.String()
+				case "b":
+					v = map[string]string{"1": "TRUE", "0": "FALSE"}[c.Value]
+				}
+				values[col] = v
+				if strings.TrimSpace(v) != "" {
+					empty = false
+				}
+			}
+			if !empty {
+				sheet.Rows = append(sheet.Rows, values)
+			}
+		}
+		sheets = append(sheets, sheet)
+	}
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("no sheets found in %s", file)
+	}
+	return sheets, nil
+}
+
+// xlsxMaxColumns is the number of columns of the Excel sheet (A-XFD)
+const xlsxMaxColumns = 16384
+
+// xlsxColumn returns zero based column of the cell reference (C7 -> 2)
+func xlsxColumn(ref string) int {
+	col := 0
+	for _, r := range strings.ToUpper(ref) {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}
+
+// XLSXColumnName returns the column letters of the zero based column (2 -> C)
+func XLSXColumnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+// ReadTableFile reads xlsx workbook or csv file (separator is detected) as sheets
+func ReadTableFile(file string) ([]XLSXSheet, error) {
+	if !strings.EqualFold(filepath.Ext(file), ".csv") {
+		return ReadXLSX(file)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	head := make([]byte, 4096)
+	n, _ := io.ReadFull(f, head)
+	first := strings.SplitN(string(head[:n]), "\n", 2)[0]
+	comma, best := ',', 0
+	for _, sep := range []rune{',', ';', '\t', '|'} {
+		if c := strings.Count(first, string(sep)); c > best {
+			comma, best = sep, c
+		}
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(f)
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return []XLSXSheet{{Name: filepath.Base(file), Rows: rows}}, nil
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
@@ -15,42 +20,58 @@ import (
 var serverFilesPaths []string
 
 type Server struct {
-	ID           string `yaml:"-"` // new unique identifier
-	SourcePath   string `yaml:"-"` // full path, not marshalled
-	SourceName   string `yaml:"-"` // basename, not marshalled
-	Host         string `yaml:"host"`
-	IP           string `yaml:"ip"`
-	User         string `yaml:"username,omitempty"`
-	Password     string `yaml:"password,omitempty"`
-	PrivateKey   string `yaml:"privatekey,omitempty"`
-	Port         string `yaml:"port,omitempty"`
-	Description  string `yaml:"description,omitempty"`
-	Type         string `yaml:"type"`
-	Tags         string `yaml:"tags,omitempty"` // Comma-separated
-	Availability string `yaml:"-"`              // e.g., "available", "unavailable"
+	ID           string   `yaml:"-"` // new unique identifier
+	SourcePath   string   `yaml:"-"` // full path, not marshalled
+	SourceName   string   `yaml:"-"` // basename, not marshalled, relative path is SourceRel()
+	Host         string   `yaml:"host"`
+	IP           string   `yaml:"ip"`
+	User         string   `yaml:"username,omitempty"`
+	Password     string   `yaml:"password,omitempty"`
+	TOTP         string   `yaml:"totp,omitempty"` // encrypted TOTP secret (base32 or otpauth:// uri)
+	PrivateKey   string   `yaml:"privatekey,omitempty"`
+	Port         string   `yaml:"port,omitempty"`
+	Description  string   `yaml:"description,omitempty"`
+	Type         string   `yaml:"type"`
+	Tags         string   `yaml:"tags,omitempty"`  // Comma-separated
+	Group        string   `yaml:"group,omitempty"` // group path, levels separated by /, e.g. DC1/Rack4/Routers
+	Notes        []string `yaml:"notes,omitempty"` // linked notes, paths relative to the notes folder of the file
+	Jump         string   `yaml:"jump,omitempty"`  // jump host for ssh -J: [user@]host[:port], comma separated for more hops
+	Inventory    string   `yaml:"-"`               // name of the dynamic inventory, its servers are read-only
+	Availability string   `yaml:"-"`               // e.g., "available", "unavailable"
 }
 
-func (s *Server) DecryptPassword() string {
-	key := ""
+// StableID returns short identifier that stays the same between program runs,
+// as long as the servers file name and host do not change (used to link notes)
+func (s Server) StableID() string {
+	sum := sha1.Sum([]byte(s.SourceName + "/" + s.Host))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// encKey returns the encryption key of the gist the server file belongs to (empty means global key)
+func (s *Server) encKey() string {
 	exists, gist := gistExists(s.SourceName)
 	if exists {
 		//log.Printf("Exists: %s %s\n", s.SourceName, gist.EncKey)
-		key = gist.EncKey
+		return gist.EncKey
 	}
+	return ""
+}
+
+func (s *Server) DecryptPassword() string {
+	key := s.encKey()
 	pass, err := decryptString(s.Password, key)
 	if err != nil {
 		log.Printf("Decryption failed for server %s: %s\n", s.Host, err)
 		return "" // Return empty string if decryption fails
 	}
+	if pass != "" {
+		auditEvent(AuditSecretDecrypt, s.Host, s.SourceName, "password")
+	}
 	return pass
 }
 
 func (s *Server) EncryptPassword(pass string) string {
-	key := ""
-	exists, gist := gistExists(s.SourceName)
-	if exists {
-		key = gist.EncKey
-	}
+	key := s.encKey()
 	if pass == "" {
 		return ""
 	}
@@ -62,6 +83,78 @@ func (s *Server) EncryptPassword(pass string) string {
 	return encrypted
 }
 
+// DecryptTOTP returns the plain TOTP secret of the server
+func (s *Server) DecryptTOTP() string {
+	secret, err := decryptString(s.TOTP, s.encKey())
+	if err != nil {
+		log.Printf("TOTP secret decryption failed for server %s: %s\n", s.Host, err)
+		return ""
+	}
+	if secret != "" {
+		auditEvent(AuditSecretDecrypt, s.Host, s.SourceName, "totp")
+	}
+	return secret
+}
+
+// EncryptTOTP encrypts the TOTP secret using the same key as the password
+func (s *Server) EncryptTOTP(secret string) string {
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return ""
+	}
+	encrypted, err := encryptString(secret, s.encKey())
+	if err != nil {
+		log.Printf("Error encrypting TOTP secret for server %s: %s\n", s.Host, err)
+		return ""
+	}
+	return encrypted
+}
+
+// OTP generates the current one time code from the server TOTP secret
+func (s *Server) OTP() (string, error) {
+	if s.TOTP == "" {
+		return "", errors.New("no TOTP secret defined for " + s.Host)
+	}
+	secret := s.DecryptTOTP()
+	if secret == "" {
+		return "", errors.New("unable to decrypt TOTP secret for " + s.Host)
+	}
+	cfg, err := parseTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return generateTOTP(cfg, time.Now())
+}
+
+// Address returns the address used for connections, IP has priority over hostname
+func (s Server) Address() string {
+	if s.IP != "" {
+		return s.IP
+	}
+	return s.Host
+}
+
+// ConnectionString returns a copy&paste friendly connection string for the server,
+// e.g. "ssh root@10.0.0.1 -p 2222" or "rdp://administrator@10.0.0.2:3389"
+func (s Server) ConnectionString() string {
+	target := s.Address()
+	if s.User != "" {
+		target = s.User + "@" + target
+	}
+	if s.Type == "SSH" || s.Type == "" {
+		cmd := "ssh " + target
+		if s.Port != "" {
+			cmd += " -p " + s.Port
+		}
+		return cmd
+	}
+	uri := strings.ToLower(s.Type) + "://" + target
+	if s.Port != "" {
+		uri += ":" + s.Port
+	}
+	return uri
+}
+
 var ServerTypes = []string{"SSH", "RDP", "VNC", "Telnet", "Serial", "WINBOX"}
 
 // TagsList returns the tags as a []string or nil if empty
@@ -92,14 +185,16 @@ func changeEncryptionKey(db, newkey string) error {
 		//log.Printf("Error unmarshalling database file: %s\n", err)
 		return err
 	}
+	// secrets are encrypted with the current key of the file, the file is not written
+	// when any of them can't be decrypted, it would be left with secrets of both keys
+	keyOf := Server{SourceName: filepath.Base(db)}
+	oldkey := keyOf.encKey()
 	for i := range servers {
 		//serv := servers[i]
 		if servers[i].Password != "" {
-			log.Printf("before pass: %#v\n", servers[i])
-			decrypted, err := decryptString(servers[i].Password, "")
+			decrypted, err := decryptString(servers[i].Password, oldkey)
 			if err != nil {
-				log.Printf("Error decrypting password for server %s: %s\n", servers[i].Host, err)
-				continue
+				return fmt.Errorf("unable to decrypt password of server %s: %w", servers[i].Host, err)
 			}
 			newk, err := encryptString(decrypted, newkey)
 			if err != nil {
@@ -107,7 +202,18 @@ func changeEncryptionKey(db, newkey string) error {
 				continue
 			}
 			servers[i].Password = newk
-			log.Printf("after pass: %#v\n", servers[i])
+		}
+		if servers[i].TOTP != "" {
+			decrypted, err := decryptString(servers[i].TOTP, oldkey)
+			if err != nil {
+				return fmt.Errorf("unable to decrypt TOTP secret of server %s: %w", servers[i].Host, err)
+			}
+			newk, err := encryptString(decrypted, newkey)
+			if err != nil {
+				log.Printf("Error encrypting TOTP secret for server %s: %s\n", servers[i].Host, err)
+				continue
+			}
+			servers[i].TOTP = newk
 		}
 	}
 	data, err = yaml.Marshal(servers)
@@ -117,38 +223,46 @@ func changeEncryptionKey(db, newkey string) error {
 	if err := os.WriteFile(db, data, 0644); err != nil {
 		return err
 	}
+	auditEvent(AuditKeyChange, "", filepath.Base(db), fmt.Sprintf("re-encrypted %d servers", len(servers)))
 	return nil
 }
 
 // find available server configuration files
 func findServerFiles() {
 	ymlfiles = nil
-	ignored := strings.Split(settings.Ignore, ",")
+	serverFilesRel = make(map[string]string)
+	names := make(map[string]string)
 	log.Printf("Searching for server list files...\n")
 	for _, dir := range serverFilesPaths {
-		//log.Printf("searching in path: %s\n",dir)
-		files, err := filepath.Glob(filepath.Join(dir, "*.yml"))
-		if err != nil {
-			log.Printf("some error: %s\n", err)
-			continue
+		found := scanServerFiles(dir)
+		files := make([]string, 0, len(found))
+		for f := range found {
+			files = append(files, f)
 		}
+		sort.Strings(files)
 		for _, f := range files {
-			//if !f.IsDir() {
-			// Double-check if it's a file (not a dir)
-			//if info, err := fs.Stat(os.DirFS("/"), f); err == nil && !info.IsDir() {
+			rel := found[f]
+			if _, ok := serverFilesRel[f]; ok {
+				continue // already found in the other search path
+			}
 			log.Printf("Found possible servers list yml file: %s\n", f)
-			if FindInArray(ignored, filepath.Base(f)) {
-				log.Printf("Skipping file %s because it's defined as ignored\n", f)
+			if serverFileExcluded(rel) {
+				serversLog.Debugf("Skipping file %s because it's excluded", f)
+				continue
+			}
+			// servers files are identified by their name (gists, notes, keys)
+			if other, ok := names[filepath.Base(f)]; ok {
+				serversLog.Warnf("Skipping file %s because %s has the same name", f, other)
 				continue
 			}
+			names[filepath.Base(f)] = f
 			for i, g := range gists {
 				if g.Name == filepath.Base(f) {
 					gists[i].Path = f // Update the Gist path
 				}
 			}
+			serverFilesRel[f] = rel
 			ymlfiles = append(ymlfiles, f)
-
-			//}
 		}
 	}
 }
@@ -158,32 +272,15 @@ func checkServYmlFiles(filename string) (error, string) {
 	if filename == "" {
 		return errors.New("File not specified"), ""
 	}
-	var err error
-	if !strings.Contains(filename, ".yml") {
-		filename = filename + ".yml"
-	}
-	ymlfiles = nil
-	ignored := strings.Split(settings.Ignore, ",")
-	for _, dir := range serverFilesPaths {
-		fullPath := filepath.Join(dir, filename)
-		log.Printf("checking %s for servers list file\n", fullPath)
-		if FindInArray(ignored, filepath.Base(fullPath)) {
-			log.Printf("Skipping file %s because it's defined as ignored\n", fullPath)
-			continue
-		}
-		if _, err := os.Stat(fullPath); err == nil {
-			log.Printf("Found in: %s\n", fullPath)
-			ymlfiles = make([]string, 1)
-			ymlfiles[0] = fullPath
-			for i, g := range gists {
-				if g.Name == filepath.Base(fullPath) {
-					gists[i].Path = fullPath // Update the Gist path
-				}
-			}
-			return nil, fullPath
-		}
+	findServerFiles()
+	fullPath, err := resolveServersFile(filename)
+	if err != nil {
+		ymlfiles = nil
+		return err, ""
 	}
-	return err, ""
+	log.Printf("Found in: %s\n", fullPath)
+	ymlfiles = []string{fullPath}
+	return nil, fullPath
 }
 
 func fetchServersFromFiles() {
@@ -212,13 +309,18 @@ func fetchServersFromFiles() {
 		}
 		tmpservs = append(tmpservs, serversFromFile...)
 	}
-	servers = tmpservs
+	servers = mergeInventoryServers(tmpservs)
 	filteredServers = servers // Initially show all servers
 }
 
-func pushServersToFile() {
+// pushServersToFile writes servers to their files, files in touched are written
+// even when no servers are left in them (the last server was deleted or moved)
+func pushServersToFile(touched ...string) {
 	// 1) Group servers by their SourcePath
 	byPath := make(map[string][]Server)
+	for _, path := range touched {
+		byPath[path] = []Server{}
+	}
 	for _, srv := range servers {
 		if srv.SourcePath == "" {
 			log.Printf("skip server %s: no SourcePath\n", srv.Host)
@@ -61,7 +61,12 @@ type GuiServTable struct {
 }
 
 type NoteSettings struct {
-	AlwaysOnTop bool
+	AlwaysOnTop    bool
+	PopupOnConnect bool   // show notes linked to the server as stickies when connecting
+	HistoryKeep    int    // keep only N newest revisions of each note, 0 keeps all
+	HistoryDays    int    // remove revisions older than N days, 0 keeps all
+	Reminders      bool   // tray notifications when due date of the task arrives
+	Inbox          string // note where quick capture entries are appended
 }
 
 func NewServTableColumnsSizes() *GuiServTable {
@@ -86,9 +91,12 @@ type Settings struct {
 	DefaultSSHKey   string
 	Sync            bool
 	ServerTableGui  GuiServTable
-	Ignore          string
+	Include         []string // glob patterns of the servers files, empty includes all
+	Exclude         []string // glob patterns of the excluded servers files
 	DecryptPassword string
 	NotesSettings   NoteSettings
+	ClipboardClear  int // seconds after copied secrets are wiped from clipboard, 0 disables
+	AutoLock        int // minutes of inactivity after the tray app locks itself, 0 disables
 	//GistID        string
 	//GistSecret    string
 	//DefaultDB     string
@@ -118,11 +126,7 @@ func InitializeEnvironment() {
 	}
 	env = environ
 	// collect and set possible server .yml files locations
-	serverFilesPaths = []string{
-		filepath.Join(env.configDir, "servers"),
-		env.configDir,
-		env.appPath,
-	}
+	setServerFilesPaths("")
 	switch env.os {
 	case "windows":
 		sshConnectionClients = append(sshConnectionClients, "putty")
@@ -199,12 +203,21 @@ func loadSettings(passphrase string) {
 		//	settings.GistID = section.Key("gistid").MustString("")
 		//	settings.GistSecret = section.Key("gistsecret").MustString("")
 	}
-	if section.HasKey("ignore") {
-		settings.Ignore = section.Key("ignore").String()
-	}
+	setServerFilesPaths(section.Key("paths").String())
+	settings.Include = splitPatterns(section.Key("include").String())
+	// ignore is the older list of excluded file names
+	settings.Exclude = splitPatterns(section.Key("exclude").String() + "," + section.Key("ignore").String())
 	if section.HasKey("defaultsshkey") {
 		settings.DefaultSSHKey = section.Key("defaultsshkey").String()
 	}
+	settings.ClipboardClear = 30
+	if section.HasKey("clipboard_clear") {
+		settings.ClipboardClear = section.Key("clipboard_clear").MustInt(30)
+	}
+	settings.AutoLock = 15
+	if section.HasKey("autolock") {
+		settings.AutoLock = section.Key("autolock").MustInt(15)
+	}
 	settings.ServerTableGui = *NewServTableColumnsSizes()
 	if cfg.HasSection("ServersTable") {
 		section = cfg.Section("ServersTable")
@@ -239,16 +252,37 @@ func loadSettings(passphrase string) {
 			settings.ServerTableGui.DisableRowTooltips = section.Key("disablerowtooltips").MustBool()
 		}
 	}
+	settings.NotesSettings.HistoryKeep = 0
+	settings.NotesSettings.Reminders = true
 	if cfg.HasSection("notes") {
 		section = cfg.Section("notes")
+		if section.HasKey("history_keep") {
+			settings.NotesSettings.HistoryKeep = section.Key("history_keep").MustInt(0)
+		}
+		if section.HasKey("history_days") {
+			settings.NotesSettings.HistoryDays = section.Key("history_days").MustInt()
+		}
 		if section.HasKey("alwaysontop") {
 			settings.NotesSettings.AlwaysOnTop = section.Key("alwaysontop").MustBool()
 		}
+		if section.HasKey("popuponconnect") {
+			settings.NotesSettings.PopupOnConnect = section.Key("popuponconnect").MustBool()
+		}
+		if section.HasKey("reminders") {
+			settings.NotesSettings.Reminders = section.Key("reminders").MustBool(true)
+		}
+		if section.HasKey("inbox") {
+			settings.NotesSettings.Inbox = section.Key("inbox").String()
+		}
 	}
 	// should be initialized as nil because if we run loadsettings few times the gist array becomes huge... :D
 	gists = nil
-	ignored := strings.Split(settings.Ignore, ",")
+	inventories = nil
 	for _, section := range cfg.Sections() {
+		if strings.HasPrefix(section.Name(), inventorySection) {
+			inventories = append(inventories, inventoryFromSection(section))
+			continue
+		}
 		if section.Name() == "DEFAULT" || section.Name() == "General" || section.Name() == "ServersTable" || section.Name() == "Notes" {
 			continue
 		}
@@ -265,7 +299,7 @@ func loadSettings(passphrase string) {
 			continue
 		}
 
-		if FindInArray(ignored, section.Name()[5:]) {
+		if matchPatterns(settings.Exclude, section.Name()[5:]) {
 			continue
 		}
 
@@ -282,7 +316,7 @@ func loadSettings(passphrase string) {
 		log.Printf("Decryption key loaded, settings loaded, re-checking yml files of the servers...\n")
 		if dbFlag != "" {
 			if err, _ := checkServYmlFiles(dbFlag); err != nil {
-				log.Printf("Servers DB Not found at: %s: %w\n", dbFlag, err)
+				log.Printf("Servers DB Not found at: %s: %s\n", dbFlag, err)
 				os.Exit(1)
 			}
 		} else {
@@ -110,6 +110,7 @@ func uploadToGist(gist GistConfig) error {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Send request
+	syncLog.Debugf("Pushing %s to gist %s", gist.Path, gist.GistID)
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -120,9 +121,12 @@ func uploadToGist(gist GistConfig) error {
 	// Check response
 	if resp.StatusCode != 200 {
 		body, _ := ioutil.ReadAll(resp.Body)
+		syncLog.Errorf("Push to gist %s failed with status %d", gist.GistID, resp.StatusCode)
 		return fmt.Errorf("GitHub API error: %s", string(body))
 	}
 
+	auditEvent(AuditGistPush, "", gist.Name, gist.GistID)
+	syncLog.Infof("Servers list %s pushed to gist %s", gist.Name, gist.GistID)
 	fmt.Printf("✅ Servers list %s pushed to GitHub Gist successfully!\n", gist.Name)
 	return nil
 }
@@ -136,6 +140,7 @@ func downloadFromGist(gist GistConfig) error {
 	}
 	req.Header.Set("Authorization", "token "+gist.GistSec)
 
+	syncLog.Debugf("Pulling %s from gist %s", gist.Name, gist.GistID)
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -145,6 +150,7 @@ func downloadFromGist(gist GistConfig) error {
 
 	if resp.StatusCode != 200 {
 		body, _ := ioutil.ReadAll(resp.Body)
+		syncLog.Errorf("Pull of gist %s failed with status %d", gist.GistID, resp.StatusCode)
 		return fmt.Errorf("GitHub API error: %s", string(body))
 	}
 
@@ -181,6 +187,8 @@ func downloadFromGist(gist GistConfig) error {
 		return err
 	}
 
+	auditEvent(AuditGistPull, "", gist.Name, gist.GistID)
+	syncLog.Infof("Servers list %s pulled from gist %s", gist.Name, gist.GistID)
 	fmt.Printf("✅ Servers list %s pulled from GitHub gist successfully!\n", gist.Name)
 	return nil
 }
@@ -64,6 +64,17 @@ func indexOf(slice []string, val string) int {
 	return -1 // not found
 }
 
+// splitList splits comma separated list, trimming spaces and dropping empty items
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
 // baseNames returns the file name (with extension) for each full path.
 func baseNames(paths []string) []string {
 	names := make([]string, len(paths))
@@ -164,8 +175,10 @@ func containsString(slice []string, s string) bool {
 }
 
 func trimYML(s string) string {
-	if strings.HasSuffix(s, ".yml") {
-		return strings.TrimSuffix(s, ".yml")
+	for _, ext := range serverFileExts {
+		if strings.HasSuffix(s, ext) {
+			return strings.TrimSuffix(s, ext)
+		}
 	}
 	return s
 }
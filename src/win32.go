@@ -6,6 +6,7 @@ package main
 import (
 	"log"
 	"syscall"
+	"time"
 	"unsafe"
 )
 
@@ -14,12 +15,18 @@ var (
 	registerHotKey   = user32.NewProc("RegisterHotKey")
 	unregisterHotKey = user32.NewProc("UnregisterHotKey")
 	getMessageW      = user32.NewProc("GetMessageW")
+	openInputDesktop = user32.NewProc("OpenInputDesktop")
+	switchDesktop    = user32.NewProc("SwitchDesktop")
+	closeDesktop     = user32.NewProc("CloseDesktop")
 )
 
 const (
 	MOD_CONTROL = 0x0002
+	MOD_SHIFT   = 0x0004
 	VK_SPACE    = 0x20
 	WM_HOTKEY   = 0x0312
+
+	DESKTOP_SWITCHDESKTOP = 0x0100
 )
 
 func win32_bindkey() {
@@ -29,6 +36,12 @@ func win32_bindkey() {
 		return
 	}
 	defer unregisterHotKey.Call(0, 1)
+	// Ctrl + Shift + Space opens quick capture with ID 2
+	if r, _, err := registerHotKey.Call(0, 2, MOD_CONTROL|MOD_SHIFT, VK_SPACE); r == 0 {
+		notesLog.Warnf("win32 Failed to register quick capture hotkey: %v", err)
+	} else {
+		defer unregisterHotKey.Call(0, 2)
+	}
 
 	log.Println("Hotkey registered. Press Ctrl + Space...")
 
@@ -47,8 +60,36 @@ func win32_bindkey() {
 			break // WM_QUIT
 		}
 		if msg.message == WM_HOTKEY {
+			if msg.wParam == 2 {
+				uiCmdChan <- "capture"
+				continue
+			}
 			log.Println("Ctrl + Space pressed!")
 			uiCmdChan <- "show"
 		}
 	}
 }
+
+// win32_isScreenLocked returns true when the input desktop is not the user desktop (lock screen / UAC)
+func win32_isScreenLocked() bool {
+	h, _, _ := openInputDesktop.Call(0, 0, DESKTOP_SWITCHDESKTOP)
+	if h == 0 {
+		return true
+	}
+	defer closeDesktop.Call(h)
+	r, _, _ := switchDesktop.Call(h)
+	return r == 0
+}
+
+// win32_watchScreenLock polls the input desktop, there is no session notification without a window handle
+func win32_watchScreenLock(onLock func()) {
+	locked := false
+	for {
+		now := win32_isScreenLocked()
+		if now && !locked {
+			onLock()
+		}
+		locked = now
+		time.Sleep(2 * time.Second)
+	}
+}
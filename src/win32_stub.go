@@ -9,3 +9,6 @@ func win32_bindkey() {
       // dummy version
 }
 
+func win32_watchScreenLock(onLock func()) {
+	// dummy version
+}